@@ -1,13 +1,26 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"fin-web/internal/controller"
 	"fin-web/internal/db"
+	"fin-web/internal/notify"
 )
 
+// shutdownTimeout is how long in-flight requests get to finish on shutdown.
+const shutdownTimeout = 30 * time.Second
+
 func main() {
 	dbPath := os.Getenv("DB_PATH")
 	tiingoToken := os.Getenv("TIINGO_TOKEN")
@@ -30,10 +43,75 @@ func main() {
 		log.Fatal(err.Error())
 	}
 
-	api := controller.NewController(DB, tiingoToken, port)
-
-	err = api.Server.ListenAndServe()
+	alerts, err := alertSettings()
 	if err != nil {
 		log.Fatal(err.Error())
 	}
+
+	api := controller.NewController(DB, tiingoToken, port, alerts)
+
+	go func() {
+		err := api.Server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	// On SIGINT or SIGTERM, finish in-flight requests, then send any alerts
+	// they queued before exiting.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := api.Server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("error shutting down: %v", err)
+	}
+	api.Close()
+}
+
+// alertSettings reads the optional alerting configuration. Email is sent when
+// SMTP_ADDR is set, and a webhook is called when ALERT_WEBHOOK_URL is.
+func alertSettings() (controller.AlertSettings, error) {
+	settings := controller.AlertSettings{
+		BaseURL: strings.TrimSuffix(os.Getenv("BASE_URL"), "/"),
+	}
+
+	if addr := os.Getenv("SMTP_ADDR"); addr != "" {
+		from := os.Getenv("SMTP_FROM")
+		to := strings.FieldsFunc(os.Getenv("SMTP_TO"), func(r rune) bool { return r == ',' || r == ' ' })
+		if from == "" || len(to) == 0 {
+			return settings, errors.New("SMTP_FROM and SMTP_TO are required with SMTP_ADDR")
+		}
+		settings.Notifiers = append(settings.Notifiers, notify.NewSMTPNotifier(
+			addr,
+			os.Getenv("SMTP_USERNAME"),
+			os.Getenv("SMTP_PASSWORD"),
+			from,
+			to,
+		))
+	}
+
+	if url := os.Getenv("ALERT_WEBHOOK_URL"); url != "" {
+		settings.Notifiers = append(settings.Notifiers, notify.NewWebhookNotifier(url))
+	}
+
+	if v := os.Getenv("ALERT_LARGE_TRANSACTION"); v != "" {
+		amount, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return settings, fmt.Errorf("ALERT_LARGE_TRANSACTION: %w", err)
+		}
+		settings.LargeTransaction = amount
+	}
+
+	if v := os.Getenv("ALERT_NET_WORTH_STALE_DAYS"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil {
+			return settings, fmt.Errorf("ALERT_NET_WORTH_STALE_DAYS: %w", err)
+		}
+		settings.NetWorthStaleDays = days
+	}
+
+	return settings, nil
 }
@@ -1,12 +1,25 @@
+// Command categories seeds categories and re-runs categorization.
+//
+//	go run ./cmd/categories                    # same as seed
+//	go run ./cmd/categories seed               # create categories from categories.json
+//	go run ./cmd/categories recategorize [-from YYYY-MM-DD] [-to YYYY-MM-DD] [-keep-manual=false] [-apply]
+//
+// recategorize prints the transactions whose category would change and only
+// writes them when -apply is given. Categories picked by hand are kept unless
+// -keep-manual=false.
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
+	"flag"
+	"fmt"
 	"log"
 	"os"
 
 	"fin-web/internal/db"
 	"fin-web/internal/model"
+	"fin-web/internal/rules"
 )
 
 func main() {
@@ -21,6 +34,22 @@ func main() {
 		log.Fatal(err.Error())
 	}
 
+	cmd := "seed"
+	if len(os.Args) > 1 {
+		cmd = os.Args[1]
+	}
+
+	switch cmd {
+	case "seed":
+		seed(transactionsDB)
+	case "recategorize":
+		recategorize(transactionsDB, os.Args[2:])
+	default:
+		log.Fatalf("unknown command %q (want seed or recategorize)", cmd)
+	}
+}
+
+func seed(transactionsDB *sql.DB) {
 	fileBytes, err := os.ReadFile("categories.json")
 	if err != nil {
 		log.Fatalf("Error reading file: %v", err)
@@ -61,3 +90,52 @@ func main() {
 		}
 	}
 }
+
+func recategorize(transactionsDB *sql.DB, args []string) {
+	fs := flag.NewFlagSet("recategorize", flag.ExitOnError)
+	from := fs.String("from", "", "first date to re-evaluate (YYYY-MM-DD)")
+	to := fs.String("to", "", "last date to re-evaluate (YYYY-MM-DD)")
+	keepManual := fs.Bool("keep-manual", true, "leave manually assigned categories alone")
+	apply := fs.Bool("apply", false, "write the changes instead of only listing them")
+	fs.Parse(args)
+
+	changes, err := rules.Plan(transactionsDB, rules.RecategorizeOptions{
+		StartDate:  *from,
+		EndDate:    *to,
+		KeepManual: *keepManual,
+	})
+	if err != nil {
+		log.Fatalf("Error planning recategorization: %v", err)
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("No transactions would change.")
+		return
+	}
+
+	fmt.Printf("%-10s %10s  %-30s %s\n", "DATE", "AMOUNT", "NAME", "CHANGE")
+	for _, c := range changes {
+		from := "Uncategorized"
+		if c.Transaction.CategoryID.Valid {
+			from = c.Transaction.CustomCategory.String
+		}
+		fmt.Printf("%-10s %10.2f  %-30.30s %s -> %s\n", c.Transaction.Date, c.Transaction.Amount, c.Transaction.Name, from, c.Category)
+	}
+
+	fmt.Println()
+	for _, m := range rules.Summarize(changes) {
+		fmt.Printf("%s -> %s: %d\n", m.From, m.To, m.Count)
+	}
+
+	if !*apply {
+		fmt.Println("\nDry run; pass -apply to write these changes.")
+		return
+	}
+
+	applied, err := rules.Apply(transactionsDB, changes)
+	if err != nil {
+		log.Fatalf("Error applying recategorization: %v", err)
+	}
+
+	fmt.Printf("\nRecategorized %d transactions.\n", applied)
+}
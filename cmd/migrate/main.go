@@ -0,0 +1,88 @@
+// Command migrate inspects and applies the embedded schema migrations.
+//
+//	go run ./cmd/migrate            # same as status
+//	go run ./cmd/migrate status     # list migrations and whether each is applied
+//	go run ./cmd/migrate up         # apply everything pending
+//	go run ./cmd/migrate to <N>     # apply pending migrations up to version N
+//
+// cmd/api and cmd/normalize already migrate on startup; this is for checking
+// what a database is at, or stepping it forward deliberately.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"fin-web/internal/db"
+)
+
+func main() {
+	dbPath := os.Getenv("DB_PATH")
+	if dbPath == "" {
+		log.Fatal("DB_PATH is required")
+	}
+
+	conn, err := db.Open(dbPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+
+	cmd := "status"
+	if len(os.Args) > 1 {
+		cmd = os.Args[1]
+	}
+
+	switch cmd {
+	case "status":
+		statuses, err := db.MigrationStatuses(conn)
+		printStatuses(statuses)
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "up":
+		ran, err := db.Migrate(conn)
+		printApplied(ran)
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "to":
+		if len(os.Args) < 3 {
+			log.Fatal("usage: migrate to <version>")
+		}
+		version, err := strconv.Atoi(os.Args[2])
+		if err != nil {
+			log.Fatalf("invalid version %q: %v", os.Args[2], err)
+		}
+		ran, err := db.MigrateTo(conn, version)
+		printApplied(ran)
+		if err != nil {
+			log.Fatal(err)
+		}
+	default:
+		log.Fatalf("unknown command %q (want status, up, or to <version>)", cmd)
+	}
+}
+
+func printStatuses(statuses []db.MigrationStatus) {
+	fmt.Printf("%-8s %-30s %-8s %s\n", "VERSION", "NAME", "STATE", "APPLIED AT")
+	for _, s := range statuses {
+		state := "pending"
+		if s.Applied {
+			state = "applied"
+		}
+		fmt.Printf("%-8d %-30s %-8s %s\n", s.Version, s.Name, state, s.AppliedAt)
+	}
+}
+
+func printApplied(ran []db.Migration) {
+	if len(ran) == 0 {
+		fmt.Println("Nothing to apply.")
+		return
+	}
+	for _, m := range ran {
+		fmt.Printf("Applied %d_%s\n", m.Version, m.Name)
+	}
+}
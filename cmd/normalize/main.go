@@ -6,7 +6,9 @@ import (
 
 	"fin-web/internal/bofa"
 	"fin-web/internal/citi"
+	"fin-web/internal/csvprofile"
 	"fin-web/internal/db"
+	"fin-web/internal/ofx"
 	"fin-web/internal/schwab"
 	"fin-web/internal/worker"
 )
@@ -33,12 +35,28 @@ func main() {
 	providers := []worker.Provider{
 		bofa.NewBofaProvider(DB),
 		citi.NewCitiProvider(DB),
+		ofx.NewOfxProvider(DB),
 		schwab.NewSchwabProvider(DB),
 	}
 
-	for _, p := range providers {
-		if err := bw.Process(p); err != nil {
-			log.Printf("Error processing provider %s: %v", p.GetPrefix(), err)
+	profiles, err := csvprofile.LoadProviders(DB)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	for _, p := range profiles {
+		providers = append(providers, p)
+	}
+
+	results, err := bw.Process(providers)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	for _, r := range results {
+		if r.Err != nil {
+			log.Printf("%s: batch=%d %v", r.File, r.BatchID, r.Err)
+			continue
 		}
+		log.Printf("%s: batch=%d inserted=%d duplicates=%d reconciled=%d rejected=%d", r.File, r.BatchID, r.Inserted, r.Duplicates, r.Reconciled, r.Rejected)
 	}
 }
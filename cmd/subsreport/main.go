@@ -2,7 +2,9 @@
 // detection. It runs the real internal/recurring detector over the live
 // database and prints what it flags, plus (with SHOW_RAW=1) the raw transaction
 // name variants behind each normalized merchant key — the ground truth for
-// tuning normalization. Run: `set -a; . ./.env; set +a; go run ./cmd/subsreport`.
+// tuning normalization. Decisions saved on /subscriptions are applied, so
+// ignored and merged keys show up the way the app shows them.
+// Run: `set -a; . ./.env; set +a; go run ./cmd/subsreport`.
 package main
 
 import (
@@ -36,16 +38,21 @@ func main() {
 		log.Fatal(err)
 	}
 
+	decisions, err := model.GetRecurringDecisions(conn)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	if os.Getenv("SHOW_RAW") != "" {
-		dumpRawVariants(charges, []string{
+		dumpRawVariants(charges, decisions, []string{
 			"SPOTIFY", "DIGITALOCEAN", "SHELL", "UBER", "AMAZON", "AMZN",
 			"NETFLIX", "HULU", "DISNEY", "FITNESS", "HOUR", "PEPCO",
 		})
 	}
 
-	report := recurring.Detect(charges, time.Now())
+	report := recurring.DetectWith(charges, time.Now(), decisions)
 
-	fmt.Printf("Scanned %d expense charges.\n", len(charges))
+	fmt.Printf("Scanned %d expense charges, %d saved decisions.\n", len(charges), len(decisions))
 	fmt.Printf("Thresholds: minOccurrences=%d gapCV<=%.2f amountCV<=%.2f billMonthly>=%.0f\n\n",
 		recurring.MinOccurrences, recurring.GapCVMax, recurring.AmountCVMax, recurring.BillMonthlyMin)
 
@@ -57,6 +64,7 @@ func main() {
 
 	printList("POSSIBLE (2 charges, low confidence)", report.Possible)
 	printList("LIKELY CANCELED", report.Canceled)
+	printList("IGNORED", report.Ignored)
 }
 
 func printList(title string, rs []recurring.Recurring) {
@@ -69,18 +77,18 @@ func printList(title string, rs []recurring.Recurring) {
 		"MERCHANT", "N", "CADENCE", "TYPICAL", "MONTHLY", "ANNUAL", "LAST", "NEXT", "AMOUNT")
 	for _, r := range rs {
 		fmt.Printf("%-30s %4d %-9s %8.2f %9.2f %9.2f  %-10s %-10s %s\n",
-			trunc(r.Merchant, 30), r.Count, r.Cadence, r.TypicalAmt, r.Monthly, r.Annual,
+			trunc(r.Name, 30), r.Count, r.Cadence, r.TypicalAmt, r.Monthly, r.Annual,
 			r.Last, r.Next, fixedLabel(r.AmountFixed))
 	}
 	fmt.Println()
 }
 
 // dumpRawVariants prints, for every merchant key containing any watch term, the
-// distinct raw transaction names that normalized into it.
-func dumpRawVariants(charges []recurring.Charge, watch []string) {
+// distinct raw transaction names that normalized or were merged into it.
+func dumpRawVariants(charges []recurring.Charge, decisions map[string]recurring.Decision, watch []string) {
 	raws := map[string]map[string]int{} // key -> raw name -> count
 	for _, c := range charges {
-		key := util.NormalizeMerchant(c.Name)
+		key := recurring.Resolve(util.NormalizeMerchant(c.Name), decisions)
 		if key == "" {
 			continue
 		}
@@ -2,20 +2,21 @@ const filterBtn = document.getElementById('filter-transactions');
 
 if (filterBtn) {
   filterBtn.addEventListener('click', () => {
+    const search = document.getElementById('search').value.trim();
     const startDate = document.getElementById('startDate').value;
     const endDate = document.getElementById('endDate').value;
     const sortBy = document.getElementById('sortBy').value;
     const sortDirection = document.getElementById('sortDirection').value;
-    const categoryOptions = document.getElementById('categories').options;
-
-    const categories = [];
-    for (let i = 0; i < categoryOptions.length; i++) {
-      if (categoryOptions[i].selected) {
-        categories.push(categoryOptions[i].value);
-      }
-    }
+    const selected = (id) =>
+      Array.from(document.getElementById(id).options)
+        .filter((o) => o.selected)
+        .map((o) => o.value);
+    const categories = selected('categories');
 
     const p = new URLSearchParams(location.search);
+    // New filters start again from the first page.
+    p.delete('after');
+    p.delete('before');
 
     if (startDate) p.set('startDate', startDate);
     if (endDate) p.set('endDate', endDate);
@@ -27,8 +28,32 @@ if (filterBtn) {
       p.delete('categories');
     }
 
+    const setOrDelete = (key, value) => {
+      if (value) {
+        p.set(key, value);
+      } else {
+        p.delete(key);
+      }
+    };
+
+    setOrDelete('q', search);
+    setOrDelete('merchant', document.getElementById('merchant').value.trim());
+    setOrDelete('minAmount', document.getElementById('minAmount').value);
+    setOrDelete('maxAmount', document.getElementById('maxAmount').value);
+    setOrDelete('accounts', selected('accounts').join(','));
+    setOrDelete('sources', selected('sources').join(','));
+    setOrDelete('reimbursement', document.getElementById('reimbursement').value);
+
     window.location = `${location.origin}?${p.toString()}`;
   });
+
+  for (const id of ['search', 'merchant', 'minAmount', 'maxAmount']) {
+    document.getElementById(id).addEventListener('keydown', (e) => {
+      if (e.key === 'Enter') {
+        filterBtn.click();
+      }
+    });
+  }
 }
 
 const formatter = new Intl.NumberFormat('en-US', {
@@ -42,3 +67,64 @@ for (let i = 0; i < currencyElements.length; i++) {
     currencyElements[i].textContent
   );
 }
+
+const alertFeed = document.getElementById('alert-feed');
+
+if (alertFeed) {
+  const alertCount = document.getElementById('alert-count');
+  const alertList = document.getElementById('alert-list');
+
+  const renderAlerts = (feed) => {
+    alertCount.textContent = feed.unread;
+    alertCount.classList.toggle('hide', feed.unread === 0);
+
+    alertList.replaceChildren();
+    if (!feed.notifications.length) {
+      const li = document.createElement('li');
+      li.textContent = 'No alerts';
+      alertList.append(li);
+      return;
+    }
+
+    for (const n of feed.notifications) {
+      const li = document.createElement('li');
+      li.classList.toggle('unread', !n.read);
+
+      const message = document.createElement(n.link ? 'a' : 'span');
+      message.textContent = n.message;
+      if (n.link) message.href = n.link;
+      li.append(message);
+
+      if (!n.read) {
+        const btn = document.createElement('button');
+        btn.type = 'button';
+        btn.className = 'btn btn-secondary';
+        btn.textContent = 'Mark read';
+        btn.addEventListener('click', () => markRead([n.id]));
+        li.append(btn);
+      }
+
+      alertList.append(li);
+    }
+  };
+
+  // An empty ids list marks every alert read.
+  const markRead = async (ids) => {
+    const resp = await fetch('/notifications/read', {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ ids }),
+    });
+    if (resp.ok) {
+      renderAlerts(await resp.json());
+    }
+  };
+
+  document
+    .getElementById('alerts-read-all')
+    .addEventListener('click', () => markRead([]));
+
+  fetch('/notifications')
+    .then((resp) => (resp.ok ? resp.json() : null))
+    .then((feed) => feed && renderAlerts(feed));
+}
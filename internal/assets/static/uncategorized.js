@@ -0,0 +1,90 @@
+const form = document.getElementById('bulk-form');
+const count = document.getElementById('bulk-count');
+const selectAll = document.getElementById('select-all');
+const rows = document.querySelectorAll('.select-row');
+
+function selectedIDs() {
+  return [...rows].filter((r) => r.checked).map((r) => r.value);
+}
+
+function updateCount() {
+  count.textContent = selectedIDs().length;
+}
+
+selectAll.addEventListener('change', () => {
+  for (const r of rows) {
+    r.checked = selectAll.checked;
+  }
+  updateCount();
+});
+
+for (const r of rows) {
+  r.addEventListener('change', updateCount);
+}
+
+async function submit(body) {
+  // clear errors on submit
+  for (const el of form.querySelectorAll('.form-error')) {
+    el.textContent = '';
+  }
+
+  const resp = await fetch('/transactions/bulk', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ ids: selectedIDs(), ...body }),
+  });
+  const data = await resp.json();
+
+  if (resp.ok) {
+    window.location.reload();
+    return;
+  }
+
+  if (data.errs) {
+    for (const [key, err] of Object.entries(data.errs)) {
+      const el = form.querySelector(`.${key}-err`);
+      if (el) {
+        el.textContent = err;
+      }
+    }
+  }
+}
+
+form.addEventListener('submit', async (e) => {
+  e.preventDefault();
+
+  const body = {};
+  const category = form.querySelector('select[name="category"]').value;
+  if (category) {
+    body.category_id = Number(category);
+  }
+
+  const isReimbursement = form.querySelector(
+    'select[name="is_reimbursement"]'
+  ).value;
+  if (isReimbursement) {
+    body.is_reimbursement = isReimbursement === 'true';
+  }
+
+  const description = form.querySelector('input[name="description"]').value;
+  if (description) {
+    body.description = description;
+  }
+
+  body.create_category_values = form.querySelector(
+    'input[name="create_category_values"]'
+  ).checked;
+
+  await submit(body);
+});
+
+document.getElementById('bulk-delete').addEventListener('click', async (e) => {
+  e.preventDefault();
+
+  const ids = selectedIDs();
+  if (!confirm(`Delete ${ids.length} transactions?`)) {
+    return;
+  }
+
+  await submit({ delete: true });
+});
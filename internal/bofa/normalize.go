@@ -28,6 +28,17 @@ func (p *Provider) GetPrefix() string {
 	return "bofa"
 }
 
+const columnHeader = "Date,Reference Number,Payee,Address,Amount"
+
+// Detect claims files whose first line is the BofA column header.
+func (p *Provider) Detect(header []byte) int {
+	firstLine, _, _ := strings.Cut(string(header), "\n")
+	if strings.TrimSpace(firstLine) == columnHeader {
+		return 100
+	}
+	return 0
+}
+
 func (p *Provider) ParseFile(filePath string) ([]model.Transaction, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -1,6 +1,7 @@
 package bofa
 
 import (
+	"os"
 	"testing"
 
 	"fin-web/internal/testutil"
@@ -51,3 +52,13 @@ func TestParseFileMissingFile(t *testing.T) {
 func TestGetPrefix(t *testing.T) {
 	assert.Equal(t, "bofa", NewBofaProvider(nil).GetPrefix())
 }
+
+func TestDetect(t *testing.T) {
+	p := NewBofaProvider(nil)
+
+	header, err := os.ReadFile("testdata/sample.csv")
+	require.NoError(t, err)
+	assert.Equal(t, 100, p.Detect(header))
+
+	assert.Equal(t, 0, p.Detect([]byte("Citi Account Statement\nAccount: ****1234\n")))
+}
@@ -28,6 +28,18 @@ func (p *Provider) GetPrefix() string {
 	return "From"
 }
 
+const columnHeader = "Date,Description,Debit,Credit,Category"
+
+// Detect claims files with the Citi column header after the 5 preamble lines
+// ParseFile skips.
+func (p *Provider) Detect(header []byte) int {
+	lines := strings.SplitN(string(header), "\n", 7)
+	if len(lines) > 5 && strings.TrimSpace(lines[5]) == columnHeader {
+		return 100
+	}
+	return 0
+}
+
 func (p *Provider) ParseFile(filePath string) ([]model.Transaction, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
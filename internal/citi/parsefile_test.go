@@ -1,6 +1,7 @@
 package citi
 
 import (
+	"os"
 	"testing"
 
 	"fin-web/internal/testutil"
@@ -46,3 +47,13 @@ func TestParseFileMissingFile(t *testing.T) {
 func TestGetPrefix(t *testing.T) {
 	assert.Equal(t, "From", NewCitiProvider(nil).GetPrefix())
 }
+
+func TestDetect(t *testing.T) {
+	p := NewCitiProvider(nil)
+
+	header, err := os.ReadFile("testdata/sample.csv")
+	require.NoError(t, err)
+	assert.Equal(t, 100, p.Detect(header))
+
+	assert.Equal(t, 0, p.Detect([]byte("Date,Reference Number,Payee,Address,Amount\n02/04/2026,REF001,X,,-5.75\n")))
+}
@@ -0,0 +1,250 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"fin-web/internal/model"
+	"fin-web/internal/notify"
+	"fin-web/internal/recurring"
+)
+
+const (
+	defaultLargeTransaction  = 500.0
+	defaultNetWorthStaleDays = 35
+	// notifyTimeout bounds how long an alert may take to reach every
+	// notifier.
+	notifyTimeout = 10 * time.Second
+)
+
+// alert is a notification raised by an alert rule. Date is the day what it
+// is about happened, such as a charge, or empty when it is about now.
+type alert struct {
+	model.Notification
+	Date string
+}
+
+// AlertSettings configures the alert rules and where alerts are sent besides
+// the header feed. Zero thresholds use the defaults.
+type AlertSettings struct {
+	Notifiers []notify.Notifier
+	// BaseURL, such as http://fin.local:3000, makes alert links absolute for
+	// notifiers. Without it they are sent as paths.
+	BaseURL string
+	// LargeTransaction is the amount, either way, at which an imported
+	// transaction is alerted on.
+	LargeTransaction float64
+	// NetWorthStaleDays is how old the latest net worth snapshot may get
+	// before an alert asks for a new one.
+	NetWorthStaleDays int
+}
+
+func (s AlertSettings) largeTransaction() float64 {
+	if s.LargeTransaction > 0 {
+		return s.LargeTransaction
+	}
+	return defaultLargeTransaction
+}
+
+func (s AlertSettings) netWorthStaleDays() int {
+	if s.NetWorthStaleDays > 0 {
+		return s.NetWorthStaleDays
+	}
+	return defaultNetWorthStaleDays
+}
+
+// recordAlerts runs every alert rule, with the import rules over the given
+// import batches, and raises what they find. The very first run only fills
+// the feed: what it finds was already there before alerts were switched on.
+func (c *Controller) recordAlerts(now time.Time, batchIDs []int) error {
+	since, first, err := model.NotifySince(c.db, now)
+	if err != nil {
+		return err
+	}
+	sendFrom := since.Local().Format("2006-01-02")
+	if first {
+		sendFrom = ""
+	}
+
+	rules := []func() ([]alert, error){
+		func() ([]alert, error) { return c.recurringAlerts(now) },
+		func() ([]alert, error) { return c.budgetAlerts(now) },
+		func() ([]alert, error) { return c.netWorthAlerts(now) },
+		func() ([]alert, error) { return c.importAlerts(batchIDs) },
+	}
+
+	for _, rule := range rules {
+		alerts, err := rule()
+		if err != nil {
+			return err
+		}
+		if err := c.raise(alerts, sendFrom); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// raise stores each alert in the feed and queues the ones not raised before,
+// and dated on or after sendFrom, for the notifiers. An empty sendFrom sends
+// nothing. Failing to send is only logged; the alert is still in the feed.
+func (c *Controller) raise(alerts []alert, sendFrom string) error {
+	for _, a := range alerts {
+		created, err := model.CreateNotification(c.db, a.Notification)
+		if err != nil {
+			return err
+		}
+		if !created || c.alertQueue == nil || sendFrom == "" || (a.Date != "" && a.Date < sendFrom) {
+			continue
+		}
+
+		msg := notify.Message{
+			Kind:      a.Kind,
+			Text:      a.Message,
+			CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		}
+		if a.Link != "" {
+			msg.Link = c.alerts.BaseURL + a.Link
+		}
+
+		if !c.alertQueue.Enqueue(msg) {
+			log.Printf("error sending alert %s: the queue is full", a.DedupeKey)
+		}
+	}
+
+	return nil
+}
+
+func (c *Controller) recurringAlerts(now time.Time) ([]alert, error) {
+	charges, err := model.RecurringCandidates(c.db)
+	if err != nil {
+		return nil, err
+	}
+
+	decisions, err := model.GetRecurringDecisions(c.db)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := []alert{}
+	for _, change := range recurring.DetectChanges(charges, now, decisions) {
+		alerts = append(alerts, alert{
+			Notification: model.Notification{
+				Kind:      change.Kind,
+				DedupeKey: "recurring:" + change.Key(),
+				Message:   change.Message(),
+				Link:      "/subscriptions",
+			},
+			Date: change.Date,
+		})
+	}
+
+	return alerts, nil
+}
+
+// budgetAlerts flags each budget already overspent this month, once per
+// budget and month.
+func (c *Controller) budgetAlerts(now time.Time) ([]alert, error) {
+	month, _ := getStartAndEndOfMonth(now)
+	rows, err := c.budgetRows(month, now)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := []alert{}
+	for _, row := range rows {
+		if !row.Over {
+			continue
+		}
+		alerts = append(alerts, alert{Notification: model.Notification{
+			Kind:      "budget_over",
+			DedupeKey: fmt.Sprintf("budget-over:%d:%s", row.Budget.CategoryID, month.Format(monthLayout)),
+			Message:   fmt.Sprintf("%s is over budget for %s: %.2f spent of %.2f", row.Budget.CategoryLabel, month.Format(monthLayout), row.Spent, row.Available),
+			Link:      "/budgets?month=" + month.Format(monthLayout),
+		}})
+	}
+
+	return alerts, nil
+}
+
+// netWorthAlerts asks for a new net worth snapshot once the latest is
+// older than the stale threshold. Without any snapshots there is nothing to
+// keep up to date.
+func (c *Controller) netWorthAlerts(now time.Time) ([]alert, error) {
+	items, err := model.QueryNetWorthItems(c.db, model.QueryNetWorthItemsFilters{
+		OrderBy:        "date",
+		OrderDirection: "DESC",
+		Limit:          1,
+	})
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+
+	latest, err := time.ParseInLocation("2006-01-02", items[0].Date, time.Local)
+	if err != nil {
+		return nil, err
+	}
+
+	days := int(math.Floor(now.Sub(latest).Hours() / 24))
+	if days <= c.alerts.netWorthStaleDays() {
+		return nil, nil
+	}
+
+	return []alert{{Notification: model.Notification{
+		Kind:      "net_worth_stale",
+		DedupeKey: "net-worth-stale:" + items[0].Date,
+		Message:   fmt.Sprintf("Net worth was last recorded on %s, %d days ago", items[0].Date, days),
+		Link:      "/net-worth/new",
+	}}}, nil
+}
+
+// importAlerts looks over what the given import batches inserted for large
+// transactions and rows left uncategorized. Large transactions are dated, so
+// importing old statements doesn't send alerts about old charges.
+func (c *Controller) importAlerts(batchIDs []int) ([]alert, error) {
+	alerts := []alert{}
+	for _, id := range batchIDs {
+		batch, err := model.GetImportBatch(c.db, strconv.Itoa(id))
+		if err != nil {
+			return nil, err
+		}
+
+		transactions, err := model.GetImportBatchTransactions(c.db, strconv.Itoa(id))
+		if err != nil {
+			return nil, err
+		}
+
+		uncategorized := 0
+		for _, t := range transactions {
+			if !t.CategoryID.Valid {
+				uncategorized++
+			}
+			if math.Abs(t.Amount) >= c.alerts.largeTransaction() {
+				alerts = append(alerts, alert{
+					Notification: model.Notification{
+						Kind:      "large_transaction",
+						DedupeKey: "large-transaction:" + t.ID,
+						Message:   fmt.Sprintf("Large transaction: %s %.2f on %s", t.Name, t.Amount, t.Date),
+						Link:      "/transactions/" + t.ID,
+					},
+					Date: t.Date,
+				})
+			}
+		}
+
+		if uncategorized > 0 {
+			alerts = append(alerts, alert{Notification: model.Notification{
+				Kind:      "uncategorized",
+				DedupeKey: fmt.Sprintf("uncategorized:batch:%d", id),
+				Message:   fmt.Sprintf("%d new uncategorized transactions from %s", uncategorized, batch.FileName),
+				Link:      "/transactions/uncategorized",
+			}})
+		}
+	}
+
+	return alerts, nil
+}
@@ -0,0 +1,220 @@
+package controller
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"fin-web/internal/model"
+	"fin-web/internal/notify"
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookReceiver collects what a WebhookNotifier posts to it.
+type webhookReceiver struct {
+	mu       sync.Mutex
+	messages []notify.Message
+}
+
+func startWebhookReceiver(t *testing.T) (*webhookReceiver, *httptest.Server) {
+	t.Helper()
+
+	rcv := &webhookReceiver{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg notify.Message
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rcv.mu.Lock()
+		rcv.messages = append(rcv.messages, msg)
+		rcv.mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+
+	return rcv, srv
+}
+
+func (rcv *webhookReceiver) kinds() map[string]int {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+
+	kinds := map[string]int{}
+	for _, msg := range rcv.messages {
+		kinds[msg.Kind]++
+	}
+	return kinds
+}
+
+// newAlertController is a controller sending alerts to the notifiers in the
+// background, as NewController sets it up.
+func newAlertController(t *testing.T, db *sql.DB, alerts AlertSettings) *Controller {
+	t.Helper()
+
+	c := &Controller{db: db, alerts: alerts, alertQueue: notify.NewQueue(alerts.Notifiers, notifyTimeout)}
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestRecordAlerts(t *testing.T) {
+	db := testutil.NewDB(t)
+	now := time.Now()
+	today := now.Format("2006-01-02")
+
+	rcv, srv := startWebhookReceiver(t)
+	c := newAlertController(t, db, AlertSettings{
+		Notifiers:        []notify.Notifier{notify.NewWebhookNotifier(srv.URL)},
+		BaseURL:          "http://fin.local",
+		LargeTransaction: 1000,
+	})
+
+	// Alerts were switched on before any of this happened.
+	require.NoError(t, c.recordAlerts(now, nil))
+
+	// Dining is over its budget this month.
+	dining := mustCreateCategory(t, db, "dining", 1, "fun")
+	_, err := model.SetBudget(db, model.Budget{CategoryID: dining, Amount: 100, StartMonth: now.Format(monthLayout)})
+	require.NoError(t, err)
+	seedTransaction(t, db, "dinner", "FANCY PLACE", 150, today, catID(dining))
+
+	// The last net worth snapshot is two months old.
+	_, err = model.CreateNetWorthItem(db, fullNetWorthParams(now.AddDate(0, -2, 0).Format("2006-01-02"), 1000))
+	require.NoError(t, err)
+
+	// An import brought in a large uncategorized charge and a small one.
+	batchID, err := model.CreateImportBatch(db, model.ImportBatch{FileName: "june.csv", Provider: "citi", Status: model.ImportBatchCompleted})
+	require.NoError(t, err)
+	for _, tx := range []model.Transaction{
+		{ID: "tv", Name: "BEST BUY", Amount: 1499, Date: today},
+		{ID: "gum", Name: "CVS", Amount: 2.50, Date: today},
+	} {
+		tx.Source, tx.Account = "citi", "citi"
+		tx.BatchID = sql.NullInt64{Valid: true, Int64: int64(batchID)}
+		require.NoError(t, model.CreateTransaction(db, tx))
+	}
+
+	require.NoError(t, c.recordAlerts(now, []int{batchID}))
+
+	// Running the rules again raises nothing new.
+	require.NoError(t, c.recordAlerts(now, []int{batchID}))
+	c.Close()
+
+	notifications, err := model.GetNotifications(db, 10)
+	require.NoError(t, err)
+	messages := map[string]string{}
+	for _, n := range notifications {
+		messages[n.Kind] = n.Message
+	}
+	assert.Equal(t, map[string]string{
+		"budget_over":       "dining is over budget for " + now.Format(monthLayout) + ": 150.00 spent of 100.00",
+		"net_worth_stale":   messages["net_worth_stale"],
+		"large_transaction": "Large transaction: BEST BUY 1499.00 on " + today,
+		"uncategorized":     "2 new uncategorized transactions from june.csv",
+	}, messages)
+	assert.Contains(t, messages["net_worth_stale"], "Net worth was last recorded on "+now.AddDate(0, -2, 0).Format("2006-01-02"))
+
+	assert.Equal(t, map[string]int{
+		"budget_over":       1,
+		"net_worth_stale":   1,
+		"large_transaction": 1,
+		"uncategorized":     1,
+	}, rcv.kinds())
+	for _, msg := range rcv.messages {
+		if msg.Kind == "large_transaction" {
+			assert.Equal(t, "http://fin.local/transactions/tv", msg.Link)
+		}
+	}
+}
+
+func TestRecordAlertsFirstRunOnlyFillsFeed(t *testing.T) {
+	db := testutil.NewDB(t)
+	now := time.Now()
+
+	dining := mustCreateCategory(t, db, "dining", 1, "fun")
+	_, err := model.SetBudget(db, model.Budget{CategoryID: dining, Amount: 100, StartMonth: now.Format(monthLayout)})
+	require.NoError(t, err)
+	seedTransaction(t, db, "dinner", "FANCY PLACE", 150, now.Format("2006-01-02"), catID(dining))
+
+	rcv, srv := startWebhookReceiver(t)
+	c := newAlertController(t, db, AlertSettings{
+		Notifiers:        []notify.Notifier{notify.NewWebhookNotifier(srv.URL)},
+		LargeTransaction: 1000,
+	})
+
+	// The first run finds what was already there.
+	require.NoError(t, c.recordAlerts(now, nil))
+	notifications, err := model.GetNotifications(db, 10)
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, "budget_over", notifications[0].Kind)
+
+	// An import of last year's statement is only sent about as a batch.
+	batchID, err := model.CreateImportBatch(db, model.ImportBatch{FileName: "old.csv", Provider: "citi", Status: model.ImportBatchCompleted})
+	require.NoError(t, err)
+	for _, tx := range []model.Transaction{
+		{ID: "old-tv", Name: "BEST BUY", Amount: 1499, Date: now.AddDate(-1, 0, 0).Format("2006-01-02")},
+		{ID: "new-tv", Name: "COSTCO", Amount: 1299, Date: now.Format("2006-01-02")},
+	} {
+		tx.Source, tx.Account = "citi", "citi"
+		tx.BatchID = sql.NullInt64{Valid: true, Int64: int64(batchID)}
+		require.NoError(t, model.CreateTransaction(db, tx))
+	}
+	require.NoError(t, c.recordAlerts(now, []int{batchID}))
+	c.Close()
+
+	notifications, err = model.GetNotifications(db, 10)
+	require.NoError(t, err)
+	assert.Len(t, notifications, 4, "the feed has everything")
+
+	assert.Equal(t, map[string]int{"large_transaction": 1, "uncategorized": 1}, rcv.kinds())
+	for _, msg := range rcv.messages {
+		if msg.Kind == "large_transaction" {
+			assert.Equal(t, "/transactions/new-tv", msg.Link)
+		}
+	}
+}
+
+func TestRecordAlertsQuiet(t *testing.T) {
+	db := testutil.NewDB(t)
+	now := time.Now()
+
+	dining := mustCreateCategory(t, db, "dining", 1, "fun")
+	_, err := model.SetBudget(db, model.Budget{CategoryID: dining, Amount: 100, StartMonth: now.Format(monthLayout)})
+	require.NoError(t, err)
+	seedTransaction(t, db, "lunch", "CAFE", 20, now.Format("2006-01-02"), catID(dining))
+
+	_, err = model.CreateNetWorthItem(db, fullNetWorthParams(now.AddDate(0, 0, -3).Format("2006-01-02"), 1000))
+	require.NoError(t, err)
+
+	// A notifier that always fails doesn't stop alerts reaching the feed.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := newAlertController(t, db, AlertSettings{
+		Notifiers: []notify.Notifier{notify.NewWebhookNotifier(srv.URL)},
+	})
+	require.NoError(t, c.recordAlerts(now, nil))
+
+	notifications, err := model.GetNotifications(db, 10)
+	require.NoError(t, err)
+	assert.Empty(t, notifications)
+
+	_, err = model.CreateNetWorthItem(db, fullNetWorthParams(now.AddDate(0, 0, -60).Format("2006-01-02"), 1000))
+	require.NoError(t, err)
+	seedTransaction(t, db, "feast", "BANQUET", 200, now.Format("2006-01-02"), catID(dining))
+	require.NoError(t, c.recordAlerts(now, nil))
+
+	notifications, err = model.GetNotifications(db, 10)
+	require.NoError(t, err)
+	require.Len(t, notifications, 1, "only the budget; the latest snapshot is still recent")
+	assert.Equal(t, "budget_over", notifications[0].Kind)
+}
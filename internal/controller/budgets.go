@@ -0,0 +1,339 @@
+package controller
+
+import (
+	"database/sql"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"fin-web/internal/model"
+)
+
+const monthLayout = "2006-01"
+
+type BudgetFormData struct {
+	CategoryID string
+	Amount     string
+	Rollover   bool
+	StartMonth string
+}
+
+// BudgetRow is one budget against the month's spending. Carry is what earlier
+// months rolled into this one, negative after overspending; Available is the
+// budget plus Carry.
+type BudgetRow struct {
+	Budget    model.Budget
+	Carry     float64
+	Available float64
+	Spent     float64
+	Projected float64
+	// Percent is Spent as a share of Available, capped at 100 for the bar.
+	Percent       int
+	Over          bool
+	ProjectedOver bool
+}
+
+func (r BudgetRow) Remaining() float64 {
+	return r.Available - r.Spent
+}
+
+type BudgetsPage struct {
+	Month      string
+	StartDate  string
+	EndDate    string
+	PrevMonth  string
+	NextMonth  string
+	Rows       []BudgetRow
+	Available  float64
+	Spent      float64
+	Projected  float64
+	Categories []model.Category
+	Form       BudgetFormData
+	Errs       map[string]string
+}
+
+func (c *Controller) renderBudgets(w http.ResponseWriter, month time.Time, form BudgetFormData, errs map[string]string) error {
+	rows, err := c.budgetRows(month, time.Now())
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error computing budgets: " + err.Error(),
+		}
+	}
+
+	cs, err := model.GetCategories(c.db)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error fetching categories: " + err.Error(),
+		}
+	}
+
+	start, end := getStartAndEndOfMonth(month)
+	page := BudgetsPage{
+		Month:      month.Format(monthLayout),
+		StartDate:  start.Format("2006-01-02"),
+		EndDate:    end.Format("2006-01-02"),
+		PrevMonth:  month.AddDate(0, -1, 0).Format(monthLayout),
+		NextMonth:  month.AddDate(0, 1, 0).Format(monthLayout),
+		Rows:       rows,
+		Categories: spendingCategories(cs),
+		Form:       form,
+		Errs:       errs,
+	}
+	for _, row := range rows {
+		page.Available += row.Available
+		page.Spent += row.Spent
+		page.Projected += row.Projected
+	}
+
+	err = renderTemplate(w, Base[BudgetsPage]{
+		Data: page,
+	}, "layout", []string{"budgets.html", "layout.html"})
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+// budgetRows compares every budget that has started by month with spending
+// in it. Spending is the expenses side of CategoryCounts, so splits,
+// reimbursements and transfers count the same way they do on the home page.
+func (c *Controller) budgetRows(month time.Time, today time.Time) ([]BudgetRow, error) {
+	budgets, err := model.GetBudgets(c.db)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := getStartAndEndOfMonth(month)
+	spent, err := categorySpend(c.db, model.QueryTransactionsFilters{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []BudgetRow{}
+	for _, b := range budgets {
+		if b.StartMonth > month.Format(monthLayout) {
+			continue
+		}
+
+		row := BudgetRow{
+			Budget: b,
+			Spent:  spent[b.CategoryID],
+		}
+
+		if b.Rollover {
+			row.Carry, err = c.budgetCarry(b, start)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		row.Available = b.Amount + row.Carry
+		row.Projected = projectedSpend(row.Spent, month, today)
+		row.Over = row.Spent > row.Available
+		row.ProjectedOver = row.Projected > row.Available
+		switch {
+		case row.Over:
+			row.Percent = 100
+		case row.Available > 0:
+			row.Percent = int(math.Round(row.Spent / row.Available * 100))
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// budgetCarry is what b left unspent, or overspent, in the whole months from
+// its start month up to monthStart.
+func (c *Controller) budgetCarry(b model.Budget, monthStart time.Time) (float64, error) {
+	from, err := time.ParseInLocation(monthLayout, b.StartMonth, time.Local)
+	if err != nil {
+		return 0, err
+	}
+
+	months := monthsBetween(from, monthStart)
+	if months <= 0 {
+		return 0, nil
+	}
+
+	spent, err := categorySpend(c.db, model.QueryTransactionsFilters{
+		StartDate:  from.Format("2006-01-02"),
+		EndDate:    monthStart.AddDate(0, 0, -1).Format("2006-01-02"),
+		Categories: []string{strconv.Itoa(b.CategoryID)},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return b.Amount*float64(months) - spent[b.CategoryID], nil
+}
+
+// categorySpend returns expenses per category ID for filters.
+func categorySpend(conn *sql.DB, filters model.QueryTransactionsFilters) (map[int]float64, error) {
+	filters.Type = "expenses"
+	counts, err := model.CategoryCounts(conn, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	spent := map[int]float64{}
+	for _, count := range counts {
+		spent[count.ID] = count.Value
+	}
+
+	return spent, nil
+}
+
+// projectedSpend extends spending so far in the current month at the same
+// daily rate to the month's end. Other months are already as spent as they
+// will be.
+func projectedSpend(spent float64, month time.Time, today time.Time) float64 {
+	if today.Year() != month.Year() || today.Month() != month.Month() {
+		return spent
+	}
+
+	_, end := getStartAndEndOfMonth(month)
+	return spent / float64(today.Day()) * float64(end.Day())
+}
+
+func monthsBetween(from time.Time, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
+
+// spendingCategories leaves out income and ignored categories, which can't
+// be budgeted for.
+func spendingCategories(cs []model.Category) []model.Category {
+	spending := []model.Category{}
+	for _, category := range cs {
+		if category.IsIgnored || category.Type.String == "income" {
+			continue
+		}
+		spending = append(spending, category)
+	}
+	return spending
+}
+
+// parseMonth reads a YYYY-MM query value, defaulting to the current month.
+func parseMonth(value string) (time.Time, error) {
+	if value == "" {
+		start, _ := getStartAndEndOfMonth(time.Now())
+		return start, nil
+	}
+
+	month, err := time.ParseInLocation(monthLayout, value, time.Local)
+	if err != nil {
+		return time.Time{}, APIError{
+			Status:  http.StatusBadRequest,
+			Message: "month must look like 2006-01",
+		}
+	}
+
+	return month, nil
+}
+
+func (c *Controller) budgets(w http.ResponseWriter, r *http.Request) error {
+	month, err := parseMonth(r.URL.Query().Get("month"))
+	if err != nil {
+		return err
+	}
+
+	return c.renderBudgets(w, month, BudgetFormData{StartMonth: month.Format(monthLayout)}, nil)
+}
+
+func (c *Controller) validateBudgetForm(r *http.Request) (BudgetFormData, model.Budget, map[string]string, error) {
+	errs := map[string]string{}
+	form := BudgetFormData{
+		CategoryID: r.FormValue("category"),
+		Amount:     strings.TrimSpace(r.FormValue("amount")),
+		Rollover:   r.FormValue("rollover") == "on",
+		StartMonth: strings.TrimSpace(r.FormValue("start_month")),
+	}
+	budget := model.Budget{
+		Rollover:   form.Rollover,
+		StartMonth: form.StartMonth,
+	}
+
+	var err error
+	budget.CategoryID, err = strconv.Atoi(form.CategoryID)
+	if err != nil {
+		errs["category"] = "pick a category"
+	} else {
+		cs, err := model.GetCategories(c.db)
+		if err != nil {
+			return form, budget, errs, APIError{
+				Status:  http.StatusInternalServerError,
+				Message: "error fetching categories: " + err.Error(),
+			}
+		}
+
+		if !slices.ContainsFunc(spendingCategories(cs), func(category model.Category) bool {
+			return category.ID == budget.CategoryID
+		}) {
+			errs["category"] = "pick a spending category"
+		}
+	}
+
+	budget.Amount, err = strconv.ParseFloat(form.Amount, 64)
+	if err != nil || budget.Amount < 0 {
+		errs["amount"] = "amount must be a number of at least 0"
+	}
+
+	if _, err := time.Parse(monthLayout, form.StartMonth); err != nil {
+		errs["start_month"] = "start month must look like 2006-01"
+	}
+
+	return form, budget, errs, nil
+}
+
+// setBudget creates or replaces the budget for the submitted category.
+func (c *Controller) setBudget(w http.ResponseWriter, r *http.Request) error {
+	month, err := parseMonth(r.URL.Query().Get("month"))
+	if err != nil {
+		return err
+	}
+
+	form, budget, errs, err := c.validateBudgetForm(r)
+	if err != nil {
+		return err
+	}
+	if len(errs) != 0 {
+		return c.renderBudgets(w, month, form, errs)
+	}
+
+	_, err = model.SetBudget(c.db, budget)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error saving budget: " + err.Error(),
+		}
+	}
+
+	http.Redirect(w, r, "/budgets?month="+month.Format(monthLayout), http.StatusSeeOther)
+	return nil
+}
+
+func (c *Controller) deleteBudget(w http.ResponseWriter, r *http.Request) error {
+	err := model.DeleteBudget(c.db, r.PathValue("id"))
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error deleting budget: " + err.Error(),
+		}
+	}
+
+	http.Redirect(w, r, "/budgets", http.StatusSeeOther)
+	return nil
+}
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"fin-web/internal/model"
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetBudgetAndRollover(t *testing.T) {
+	db := testutil.NewDB(t)
+	dining := mustCreateCategory(t, db, "Dining", 1, "fun")
+	rent := mustCreateCategory(t, db, "Rent", 2, "fixed")
+	seedTransaction(t, db, "jan-dinner", "NOPA", 250, "2026-01-10", catID(dining))
+	seedTransaction(t, db, "feb-dinner", "NOPA", 350, "2026-02-10", catID(dining))
+	seedTransaction(t, db, "mar-dinner", "NOPA", 120, "2026-03-05", catID(dining))
+	seedTransaction(t, db, "mar-rent", "RENT", 2100, "2026-03-01", catID(rent))
+	c := &Controller{db: db}
+
+	for _, form := range []url.Values{
+		{"category": {strconv.Itoa(dining)}, "amount": {"300"}, "rollover": {"on"}, "start_month": {"2026-01"}},
+		{"category": {strconv.Itoa(rent)}, "amount": {"2000"}, "start_month": {"2026-01"}},
+	} {
+		rec := httptest.NewRecorder()
+		require.NoError(t, c.setBudget(rec, newFormRequest("/budgets?month=2026-03", form)))
+		assert.Equal(t, http.StatusSeeOther, rec.Code)
+		assert.Equal(t, "/budgets?month=2026-03", rec.Header().Get("Location"))
+	}
+
+	rows, err := c.budgetRows(time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local), time.Date(2026, 4, 2, 0, 0, 0, 0, time.Local))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	// Dining carries +50 from January and -50 from February.
+	assert.InDelta(t, 0, rows[0].Carry, 1e-9)
+	assert.InDelta(t, 300, rows[0].Available, 1e-9)
+	assert.InDelta(t, 120, rows[0].Spent, 1e-9)
+	assert.Equal(t, 40, rows[0].Percent)
+	assert.False(t, rows[0].Over)
+
+	// Rent has no rollover, so January's unspent 2000 doesn't help.
+	assert.InDelta(t, 0, rows[1].Carry, 1e-9)
+	assert.True(t, rows[1].Over)
+	assert.Equal(t, 100, rows[1].Percent)
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.budgets(rec, httptest.NewRequest(http.MethodGet, "/budgets?month=2026-03", nil)))
+	body := rec.Body.String()
+	assert.Contains(t, body, "Dining")
+	assert.Contains(t, body, "budget-fill over")
+	assert.Contains(t, body, `href="/?startDate=2026-03-01&endDate=2026-03-31&categories=`+strconv.Itoa(dining)+`"`)
+}
+
+func TestBudgetsBeforeStartMonth(t *testing.T) {
+	db := testutil.NewDB(t)
+	dining := mustCreateCategory(t, db, "Dining", 1, "fun")
+	seedTransaction(t, db, "feb-dinner", "NOPA", 80, "2026-02-10", catID(dining))
+	_, err := model.SetBudget(db, model.Budget{CategoryID: dining, Amount: 300, Rollover: true, StartMonth: "2026-03"})
+	require.NoError(t, err)
+	c := &Controller{db: db}
+
+	rows, err := c.budgetRows(time.Date(2026, 2, 1, 0, 0, 0, 0, time.Local), time.Date(2026, 4, 2, 0, 0, 0, 0, time.Local))
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+
+	// February's spending predates the budget, so March carries nothing.
+	rows, err = c.budgetRows(time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local), time.Date(2026, 4, 2, 0, 0, 0, 0, time.Local))
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.InDelta(t, 0, rows[0].Carry, 1e-9)
+	assert.InDelta(t, 300, rows[0].Available, 1e-9)
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.budgets(rec, httptest.NewRequest(http.MethodGet, "/budgets?month=2026-02", nil)))
+	assert.NotContains(t, rec.Body.String(), "budget-fill")
+}
+
+func TestSetBudgetValidation(t *testing.T) {
+	db := testutil.NewDB(t)
+	income := mustCreateCategory(t, db, "Salary", 1, "income")
+	c := &Controller{db: db}
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.setBudget(rec, newFormRequest("/budgets", url.Values{
+		"category":    {strconv.Itoa(income)},
+		"amount":      {"-5"},
+		"start_month": {"March"},
+	})))
+	body := rec.Body.String()
+	assert.Contains(t, body, "pick a spending category")
+	assert.Contains(t, body, "amount must be a number of at least 0")
+	assert.Contains(t, body, "start month must look like 2006-01")
+
+	budgets, err := model.GetBudgets(db)
+	require.NoError(t, err)
+	assert.Empty(t, budgets)
+
+	err = c.budgets(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/budgets?month=soon", nil))
+	var apiErr APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+}
+
+func TestProjectedSpend(t *testing.T) {
+	march := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+
+	// Ten days into a 31-day month at 100 so far.
+	assert.InDelta(t, 310, projectedSpend(100, march, time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local)), 1e-9)
+	assert.InDelta(t, 100, projectedSpend(100, march, time.Date(2026, 4, 10, 0, 0, 0, 0, time.Local)), 1e-9, "past month")
+	assert.InDelta(t, 0, projectedSpend(0, march, time.Date(2026, 2, 10, 0, 0, 0, 0, time.Local)), 1e-9, "future month")
+}
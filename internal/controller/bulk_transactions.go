@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"fin-web/internal/model"
+	"fin-web/internal/util"
+)
+
+// BulkEditRequest is the body of POST /transactions/bulk. Either Delete is set
+// or at least one of the update fields is; unset fields are left alone.
+type BulkEditRequest struct {
+	IDs             []string `json:"ids"`
+	Delete          bool     `json:"delete"`
+	CategoryID      *int     `json:"category_id"`
+	IsReimbursement *bool    `json:"is_reimbursement"`
+	Description     *string  `json:"description"`
+	// CreateCategoryValues adds each selected merchant to CategoryID's
+	// category_values so later imports pick the same category.
+	CreateCategoryValues bool `json:"create_category_values"`
+}
+
+func (c *Controller) bulkEditTransactions(w http.ResponseWriter, r *http.Request) error {
+	req, err := decode[BulkEditRequest](r)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	errs := map[string]string{}
+	update := req.CategoryID != nil || req.IsReimbursement != nil || req.Description != nil
+
+	if len(req.IDs) == 0 {
+		errs["ids"] = "select at least one transaction"
+	}
+
+	switch {
+	case req.Delete && update:
+		errs["action"] = "delete can't be combined with other changes"
+	case !req.Delete && !update:
+		errs["action"] = "pick something to change"
+	}
+
+	if req.CreateCategoryValues && req.CategoryID == nil {
+		errs["category"] = "pick a category to add merchants to"
+	}
+
+	if len(errs) != 0 {
+		return encode(w, r, http.StatusBadRequest, map[string]any{
+			"errs": errs,
+		})
+	}
+
+	transactions := make([]model.Transaction, 0, len(req.IDs))
+	for _, id := range req.IDs {
+		t, err := model.GetTransaction(c.db, id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return encode(w, r, http.StatusBadRequest, map[string]any{
+					"errs": map[string]string{
+						"ids": "transaction " + id + " not found",
+					},
+				})
+			}
+
+			return APIError{
+				Status:  http.StatusInternalServerError,
+				Message: "error getting transaction: " + err.Error(),
+			}
+		}
+
+		transactions = append(transactions, t)
+	}
+
+	if req.Delete {
+		err := model.DeleteTransactions(c.db, req.IDs)
+		if err != nil {
+			return APIError{
+				Status:  http.StatusInternalServerError,
+				Message: "error deleting transactions: " + err.Error(),
+			}
+		}
+
+		return encode(w, r, http.StatusOK, map[string]int{
+			"deleted": len(req.IDs),
+		})
+	}
+
+	values := []string{}
+	if req.CategoryID != nil {
+		category, err := model.GetCategory(c.db, strconv.Itoa(*req.CategoryID))
+		if err != nil {
+			return APIError{
+				Status:  http.StatusInternalServerError,
+				Message: "error getting category: " + err.Error(),
+			}
+		}
+		if category.ID == 0 {
+			return encode(w, r, http.StatusBadRequest, map[string]any{
+				"errs": map[string]string{
+					"category": "category not found",
+				},
+			})
+		}
+
+		if req.CreateCategoryValues {
+			values = merchantValues(transactions, category.Values)
+		}
+	}
+
+	err = model.UpdateTransactions(c.db, req.IDs, model.UpdateTransactionParams{
+		CategoryID:      req.CategoryID,
+		IsReimbursement: req.IsReimbursement,
+		Description:     req.Description,
+	}, values)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error updating transactions: " + err.Error(),
+		}
+	}
+
+	return encode(w, r, http.StatusOK, map[string]any{
+		"updated": len(req.IDs),
+		"values":  values,
+	})
+}
+
+// merchantValues returns a category value for each distinct merchant among
+// transactions that the category doesn't already match. Values are matched as
+// substrings of the lower-cased name, so a merchant key that normalization
+// pulled out of order is skipped rather than saved as a value that never hits.
+func merchantValues(transactions []model.Transaction, existing []model.CategoryValue) []string {
+	values := []string{}
+	for _, t := range transactions {
+		name := strings.ToLower(t.Name)
+		value := strings.ToLower(util.NormalizeMerchant(t.Name))
+		if value == "" || !strings.Contains(name, value) || slices.Contains(values, value) {
+			continue
+		}
+
+		matched := false
+		for _, v := range existing {
+			// An empty value would match every name and stop all learning.
+			existingValue := strings.ToLower(v.Value.String)
+			if existingValue != "" && strings.Contains(name, existingValue) {
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+
+		values = append(values, value)
+	}
+
+	return values
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"fin-web/internal/model"
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newJSONRequest(target string, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestBulkEditSetsCategoryAndValues(t *testing.T) {
+	db := testutil.NewDB(t)
+	dining := testutil.SeedCategory(t, db, "Dining", 1, "chipotle")
+	seedTransaction(t, db, "tx-1", "SWEETGREEN 1234 NEW YORK NY", 14, "2026-03-03", sql.NullInt32{})
+	seedTransaction(t, db, "tx-2", "SWEETGREEN 5678 NEW YORK NY", 15, "2026-03-04", sql.NullInt32{})
+	seedTransaction(t, db, "tx-3", "CHIPOTLE 88", 12, "2026-03-05", sql.NullInt32{})
+	seedTransaction(t, db, "tx-4", "UNTOUCHED", 9, "2026-03-06", sql.NullInt32{})
+	c := &Controller{db: db}
+
+	rec := httptest.NewRecorder()
+	body := `{"ids": ["tx-1", "tx-2", "tx-3"], "category_id": ` + strconv.Itoa(dining) + `, "is_reimbursement": true, "create_category_values": true}`
+	require.NoError(t, c.bulkEditTransactions(rec, newJSONRequest("/transactions/bulk", body)))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"updated": 3, "values": ["sweetgreen"]}`, rec.Body.String())
+
+	for _, id := range []string{"tx-1", "tx-2", "tx-3"} {
+		tx, err := model.GetTransaction(db, id)
+		require.NoError(t, err)
+		assert.Equal(t, int32(dining), tx.CategoryID.Int32)
+		assert.True(t, tx.IsReimbursement)
+		assert.Equal(t, model.CategorySourceManual, tx.CategorySource.String)
+	}
+
+	tx, err := model.GetTransaction(db, "tx-4")
+	require.NoError(t, err)
+	assert.False(t, tx.CategoryID.Valid)
+
+	matches, err := model.SearchCategories(db, []string{"sweetgreen 9999 brooklyn ny"})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, dining, matches[0].ID)
+}
+
+func TestBulkEditDescriptionOnly(t *testing.T) {
+	db := testutil.NewDB(t)
+	seedTransaction(t, db, "tx-1", "VENMO", 20, "2026-03-03", sql.NullInt32{})
+	c := &Controller{db: db}
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.bulkEditTransactions(rec, newJSONRequest("/transactions/bulk", `{"ids": ["tx-1"], "description": "split dinner"}`)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	tx, err := model.GetTransaction(db, "tx-1")
+	require.NoError(t, err)
+	assert.Equal(t, "split dinner", tx.Description.String)
+	assert.False(t, tx.CategoryID.Valid)
+	assert.False(t, tx.IsReimbursement)
+}
+
+func TestBulkEditDelete(t *testing.T) {
+	db := testutil.NewDB(t)
+	seedTransaction(t, db, "tx-1", "A", 1, "2026-03-03", sql.NullInt32{})
+	seedTransaction(t, db, "tx-2", "B", 2, "2026-03-04", sql.NullInt32{})
+	seedTransaction(t, db, "tx-3", "C", 3, "2026-03-05", sql.NullInt32{})
+	c := &Controller{db: db}
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.bulkEditTransactions(rec, newJSONRequest("/transactions/bulk", `{"ids": ["tx-1", "tx-3"], "delete": true}`)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var n int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM transactions").Scan(&n))
+	assert.Equal(t, 1, n)
+}
+
+func TestBulkEditValidation(t *testing.T) {
+	db := testutil.NewDB(t)
+	seedTransaction(t, db, "tx-1", "A", 1, "2026-03-03", sql.NullInt32{})
+	c := &Controller{db: db}
+
+	tests := []struct {
+		name string
+		body string
+		errs map[string]string
+	}{
+		{name: "no ids", body: `{"ids": [], "description": "x"}`, errs: map[string]string{"ids": "select at least one transaction"}},
+		{name: "nothing to do", body: `{"ids": ["tx-1"]}`, errs: map[string]string{"action": "pick something to change"}},
+		{name: "delete and update", body: `{"ids": ["tx-1"], "delete": true, "description": "x"}`, errs: map[string]string{"action": "delete can't be combined with other changes"}},
+		{name: "values without category", body: `{"ids": ["tx-1"], "description": "x", "create_category_values": true}`, errs: map[string]string{"category": "pick a category to add merchants to"}},
+		{name: "unknown id", body: `{"ids": ["tx-1", "nope"], "description": "x"}`, errs: map[string]string{"ids": "transaction nope not found"}},
+		{name: "unknown category", body: `{"ids": ["tx-1"], "category_id": 999}`, errs: map[string]string{"category": "category not found"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			require.NoError(t, c.bulkEditTransactions(rec, newJSONRequest("/transactions/bulk", tt.body)))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, tt.errs, decodeErrs(t, rec))
+		})
+	}
+
+	tx, err := model.GetTransaction(db, "tx-1")
+	require.NoError(t, err)
+	assert.False(t, tx.Description.Valid, "nothing was written")
+}
+
+func TestMerchantValuesMatchesExistingIgnoringCase(t *testing.T) {
+	transactions := []model.Transaction{
+		{Name: "AMAZON MKTPLACE PMTS"},
+		{Name: "SWEETGREEN 1234 NEW YORK NY"},
+	}
+	existing := []model.CategoryValue{
+		{Value: sql.NullString{Valid: true, String: "AMAZON"}},
+		// Blank values match nothing rather than everything.
+		{Value: sql.NullString{Valid: true, String: ""}},
+		{Value: sql.NullString{}},
+	}
+
+	assert.Equal(t, []string{"sweetgreen"}, merchantValues(transactions, existing))
+}
@@ -5,23 +5,48 @@ import (
 	"html/template"
 	"log"
 	"net/http"
+	"os"
 	"path"
+	"path/filepath"
 	"time"
 
 	"fin-web/internal/assets"
+	"fin-web/internal/bofa"
+	"fin-web/internal/citi"
+	"fin-web/internal/notify"
+	"fin-web/internal/ofx"
+	"fin-web/internal/schwab"
 	"fin-web/internal/templates"
+	"fin-web/internal/worker"
 )
 
 type Controller struct {
 	db          *sql.DB
 	tiingoToken string
-	Server      http.Server
+	providers   []worker.Provider
+	uploadDir   string
+	alerts      AlertSettings
+	// alertQueue sends alerts to alerts.Notifiers in the background; nil
+	// when there are none.
+	alertQueue *notify.Queue
+	Server     http.Server
 }
 
-func NewController(conn *sql.DB, tt string, port string) Controller {
-	c := Controller{
+func NewController(conn *sql.DB, tt string, port string, alerts AlertSettings) *Controller {
+	c := &Controller{
 		db:          conn,
 		tiingoToken: tt,
+		alerts:      alerts,
+		providers: []worker.Provider{
+			bofa.NewBofaProvider(conn),
+			citi.NewCitiProvider(conn),
+			ofx.NewOfxProvider(conn),
+			schwab.NewSchwabProvider(conn),
+		},
+		uploadDir: filepath.Join(os.TempDir(), "fin-web-uploads"),
+	}
+	if len(alerts.Notifiers) > 0 {
+		c.alertQueue = notify.NewQueue(alerts.Notifiers, notifyTimeout)
 	}
 	c.Server = http.Server{
 		Addr:    ":" + port,
@@ -30,6 +55,14 @@ func NewController(conn *sql.DB, tt string, port string) Controller {
 	return c
 }
 
+// Close waits for queued alerts to reach the notifiers. Call it once Server
+// has shut down, so no request can queue more.
+func (c *Controller) Close() {
+	if c.alertQueue != nil {
+		c.alertQueue.Close()
+	}
+}
+
 func (c *Controller) buildRoutes() http.Handler {
 	r := http.NewServeMux()
 
@@ -38,7 +71,10 @@ func (c *Controller) buildRoutes() http.Handler {
 	r.HandleFunc("GET /favicon.ico", MakeHandler(c.favicon))
 	r.HandleFunc("GET /annual", MakeHandler(c.annual))
 	r.HandleFunc("GET /health", MakeHandler(c.health))
+	r.HandleFunc("POST /subscriptions/decisions", MakeHandler(c.setRecurringDecision))
 	r.HandleFunc("GET /subscriptions", MakeHandler(c.subscriptions))
+	r.HandleFunc("POST /notifications/read", MakeHandler(c.markNotificationsRead))
+	r.HandleFunc("GET /notifications", MakeHandler(c.notifications))
 
 	r.HandleFunc("GET /net-worth/new", MakeHandler(c.newNetWorthItem))
 	r.HandleFunc("POST /net-worth/new", MakeHandler(c.createNetWorthItem))
@@ -47,9 +83,27 @@ func (c *Controller) buildRoutes() http.Handler {
 	r.HandleFunc("POST /net-worth/{id}", MakeHandler(c.updateNetWorthItem))
 	r.HandleFunc("GET /net-worth", MakeHandler(c.netWorth))
 
+	r.HandleFunc("GET /imports/upload", MakeHandler(c.newUpload))
+	r.HandleFunc("POST /imports/upload", MakeHandler(c.upload))
+	r.HandleFunc("POST /imports/upload/{token}/confirm", MakeHandler(c.confirmUpload))
+	r.HandleFunc("POST /imports/upload/{token}/cancel", MakeHandler(c.cancelUpload))
+	r.HandleFunc("GET /imports/profiles", MakeHandler(c.csvProfiles))
+	r.HandleFunc("POST /imports/profiles", MakeHandler(c.createCSVProfile))
+	r.HandleFunc("POST /imports/profiles/{id}/delete", MakeHandler(c.deleteCSVProfile))
+	r.HandleFunc("GET /imports/{id}", MakeHandler(c.importBatch))
+	r.HandleFunc("POST /imports/{id}/revert", MakeHandler(c.revertImportBatch))
+	r.HandleFunc("GET /imports", MakeHandler(c.imports))
+
 	r.HandleFunc("GET /transactions/uncategorized", MakeHandler(c.uncategorizedTransactions))
+	r.HandleFunc("GET /transactions/recategorize", MakeHandler(c.recategorize))
+	r.HandleFunc("POST /transactions/recategorize", MakeHandler(c.applyRecategorize))
+	r.HandleFunc("POST /transactions/bulk", MakeHandler(c.bulkEditTransactions))
 	r.HandleFunc("GET /transactions/{id}", MakeHandler(c.transaction))
 	r.HandleFunc("POST /transactions/{id}/delete", MakeHandler(c.deleteTransaction))
+	r.HandleFunc("POST /transactions/{id}/category", MakeHandler(c.setTransactionCategory))
+	r.HandleFunc("POST /transactions/{id}/splits", MakeHandler(c.updateSplits))
+	r.HandleFunc("POST /transactions/{id}/reimbursements", MakeHandler(c.linkReimbursement))
+	r.HandleFunc("POST /transactions/{id}/reimbursements/{reimbursement}/delete", MakeHandler(c.deleteReimbursement))
 	r.HandleFunc("POST /transactions/{id}", MakeHandler(c.updateTransaction))
 
 	r.HandleFunc("GET /categories/new", MakeHandler(c.newCategory))
@@ -59,6 +113,19 @@ func (c *Controller) buildRoutes() http.Handler {
 	r.HandleFunc("POST /categories/{id}", MakeHandler(c.updateCategory))
 	r.HandleFunc("GET /categories", MakeHandler(c.categories))
 
+	r.HandleFunc("POST /transfers/detect", MakeHandler(c.detectTransfers))
+	r.HandleFunc("POST /transfers/{id}/confirm", MakeHandler(c.confirmTransfer))
+	r.HandleFunc("POST /transfers/{id}/unlink", MakeHandler(c.unlinkTransfer))
+	r.HandleFunc("GET /transfers", MakeHandler(c.transfers))
+
+	r.HandleFunc("POST /budgets/{id}/delete", MakeHandler(c.deleteBudget))
+	r.HandleFunc("POST /budgets", MakeHandler(c.setBudget))
+	r.HandleFunc("GET /budgets", MakeHandler(c.budgets))
+
+	r.HandleFunc("POST /rules/{id}/delete", MakeHandler(c.deleteRule))
+	r.HandleFunc("POST /rules", MakeHandler(c.createRule))
+	r.HandleFunc("GET /rules", MakeHandler(c.rules))
+
 	r.HandleFunc("GET /trades/new", MakeHandler(c.newTrade))
 	r.HandleFunc("POST /trades/new", MakeHandler(c.createTrade))
 	r.HandleFunc("POST /trades/{id}/delete", MakeHandler(c.deleteTrade))
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"time"
+
+	"fin-web/internal/model"
+	"fin-web/internal/recurring"
+)
+
+// upcomingDays is how far ahead /health lists expected charges.
+const upcomingDays = 30
+
+// Forecast projects the current month's cash flow to its end: actuals so far
+// plus the recurring charges and income still expected before month end.
+type Forecast struct {
+	Month           string
+	IncomeToDate    float64
+	ExpenseToDate   float64
+	UpcomingIncome  float64
+	UpcomingExpense float64
+	Income          float64
+	Expense         float64
+	Savings         float64
+	SavingsRate     string
+	// RecurringIncome is the monthly average of active recurring income,
+	// for comparison with what this month looks like.
+	RecurringIncome float64
+	// Upcoming lists recurring charges expected in the next upcomingDays.
+	Upcoming      []recurring.Occurrence
+	UpcomingTotal float64
+}
+
+func (c *Controller) forecast(today time.Time) (Forecast, error) {
+	start, _ := getStartAndEndOfMonth(today)
+	flows, err := model.MonthlyFlows(c.db, model.QueryTransactionsFilters{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   today.Format("2006-01-02"),
+	})
+	if err != nil {
+		return Forecast{}, err
+	}
+
+	charges, err := model.RecurringCandidates(c.db)
+	if err != nil {
+		return Forecast{}, err
+	}
+
+	decisions, err := model.GetRecurringDecisions(c.db)
+	if err != nil {
+		return Forecast{}, err
+	}
+
+	income, err := model.RecurringIncomeCandidates(c.db)
+	if err != nil {
+		return Forecast{}, err
+	}
+
+	return buildForecast(flows, recurring.DetectWith(charges, today, decisions), recurring.Detect(income, today), today), nil
+}
+
+// buildForecast combines month-to-date flows with the expense and income
+// recurring reports. flows should cover only the current month.
+func buildForecast(flows []model.MonthlyFlow, expenses recurring.Report, income recurring.Report, today time.Time) Forecast {
+	_, end := getStartAndEndOfMonth(today)
+	f := Forecast{Month: today.Format(monthLayout)}
+
+	for _, flow := range flows {
+		f.IncomeToDate += flow.Income
+		f.ExpenseToDate += flow.Expense
+	}
+
+	activeExpenses := append(append([]recurring.Recurring{}, expenses.Subscriptions...), expenses.Bills...)
+	activeIncome := append(append([]recurring.Recurring{}, income.Subscriptions...), income.Bills...)
+
+	for _, o := range recurring.Upcoming(activeExpenses, today, end) {
+		f.UpcomingExpense += o.Amount
+	}
+	for _, o := range recurring.Upcoming(activeIncome, today, end) {
+		f.UpcomingIncome += o.Amount
+	}
+	for _, r := range activeIncome {
+		f.RecurringIncome += r.Monthly
+	}
+
+	f.Income = f.IncomeToDate + f.UpcomingIncome
+	f.Expense = f.ExpenseToDate + f.UpcomingExpense
+	f.Savings = f.Income - f.Expense
+	f.SavingsRate = formatSavingsRate(f.Income, f.Expense)
+
+	f.Upcoming = recurring.Upcoming(activeExpenses, today, today.AddDate(0, 0, upcomingDays))
+	for _, o := range f.Upcoming {
+		f.UpcomingTotal += o.Amount
+	}
+
+	return f
+}
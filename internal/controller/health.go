@@ -3,6 +3,7 @@ package controller
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"fin-web/internal/model"
 )
@@ -19,6 +20,11 @@ type HealthPage struct {
 	HasIncome      bool
 	WindowMonths   int
 	SavingsRows    []SavingsRow
+	// Awaiting lists expenses still due to be paid back; totals above
+	// count them in full until a reimbursement is linked.
+	Awaiting     []model.OutstandingExpense
+	Forecast     Forecast
+	UpcomingDays int
 }
 
 // BreakdownSlice is one wedge of the needs/wants/savings donut. ID is unused by
@@ -78,6 +84,22 @@ func (c *Controller) health(w http.ResponseWriter, r *http.Request) error {
 		donutSavings = 0
 	}
 
+	awaiting, err := model.AwaitingReimbursement(c.db)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error fetching expenses awaiting reimbursement: " + err.Error(),
+		}
+	}
+
+	forecast, err := c.forecast(time.Now())
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error forecasting cash flow: " + err.Error(),
+		}
+	}
+
 	page := HealthPage{
 		Breakdown: breakdown,
 		BreakdownDonut: []BreakdownSlice{
@@ -91,6 +113,9 @@ func (c *Controller) health(w http.ResponseWriter, r *http.Request) error {
 		HasIncome:    breakdown.Income > 0,
 		WindowMonths: windowMonths,
 		SavingsRows:  display,
+		Awaiting:     awaiting,
+		Forecast:     forecast,
+		UpcomingDays: upcomingDays,
 	}
 
 	if err := renderTemplate(w, Base[HealthPage]{Data: page}, "layout", []string{"health.html", "layout.html"}); err != nil {
@@ -1,11 +1,15 @@
 package controller
 
 import (
+	"database/sql"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
+	"time"
 
 	"fin-web/internal/model"
+	"fin-web/internal/recurring"
 	"fin-web/internal/testutil"
 
 	"github.com/stretchr/testify/assert"
@@ -113,3 +117,61 @@ func TestHealthHandlerEmpty(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rec.Code)
 	assert.Contains(t, rec.Body.String(), "Not enough income")
 }
+
+func TestBuildForecast(t *testing.T) {
+	today := time.Date(2026, 3, 10, 9, 0, 0, 0, time.Local)
+	flows := []model.MonthlyFlow{{Month: "2026-03", Income: 2000, Expense: 900}}
+	expenses := recurring.Report{
+		Subscriptions: []recurring.Recurring{
+			{Merchant: "NETFLIX.COM", TypicalAmt: 15, Last: "2026-02-20", Next: "2026-03-20"},
+			// Due again in April, inside the 30-day list but after month end.
+			{Merchant: "GYM", TypicalAmt: 40, Last: "2026-03-05", Next: "2026-04-05"},
+		},
+		Bills: []recurring.Recurring{
+			{Merchant: "RENT", TypicalAmt: 2000, Last: "2026-03-01", Next: "2026-04-01"},
+		},
+		// Canceled series are not expected again.
+		Canceled: []recurring.Recurring{
+			{Merchant: "OLD", TypicalAmt: 99, Last: "2025-01-01", Next: "2026-03-15"},
+		},
+	}
+	income := recurring.Report{
+		Bills: []recurring.Recurring{
+			{Merchant: "ACME PAYROLL", TypicalAmt: 2000, Monthly: 4340, Last: "2026-03-06", Next: "2026-03-20"},
+		},
+	}
+
+	f := buildForecast(flows, expenses, income, today)
+	assert.Equal(t, "2026-03", f.Month)
+	assert.InDelta(t, 2000, f.UpcomingIncome, 1e-9)
+	assert.InDelta(t, 15, f.UpcomingExpense, 1e-9)
+	assert.InDelta(t, 4000, f.Income, 1e-9)
+	assert.InDelta(t, 915, f.Expense, 1e-9)
+	assert.InDelta(t, 3085, f.Savings, 1e-9)
+	assert.InDelta(t, 4340, f.RecurringIncome, 1e-9)
+
+	var upcoming []string
+	for _, o := range f.Upcoming {
+		upcoming = append(upcoming, o.Date+" "+o.Merchant)
+	}
+	assert.Equal(t, []string{"2026-03-20 NETFLIX.COM", "2026-04-01 RENT", "2026-04-05 GYM"}, upcoming)
+	assert.InDelta(t, 2055, f.UpcomingTotal, 1e-9)
+}
+
+func TestHealthHandlerListsUpcomingCharges(t *testing.T) {
+	db := testutil.NewDB(t)
+	// Charged every 30 days, last 20 days ago, so due again in about 10.
+	now := time.Now()
+	for _, daysAgo := range []int{80, 50, 20} {
+		seedTransaction(t, db, "sub-"+strconv.Itoa(daysAgo), "NETFLIX.COM", 15.49, now.AddDate(0, 0, -daysAgo).Format("2006-01-02"), sql.NullInt32{})
+	}
+
+	c := &Controller{db: db}
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.health(rec, httptest.NewRequest(http.MethodGet, "/health", nil)))
+
+	body := rec.Body.String()
+	assert.Contains(t, body, "Upcoming Charges")
+	assert.Contains(t, body, now.AddDate(0, 0, 10).Format("2006-01-02"))
+	assert.Contains(t, body, "<td>NETFLIX.COM</td>")
+}
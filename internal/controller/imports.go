@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"fin-web/internal/model"
+)
+
+type ImportsPage struct {
+	Batches []model.ImportBatch
+}
+
+type ImportPage struct {
+	Batch        model.ImportBatch
+	Transactions []model.Transaction
+	Success      bool
+}
+
+func (c *Controller) imports(w http.ResponseWriter, r *http.Request) error {
+	batches, err := model.GetImportBatches(c.db)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error fetching import batches: " + err.Error(),
+		}
+	}
+
+	err = renderTemplate(w, Base[ImportsPage]{
+		Data: ImportsPage{
+			Batches: batches,
+		},
+	}, "layout", []string{"imports/imports.html", "layout.html"})
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+func (c *Controller) importBatch(w http.ResponseWriter, r *http.Request) error {
+	id := r.PathValue("id")
+
+	batch, err := model.GetImportBatch(c.db, id)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error fetching import batch: " + err.Error(),
+		}
+	}
+
+	transactions, err := model.GetImportBatchTransactions(c.db, id)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error fetching import batch transactions: " + err.Error(),
+		}
+	}
+
+	responseCookie, err := r.Cookie("response")
+	if err != nil && err != http.ErrNoCookie {
+		fmt.Println("error getting cookie: " + err.Error())
+	}
+
+	success := responseCookie != nil && responseCookie.Value == "success"
+
+	err = renderTemplate(w, Base[ImportPage]{
+		Data: ImportPage{
+			Batch:        batch,
+			Transactions: transactions,
+			Success:      success,
+		},
+	}, "layout", []string{"imports/import.html", "layout.html"})
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+func (c *Controller) revertImportBatch(w http.ResponseWriter, r *http.Request) error {
+	id := r.PathValue("id")
+
+	_, err := model.RevertImportBatch(c.db, id)
+	if err != nil {
+		if errors.Is(err, model.ErrImportBatchNotRevertible) {
+			return APIError{
+				Status:  http.StatusBadRequest,
+				Message: "error reverting import batch: " + err.Error(),
+			}
+		}
+
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error reverting import batch: " + err.Error(),
+		}
+	}
+
+	cookie := &http.Cookie{
+		Name:     "response",
+		Value:    "success",
+		Path:     "/",
+		HttpOnly: true,
+		Expires:  time.Now().Add(1 * time.Second),
+	}
+
+	http.SetCookie(w, cookie)
+
+	http.Redirect(w, r, "/imports/"+id, http.StatusSeeOther)
+	return nil
+}
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"fin-web/internal/model"
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedImportBatch records a completed batch and n transactions tagged with it.
+func seedImportBatch(t *testing.T, db *sql.DB, fileName string, n int) int {
+	t.Helper()
+	id, err := model.CreateImportBatch(db, model.ImportBatch{
+		FileName: fileName,
+		Provider: "citi",
+		FileHash: "hash-" + fileName,
+		Inserted: n,
+		Status:   model.ImportBatchCompleted,
+	})
+	require.NoError(t, err)
+
+	for i := range n {
+		require.NoError(t, model.CreateTransaction(db, model.Transaction{
+			ID:      fileName + "-" + strconv.Itoa(i),
+			Name:    "IMPORTED " + strconv.Itoa(i),
+			Amount:  10,
+			Date:    "2026-02-10",
+			Source:  "citi",
+			Account: "citi",
+			BatchID: sql.NullInt64{Valid: true, Int64: int64(id)},
+		}))
+	}
+
+	return id
+}
+
+func TestImportsHandlerRenders(t *testing.T) {
+	db := testutil.NewDB(t)
+	seedImportBatch(t, db, "From-jan.csv", 1)
+	c := &Controller{db: db}
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.imports(rec, httptest.NewRequest(http.MethodGet, "/imports", nil)))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "From-jan.csv")
+}
+
+func TestImportBatchHandlerListsTransactions(t *testing.T) {
+	db := testutil.NewDB(t)
+	id := seedImportBatch(t, db, "From-jan.csv", 2)
+	c := &Controller{db: db}
+
+	req := httptest.NewRequest(http.MethodGet, "/imports/"+strconv.Itoa(id), nil)
+	req.SetPathValue("id", strconv.Itoa(id))
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.importBatch(rec, req))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, "IMPORTED 0")
+	assert.Contains(t, body, "IMPORTED 1")
+	assert.Contains(t, body, "Revert Import")
+}
+
+func TestRevertImportBatchDeletesOnlyThatBatch(t *testing.T) {
+	db := testutil.NewDB(t)
+	wrong := seedImportBatch(t, db, "From-wrong.csv", 2)
+	seedImportBatch(t, db, "From-right.csv", 1)
+	c := &Controller{db: db}
+
+	req := httptest.NewRequest(http.MethodPost, "/imports/"+strconv.Itoa(wrong)+"/revert", nil)
+	req.SetPathValue("id", strconv.Itoa(wrong))
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.revertImportBatch(rec, req))
+
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Equal(t, "/imports/"+strconv.Itoa(wrong), rec.Header().Get("Location"))
+
+	txns, err := model.QueryTransactions(db, model.QueryTransactionsFilters{})
+	require.NoError(t, err)
+	require.Len(t, txns, 1)
+	assert.Equal(t, "From-right.csv-0", txns[0].ID)
+
+	batch, err := model.GetImportBatch(db, strconv.Itoa(wrong))
+	require.NoError(t, err)
+	assert.True(t, batch.Reverted())
+
+	// A second revert is refused rather than silently succeeding.
+	req = httptest.NewRequest(http.MethodPost, "/imports/"+strconv.Itoa(wrong)+"/revert", nil)
+	req.SetPathValue("id", strconv.Itoa(wrong))
+	err = c.revertImportBatch(httptest.NewRecorder(), req)
+	var apiErr APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+}
@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"fin-web/internal/model"
+)
+
+const (
+	// feedSize is how many notifications the header feed lists.
+	feedSize = 20
+	// alertCheckKey throttles the alert rules run from the feed, which every
+	// page loads, to once per alertCheckTTL.
+	alertCheckKey = "notifications:alerts-checked"
+	alertCheckTTL = time.Hour
+)
+
+type NotificationJSON struct {
+	ID        int    `json:"id"`
+	Kind      string `json:"kind"`
+	Message   string `json:"message"`
+	Link      string `json:"link"`
+	CreatedAt string `json:"created_at"`
+	Read      bool   `json:"read"`
+}
+
+type NotificationFeed struct {
+	Unread        int                `json:"unread"`
+	Notifications []NotificationJSON `json:"notifications"`
+}
+
+type MarkNotificationsReadRequest struct {
+	// IDs to mark read; empty marks every notification read.
+	IDs []int `json:"ids"`
+}
+
+// checkAlerts is recordAlerts, without any import batches, at most once per
+// alertCheckTTL.
+func (c *Controller) checkAlerts(now time.Time) error {
+	_, err := model.GetKVItem(c.db, alertCheckKey)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, model.ErrKVItemNotFound) {
+		return err
+	}
+
+	if err := c.recordAlerts(now, nil); err != nil {
+		return err
+	}
+
+	return model.PutKVItem(c.db, alertCheckKey, now.UTC().Format(time.RFC3339), alertCheckTTL)
+}
+
+func (c *Controller) notificationFeed() (NotificationFeed, error) {
+	notifications, err := model.GetNotifications(c.db, feedSize)
+	if err != nil {
+		return NotificationFeed{}, err
+	}
+
+	unread, err := model.CountUnreadNotifications(c.db)
+	if err != nil {
+		return NotificationFeed{}, err
+	}
+
+	feed := NotificationFeed{
+		Unread:        unread,
+		Notifications: make([]NotificationJSON, len(notifications)),
+	}
+	for i, n := range notifications {
+		feed.Notifications[i] = NotificationJSON{
+			ID:        n.ID,
+			Kind:      n.Kind,
+			Message:   n.Message,
+			Link:      n.Link,
+			CreatedAt: n.CreatedAt,
+			Read:      n.ReadAt.Valid,
+		}
+	}
+
+	return feed, nil
+}
+
+// notifications serves the header's alert feed, running the alert rules
+// first.
+func (c *Controller) notifications(w http.ResponseWriter, r *http.Request) error {
+	if err := c.checkAlerts(time.Now()); err != nil {
+		return APIError{
+			Status:       http.StatusInternalServerError,
+			Message:      "error checking alerts: " + err.Error(),
+			ResponseType: "JSON",
+		}
+	}
+
+	feed, err := c.notificationFeed()
+	if err != nil {
+		return APIError{
+			Status:       http.StatusInternalServerError,
+			Message:      "error fetching notifications: " + err.Error(),
+			ResponseType: "JSON",
+		}
+	}
+
+	return encode(w, r, http.StatusOK, feed)
+}
+
+func (c *Controller) markNotificationsRead(w http.ResponseWriter, r *http.Request) error {
+	req, err := decode[MarkNotificationsReadRequest](r)
+	if err != nil {
+		return APIError{
+			Status:       http.StatusBadRequest,
+			Message:      err.Error(),
+			ResponseType: "JSON",
+		}
+	}
+
+	if err := model.MarkNotificationsRead(c.db, req.IDs); err != nil {
+		return APIError{
+			Status:       http.StatusInternalServerError,
+			Message:      "error marking notifications read: " + err.Error(),
+			ResponseType: "JSON",
+		}
+	}
+
+	feed, err := c.notificationFeed()
+	if err != nil {
+		return APIError{
+			Status:       http.StatusInternalServerError,
+			Message:      "error fetching notifications: " + err.Error(),
+			ResponseType: "JSON",
+		}
+	}
+
+	return encode(w, r, http.StatusOK, feed)
+}
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"fin-web/internal/model"
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getFeed(t *testing.T, c *Controller) NotificationFeed {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.notifications(rec, httptest.NewRequest(http.MethodGet, "/notifications", nil)))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var feed NotificationFeed
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&feed))
+	return feed
+}
+
+func TestNotificationsRecordsRecurringChanges(t *testing.T) {
+	db := testutil.NewDB(t)
+
+	// Netflix raised its price on the latest of six monthly charges.
+	last := time.Now().AddDate(0, 0, -5)
+	for i := 5; i >= 0; i-- {
+		d := last.AddDate(0, 0, -30*i).Format("2006-01-02")
+		amount := 15.49
+		if i == 0 {
+			amount = 17.99
+		}
+		seedTransaction(t, db, "nf-"+d, "NETFLIX.COM", amount, d, sql.NullInt32{})
+	}
+
+	c := &Controller{db: db}
+	feed := getFeed(t, c)
+	require.Len(t, feed.Notifications, 1)
+	assert.Equal(t, 1, feed.Unread)
+	assert.Equal(t, "price_increase", feed.Notifications[0].Kind)
+	assert.Contains(t, feed.Notifications[0].Message, "NETFLIX.COM went up from 15.49 to 17.99")
+	assert.Equal(t, "/subscriptions", feed.Notifications[0].Link)
+
+	// Checking again, throttled or not, doesn't repeat it.
+	require.NoError(t, c.recordAlerts(time.Now(), nil))
+	assert.Len(t, getFeed(t, c).Notifications, 1)
+}
+
+func TestMarkNotificationsRead(t *testing.T) {
+	db := testutil.NewDB(t)
+	for _, key := range []string{"a", "b"} {
+		_, err := model.CreateNotification(db, model.Notification{Kind: "test", DedupeKey: key, Message: key})
+		require.NoError(t, err)
+	}
+
+	c := &Controller{db: db}
+	feed := getFeed(t, c)
+	require.Equal(t, 2, feed.Unread)
+
+	markRead := func(body string) NotificationFeed {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/notifications/read", strings.NewReader(body))
+		require.NoError(t, c.markNotificationsRead(rec, req))
+
+		var feed NotificationFeed
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&feed))
+		return feed
+	}
+
+	feed = markRead(`{"ids": [` + strconv.Itoa(feed.Notifications[0].ID) + `]}`)
+	assert.Equal(t, 1, feed.Unread)
+	assert.True(t, feed.Notifications[0].Read)
+	assert.False(t, feed.Notifications[1].Read)
+
+	feed = markRead(`{"ids": []}`)
+	assert.Equal(t, 0, feed.Unread)
+
+	var apiErr APIError
+	err := c.markNotificationsRead(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/notifications/read", strings.NewReader("nope")))
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"fin-web/internal/model"
+)
+
+// pageSizes are offered as links under paginated listings.
+var pageSizes = []int{50, 100, 500}
+
+// Pagination links a listing's pages. The links keep the rest of the query
+// string, so filters and sorting carry across pages.
+type Pagination struct {
+	Total   int
+	Shown   int
+	PrevURL string
+	NextURL string
+	Sizes   []PageSizeLink
+}
+
+type PageSizeLink struct {
+	Size    int
+	URL     string
+	Current bool
+}
+
+// parsePageRequest reads pageSize, after and before from the query string.
+func parsePageRequest(q url.Values) (model.PageRequest, error) {
+	req := model.PageRequest{
+		After:  q.Get("after"),
+		Before: q.Get("before"),
+	}
+
+	if v := q.Get("pageSize"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size <= 0 {
+			return req, APIError{
+				Status:  http.StatusBadRequest,
+				Message: "pageSize must be a positive whole number",
+			}
+		}
+		req.Size = size
+	}
+
+	return req, nil
+}
+
+// pageError reports a bad cursor as the caller's mistake.
+func pageError(err error) error {
+	if errors.Is(err, model.ErrInvalidCursor) {
+		return APIError{
+			Status:  http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+
+	return APIError{
+		Status:  http.StatusInternalServerError,
+		Message: "error fetching transactions: " + err.Error(),
+	}
+}
+
+func newPagination(u *url.URL, page model.Page) Pagination {
+	link := func(set map[string]string) string {
+		q := u.Query()
+		q.Del("after")
+		q.Del("before")
+		for k, v := range set {
+			q.Set(k, v)
+		}
+		return u.Path + "?" + q.Encode()
+	}
+
+	p := Pagination{
+		Total: page.Total,
+		Shown: len(page.Transactions),
+	}
+	if page.Prev != "" {
+		p.PrevURL = link(map[string]string{"before": page.Prev})
+	}
+	if page.Next != "" {
+		p.NextURL = link(map[string]string{"after": page.Next})
+	}
+	for _, size := range pageSizes {
+		p.Sizes = append(p.Sizes, PageSizeLink{
+			Size:    size,
+			URL:     link(map[string]string{"pageSize": strconv.Itoa(size)}),
+			Current: size == page.Size,
+		})
+	}
+
+	return p
+}
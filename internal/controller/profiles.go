@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"fin-web/internal/model"
+)
+
+type CSVProfileFormData struct {
+	Name         string
+	Account      string
+	SkipRows     string
+	DateColumn   string
+	DateLayout   string
+	NameColumn   string
+	AmountColumn string
+	DebitColumn  string
+	CreditColumn string
+	NegateAmount bool
+}
+
+type CSVProfilesPage struct {
+	Profiles []model.CSVProfile
+	Form     CSVProfileFormData
+	Errs     map[string]string
+}
+
+func (c *Controller) renderCSVProfiles(w http.ResponseWriter, form CSVProfileFormData, errs map[string]string) error {
+	profiles, err := model.GetCSVProfiles(c.db)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error fetching csv profiles: " + err.Error(),
+		}
+	}
+
+	err = renderTemplate(w, Base[CSVProfilesPage]{
+		Data: CSVProfilesPage{
+			Profiles: profiles,
+			Form:     form,
+			Errs:     errs,
+		},
+	}, "layout", []string{"imports/profiles.html", "layout.html"})
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+func (c *Controller) csvProfiles(w http.ResponseWriter, r *http.Request) error {
+	return c.renderCSVProfiles(w, CSVProfileFormData{
+		SkipRows:   "1",
+		DateLayout: "01/02/2006",
+	}, nil)
+}
+
+func validateCSVProfileForm(r *http.Request) (CSVProfileFormData, model.CSVProfile, map[string]string) {
+	errs := map[string]string{}
+	form := CSVProfileFormData{
+		Name:         strings.TrimSpace(r.FormValue("name")),
+		Account:      strings.TrimSpace(r.FormValue("account")),
+		SkipRows:     r.FormValue("skip_rows"),
+		DateColumn:   strings.TrimSpace(r.FormValue("date_column")),
+		DateLayout:   strings.TrimSpace(r.FormValue("date_layout")),
+		NameColumn:   strings.TrimSpace(r.FormValue("name_column")),
+		AmountColumn: strings.TrimSpace(r.FormValue("amount_column")),
+		DebitColumn:  strings.TrimSpace(r.FormValue("debit_column")),
+		CreditColumn: strings.TrimSpace(r.FormValue("credit_column")),
+		NegateAmount: r.FormValue("negate_amount") == "on",
+	}
+
+	if form.Name == "" {
+		errs["name"] = "name can't be empty"
+	}
+
+	if form.Account == "" {
+		errs["account"] = "account can't be empty"
+	}
+
+	skipRows, err := strconv.Atoi(form.SkipRows)
+	if err != nil || skipRows < 0 {
+		errs["skip_rows"] = "skip rows must be a whole number"
+	}
+
+	if form.DateColumn == "" {
+		errs["date_column"] = "date column can't be empty"
+	}
+
+	if form.DateLayout == "" {
+		errs["date_layout"] = "date layout can't be empty"
+	} else if time.Now().Format(form.DateLayout) == form.DateLayout {
+		errs["date_layout"] = "date layout must use Go's reference date, e.g. 01/02/2006"
+	}
+
+	if form.NameColumn == "" {
+		errs["name_column"] = "name column can't be empty"
+	}
+
+	if form.AmountColumn == "" && (form.DebitColumn == "" || form.CreditColumn == "") {
+		errs["amount_column"] = "set an amount column, or both debit and credit columns"
+	}
+
+	profile := model.CSVProfile{
+		Name:         form.Name,
+		Account:      form.Account,
+		SkipRows:     skipRows,
+		DateColumn:   form.DateColumn,
+		DateLayout:   form.DateLayout,
+		NameColumn:   form.NameColumn,
+		AmountColumn: nullString(form.AmountColumn),
+		DebitColumn:  nullString(form.DebitColumn),
+		CreditColumn: nullString(form.CreditColumn),
+		NegateAmount: form.NegateAmount,
+	}
+
+	return form, profile, errs
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{Valid: s != "", String: s}
+}
+
+func (c *Controller) createCSVProfile(w http.ResponseWriter, r *http.Request) error {
+	form, profile, errs := validateCSVProfileForm(r)
+	if len(errs) != 0 {
+		return c.renderCSVProfiles(w, form, errs)
+	}
+
+	_, err := model.CreateCSVProfile(c.db, profile)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return c.renderCSVProfiles(w, form, map[string]string{
+				"name": "a profile with this name already exists",
+			})
+		}
+
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error creating csv profile: " + err.Error(),
+		}
+	}
+
+	http.Redirect(w, r, "/imports/profiles", http.StatusSeeOther)
+	return nil
+}
+
+func (c *Controller) deleteCSVProfile(w http.ResponseWriter, r *http.Request) error {
+	id := r.PathValue("id")
+
+	err := model.DeleteCSVProfile(c.db, id)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error deleting csv profile: " + err.Error(),
+		}
+	}
+
+	http.Redirect(w, r, "/imports/profiles", http.StatusSeeOther)
+	return nil
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"fin-web/internal/model"
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func creditUnionProfileForm() url.Values {
+	return url.Values{
+		"name":          {"credit_union"},
+		"account":       {"credit_union"},
+		"skip_rows":     {"1"},
+		"date_column":   {"Date"},
+		"date_layout":   {"01/02/2006"},
+		"name_column":   {"Memo"},
+		"amount_column": {"Amount"},
+		"negate_amount": {"on"},
+	}
+}
+
+func TestCSVProfilesHandlerRenders(t *testing.T) {
+	c := &Controller{db: testutil.NewDB(t)}
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.csvProfiles(rec, httptest.NewRequest(http.MethodGet, "/imports/profiles", nil)))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "New Profile")
+}
+
+func TestCreateCSVProfile(t *testing.T) {
+	db := testutil.NewDB(t)
+	c := &Controller{db: db}
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.createCSVProfile(rec, newFormRequest("/imports/profiles", creditUnionProfileForm())))
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+
+	profiles, err := model.GetCSVProfiles(db)
+	require.NoError(t, err)
+	require.Len(t, profiles, 1)
+	assert.Equal(t, "Memo", profiles[0].NameColumn)
+	assert.Equal(t, "Amount", profiles[0].AmountColumn.String)
+	assert.False(t, profiles[0].DebitColumn.Valid)
+	assert.True(t, profiles[0].NegateAmount)
+
+	// The same name again is reported on the form rather than failing.
+	rec = httptest.NewRecorder()
+	require.NoError(t, c.createCSVProfile(rec, newFormRequest("/imports/profiles", creditUnionProfileForm())))
+	assert.Contains(t, rec.Body.String(), "a profile with this name already exists")
+}
+
+func TestCreateCSVProfileValidation(t *testing.T) {
+	db := testutil.NewDB(t)
+	c := &Controller{db: db}
+
+	form := creditUnionProfileForm()
+	form.Set("date_layout", "MM/DD/YYYY")
+	form.Del("amount_column")
+	form.Set("debit_column", "Debit")
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.createCSVProfile(rec, newFormRequest("/imports/profiles", form)))
+
+	body := rec.Body.String()
+	assert.Contains(t, body, "date layout must use Go&#39;s reference date")
+	assert.Contains(t, body, "set an amount column, or both debit and credit columns")
+
+	profiles, err := model.GetCSVProfiles(db)
+	require.NoError(t, err)
+	assert.Empty(t, profiles)
+}
+
+func TestDeleteCSVProfile(t *testing.T) {
+	db := testutil.NewDB(t)
+	c := &Controller{db: db}
+	require.NoError(t, c.createCSVProfile(httptest.NewRecorder(), newFormRequest("/imports/profiles", creditUnionProfileForm())))
+	profiles, err := model.GetCSVProfiles(db)
+	require.NoError(t, err)
+	id := strconv.Itoa(profiles[0].ID)
+
+	req := httptest.NewRequest(http.MethodPost, "/imports/profiles/"+id+"/delete", nil)
+	req.SetPathValue("id", id)
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.deleteCSVProfile(rec, req))
+
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	profiles, err = model.GetCSVProfiles(db)
+	require.NoError(t, err)
+	assert.Empty(t, profiles)
+}
+
+func TestUploadUsesSavedCSVProfile(t *testing.T) {
+	c := newUploadController(t)
+	require.NoError(t, c.createCSVProfile(httptest.NewRecorder(), newFormRequest("/imports/profiles", creditUnionProfileForm())))
+
+	rec := httptest.NewRecorder()
+	req := newUploadRequest(t, map[string]string{
+		"export.csv": "Date,Memo,Amount\n02/04/2026,CORNER BAKERY,-8.25\n",
+	})
+	require.NoError(t, c.upload(rec, req))
+
+	body := rec.Body.String()
+	assert.Contains(t, body, "CORNER BAKERY")
+	assert.Contains(t, body, "credit_union")
+	assert.NotContains(t, body, "no provider recognises")
+}
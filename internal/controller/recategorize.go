@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"fin-web/internal/rules"
+)
+
+type RecategorizeFormData struct {
+	StartDate  string
+	EndDate    string
+	KeepManual bool
+}
+
+type RecategorizePage struct {
+	Form    RecategorizeFormData
+	Errs    map[string]string
+	Changes []rules.Change
+	Moves   []rules.Move
+	// Previewed is set once a valid range has been evaluated, so an empty
+	// preview can be told apart from no preview.
+	Previewed bool
+	Success   bool
+}
+
+func validateRecategorizeForm(values url.Values) (RecategorizeFormData, map[string]string) {
+	errs := map[string]string{}
+	form := RecategorizeFormData{
+		StartDate:  strings.TrimSpace(values.Get("start")),
+		EndDate:    strings.TrimSpace(values.Get("end")),
+		KeepManual: values.Get("keep_manual") == "on",
+	}
+
+	for field, value := range map[string]string{"start": form.StartDate, "end": form.EndDate} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			errs[field] = "date must be YYYY-MM-DD"
+		}
+	}
+
+	if len(errs) == 0 && form.StartDate != "" && form.EndDate != "" && form.StartDate > form.EndDate {
+		errs["end"] = "end date must be on or after the start date"
+	}
+
+	return form, errs
+}
+
+// recategorize previews what re-running categorization over a date range would
+// change. Nothing is written; the page posts back to apply.
+func (c *Controller) recategorize(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	page := RecategorizePage{Form: RecategorizeFormData{KeepManual: true}}
+
+	if query.Has("start") || query.Has("end") {
+		page.Form, page.Errs = validateRecategorizeForm(query)
+		if len(page.Errs) == 0 {
+			changes, err := rules.Plan(c.db, rules.RecategorizeOptions{
+				StartDate:  page.Form.StartDate,
+				EndDate:    page.Form.EndDate,
+				KeepManual: page.Form.KeepManual,
+			})
+			if err != nil {
+				return APIError{
+					Status:  http.StatusInternalServerError,
+					Message: "error planning recategorization: " + err.Error(),
+				}
+			}
+
+			page.Changes = changes
+			page.Moves = rules.Summarize(changes)
+			page.Previewed = true
+		}
+	}
+
+	responseCookie, err := r.Cookie("response")
+	if err != nil && err != http.ErrNoCookie {
+		fmt.Println("error getting cookie: " + err.Error())
+	}
+
+	page.Success = responseCookie != nil && responseCookie.Value == "success"
+
+	err = renderTemplate(w, Base[RecategorizePage]{
+		Data: page,
+	}, "layout", []string{"transactions/recategorize.html", "layout.html"})
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+// applyRecategorize plans the range again and applies it in one SQL
+// transaction. Planning afresh rather than trusting the preview means the
+// result matches the current rules and categories.
+func (c *Controller) applyRecategorize(w http.ResponseWriter, r *http.Request) error {
+	err := r.ParseForm()
+	if err != nil {
+		return APIError{
+			Status:  http.StatusBadRequest,
+			Message: "error parsing form: " + err.Error(),
+		}
+	}
+
+	form, errs := validateRecategorizeForm(r.PostForm)
+	if len(errs) != 0 {
+		return APIError{
+			Status:  http.StatusBadRequest,
+			Message: "invalid date range",
+		}
+	}
+
+	changes, err := rules.Plan(c.db, rules.RecategorizeOptions{
+		StartDate:  form.StartDate,
+		EndDate:    form.EndDate,
+		KeepManual: form.KeepManual,
+	})
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error planning recategorization: " + err.Error(),
+		}
+	}
+
+	_, err = rules.Apply(c.db, changes)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error applying recategorization: " + err.Error(),
+		}
+	}
+
+	cookie := &http.Cookie{
+		Name:     "response",
+		Value:    "success",
+		Path:     "/",
+		HttpOnly: true,
+		Expires:  time.Now().Add(1 * time.Second),
+	}
+
+	http.SetCookie(w, cookie)
+
+	params := url.Values{}
+	params.Set("start", form.StartDate)
+	params.Set("end", form.EndDate)
+	if form.KeepManual {
+		params.Set("keep_manual", "on")
+	}
+
+	http.Redirect(w, r, "/transactions/recategorize?"+params.Encode(), http.StatusSeeOther)
+	return nil
+}
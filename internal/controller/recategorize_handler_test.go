@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"fin-web/internal/model"
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecategorizePreview(t *testing.T) {
+	db := testutil.NewDB(t)
+	testutil.SeedCategory(t, db, "Dining", 1, "chipotle")
+	seedTransaction(t, db, "tx-1", "CHIPOTLE 88", 12, "2026-03-03", sql.NullInt32{})
+	seedTransaction(t, db, "tx-2", "CHIPOTLE 89", 12, "2026-04-03", sql.NullInt32{})
+	c := &Controller{db: db}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/transactions/recategorize?start=2026-03-01&end=2026-03-31", nil)
+	require.NoError(t, c.recategorize(rec, req))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, "1 transactions would move")
+	assert.Contains(t, body, "Uncategorized → Dining: 1")
+	assert.NotContains(t, body, "CHIPOTLE 89")
+
+	tx, err := model.GetTransaction(db, "tx-1")
+	require.NoError(t, err)
+	assert.False(t, tx.CategoryID.Valid, "preview must not write")
+}
+
+func TestRecategorizePreviewValidation(t *testing.T) {
+	db := testutil.NewDB(t)
+	c := &Controller{db: db}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/transactions/recategorize?start=2026-04-01&end=2026-03-01", nil)
+	require.NoError(t, c.recategorize(rec, req))
+
+	assert.Contains(t, rec.Body.String(), "end date must be on or after the start date")
+	assert.NotContains(t, rec.Body.String(), "would move")
+}
+
+func TestApplyRecategorize(t *testing.T) {
+	db := testutil.NewDB(t)
+	dining := testutil.SeedCategory(t, db, "Dining", 1, "chipotle")
+	other := mustCreateCategory(t, db, "Other", 2, "fun")
+	seedTransaction(t, db, "tx-1", "CHIPOTLE 88", 12, "2026-03-03", sql.NullInt32{})
+	seedTransaction(t, db, "tx-2", "CHIPOTLE 89", 12, "2026-03-04", sql.NullInt32{})
+	require.NoError(t, model.UpdateTransaction(db, "tx-2", model.UpdateTransactionParams{CategoryID: &other}))
+	c := &Controller{db: db}
+
+	rec := httptest.NewRecorder()
+	err := c.applyRecategorize(rec, newFormRequest("/transactions/recategorize", url.Values{
+		"start":       {"2026-03-01"},
+		"end":         {"2026-03-31"},
+		"keep_manual": {"on"},
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Equal(t, "/transactions/recategorize?end=2026-03-31&keep_manual=on&start=2026-03-01", rec.Header().Get("Location"))
+
+	tx, err := model.GetTransaction(db, "tx-1")
+	require.NoError(t, err)
+	assert.Equal(t, int32(dining), tx.CategoryID.Int32)
+
+	tx, err = model.GetTransaction(db, "tx-2")
+	require.NoError(t, err)
+	assert.Equal(t, int32(other), tx.CategoryID.Int32, "manual category kept")
+}
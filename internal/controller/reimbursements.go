@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"fin-web/internal/model"
+)
+
+// maxReimbursableExpenses caps the expenses offered when linking a
+// reimbursement.
+const maxReimbursableExpenses = 100
+
+type ReimbursementFormData struct {
+	ExpenseID string
+	Amount    string
+}
+
+// validateReimbursementForm reads the expense to pay back and the amount to
+// apply, which may be left blank to apply as much as fits.
+func validateReimbursementForm(form url.Values) (ReimbursementFormData, float64, map[string]string) {
+	errs := map[string]string{}
+	data := ReimbursementFormData{
+		ExpenseID: strings.TrimSpace(form.Get("expense")),
+		Amount:    strings.TrimSpace(form.Get("amount")),
+	}
+
+	if data.ExpenseID == "" {
+		errs["expense"] = "pick an expense"
+	}
+
+	var amount float64
+	if data.Amount != "" {
+		value, err := strconv.ParseFloat(data.Amount, 64)
+		if err != nil || value <= 0 {
+			errs["amount"] = "amount must be a positive number"
+		}
+		amount = value
+	}
+
+	return data, amount, errs
+}
+
+// linkReimbursement applies the transaction, a reimbursement, to an expense.
+func (c *Controller) linkReimbursement(w http.ResponseWriter, r *http.Request) error {
+	id := r.PathValue("id")
+
+	err := r.ParseForm()
+	if err != nil {
+		return APIError{
+			Status:  http.StatusBadRequest,
+			Message: "error parsing form: " + err.Error(),
+		}
+	}
+
+	form, amount, errs := validateReimbursementForm(r.PostForm)
+	if len(errs) == 0 {
+		_, err = model.LinkReimbursement(c.db, id, form.ExpenseID, amount)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return APIError{
+				Status:  http.StatusNotFound,
+				Message: "transaction not found",
+			}
+		case errors.Is(err, model.ErrReimbursementAmount), errors.Is(err, model.ErrReimbursementSides):
+			errs["amount"] = err.Error()
+		case err != nil:
+			return APIError{
+				Status:  http.StatusInternalServerError,
+				Message: "error linking reimbursement: " + err.Error(),
+			}
+		}
+	}
+
+	if len(errs) != 0 {
+		return c.renderTransaction(w, r, transactionForms{Reimbursement: form, ReimbursementErrs: errs})
+	}
+
+	cookie := &http.Cookie{
+		Name:     "response",
+		Value:    "success",
+		Path:     "/",
+		HttpOnly: true,
+		Expires:  time.Now().Add(1 * time.Second),
+	}
+
+	http.SetCookie(w, cookie)
+
+	http.Redirect(w, r, "/transactions/"+id, http.StatusSeeOther)
+	return nil
+}
+
+func (c *Controller) deleteReimbursement(w http.ResponseWriter, r *http.Request) error {
+	id := r.PathValue("id")
+
+	err := model.DeleteReimbursement(c.db, id, r.PathValue("reimbursement"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return APIError{
+				Status:  http.StatusNotFound,
+				Message: "reimbursement not found",
+			}
+		}
+
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error removing reimbursement: " + err.Error(),
+		}
+	}
+
+	http.Redirect(w, r, "/transactions/"+id, http.StatusSeeOther)
+	return nil
+}
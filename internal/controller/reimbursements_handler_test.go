@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"fin-web/internal/model"
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkReimbursement(t *testing.T) {
+	db := testutil.NewDB(t)
+	income := mustCreateCategory(t, db, "Work", 1, "income")
+	travel := mustCreateCategory(t, db, "Travel", 2, "fun")
+	seedTransaction(t, db, "hotel", "MARRIOTT", 250, "2026-03-02", catID(travel))
+	seedTransaction(t, db, "payback", "ACME EXPENSES", -250, "2026-03-20", catID(income))
+	c := &Controller{db: db}
+
+	req := httptest.NewRequest(http.MethodGet, "/transactions/payback", nil)
+	req.SetPathValue("id", "payback")
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.transaction(rec, req))
+	assert.Contains(t, rec.Body.String(), "MARRIOTT")
+
+	req = newFormRequest("/transactions/payback/reimbursements", url.Values{"expense": {"hotel"}, "amount": {"300"}})
+	req.SetPathValue("id", "payback")
+	rec = httptest.NewRecorder()
+	require.NoError(t, c.linkReimbursement(rec, req))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), model.ErrReimbursementAmount.Error())
+
+	req = newFormRequest("/transactions/payback/reimbursements", url.Values{"expense": {"hotel"}, "amount": {""}})
+	req.SetPathValue("id", "payback")
+	rec = httptest.NewRecorder()
+	require.NoError(t, c.linkReimbursement(rec, req))
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+
+	expenses, err := model.SumTransactions(db, model.QueryTransactionsFilters{Type: "expenses"})
+	require.NoError(t, err)
+	assert.Zero(t, expenses)
+
+	req = httptest.NewRequest(http.MethodGet, "/transactions/hotel", nil)
+	req.SetPathValue("id", "hotel")
+	rec = httptest.NewRecorder()
+	require.NoError(t, c.transaction(rec, req))
+	body := rec.Body.String()
+	assert.Contains(t, body, "250.00 of")
+	assert.Contains(t, body, "ACME EXPENSES")
+
+	req = newFormRequest("/transactions/hotel/reimbursements/1/delete", url.Values{})
+	req.SetPathValue("id", "hotel")
+	req.SetPathValue("reimbursement", "1")
+	rec = httptest.NewRecorder()
+	require.NoError(t, c.deleteReimbursement(rec, req))
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+
+	links, err := model.GetReimbursements(db, "hotel")
+	require.NoError(t, err)
+	assert.Empty(t, links)
+}
+
+func TestLinkReimbursementRequiresExpense(t *testing.T) {
+	db := testutil.NewDB(t)
+	seedTransaction(t, db, "payback", "ACME EXPENSES", -250, "2026-03-20", catID(mustCreateCategory(t, db, "Work", 1, "income")))
+	c := &Controller{db: db}
+
+	req := newFormRequest("/transactions/payback/reimbursements", url.Values{"amount": {"-5"}})
+	req.SetPathValue("id", "payback")
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.linkReimbursement(rec, req))
+	body := rec.Body.String()
+	assert.Contains(t, body, "pick an expense")
+	assert.Contains(t, body, "amount must be a positive number")
+}
+
+func TestHealthListsAwaitingReimbursement(t *testing.T) {
+	db := testutil.NewDB(t)
+	travel := mustCreateCategory(t, db, "Travel", 1, "fun")
+	seedTransaction(t, db, "flight", "UNITED AIRLINES", 400, "2026-03-02", catID(travel))
+	c := &Controller{db: db}
+
+	form := url.Values{"category": {"1"}, "expects_reimbursement": {"on"}}
+	req := newFormRequest("/transactions/flight", form)
+	req.SetPathValue("id", "flight")
+	require.NoError(t, c.updateTransaction(httptest.NewRecorder(), req))
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.health(rec, httptest.NewRequest(http.MethodGet, "/health", nil)))
+	body := rec.Body.String()
+	assert.Contains(t, body, "Awaiting Reimbursement")
+	assert.Contains(t, body, "UNITED AIRLINES")
+}
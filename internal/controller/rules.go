@@ -0,0 +1,234 @@
+package controller
+
+import (
+	"database/sql"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"fin-web/internal/model"
+	"fin-web/internal/rules"
+)
+
+// recentRuleMatches is how many of the latest transactions the rules page
+// checks against the current rules.
+const recentRuleMatches = 50
+
+type RuleFormData struct {
+	Priority        string
+	MatchType       string
+	Pattern         string
+	MinAmount       string
+	MaxAmount       string
+	Source          string
+	Account         string
+	DayMin          string
+	DayMax          string
+	CategoryID      string
+	IsReimbursement bool
+}
+
+// RuleMatch pairs a transaction with the rule that categorized it at import
+// and the rule that would fire if it were imported now.
+type RuleMatch struct {
+	Transaction model.Transaction
+	Fires       *model.CategoryRule
+}
+
+type RulesPage struct {
+	Rules      []model.CategoryRule
+	Categories []model.Category
+	MatchTypes []string
+	Recent     []RuleMatch
+	Form       RuleFormData
+	Errs       map[string]string
+}
+
+func (c *Controller) renderRules(w http.ResponseWriter, form RuleFormData, errs map[string]string) error {
+	rs, err := model.GetCategoryRules(c.db)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error fetching category rules: " + err.Error(),
+		}
+	}
+
+	cs, err := model.GetCategories(c.db)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error fetching categories: " + err.Error(),
+		}
+	}
+
+	engine, err := rules.New(rs)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error compiling category rules: " + err.Error(),
+		}
+	}
+
+	transactions, err := model.QueryTransactions(c.db, model.QueryTransactionsFilters{
+		OrderBy:        "date",
+		OrderDirection: "DESC",
+		Limit:          recentRuleMatches,
+	})
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error fetching transactions: " + err.Error(),
+		}
+	}
+
+	recent := make([]RuleMatch, len(transactions))
+	for i, t := range transactions {
+		recent[i] = RuleMatch{Transaction: t, Fires: engine.Match(t)}
+	}
+
+	err = renderTemplate(w, Base[RulesPage]{
+		Data: RulesPage{
+			Rules:      rs,
+			Categories: cs,
+			MatchTypes: model.RuleMatchTypes,
+			Recent:     recent,
+			Form:       form,
+			Errs:       errs,
+		},
+	}, "layout", []string{"rules.html", "layout.html"})
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+func (c *Controller) rules(w http.ResponseWriter, r *http.Request) error {
+	return c.renderRules(w, RuleFormData{MatchType: model.RuleMatchContains}, nil)
+}
+
+func validateRuleForm(r *http.Request) (RuleFormData, model.CategoryRule, map[string]string) {
+	errs := map[string]string{}
+	form := RuleFormData{
+		Priority:        r.FormValue("priority"),
+		MatchType:       r.FormValue("match_type"),
+		Pattern:         strings.TrimSpace(r.FormValue("pattern")),
+		MinAmount:       strings.TrimSpace(r.FormValue("min_amount")),
+		MaxAmount:       strings.TrimSpace(r.FormValue("max_amount")),
+		Source:          strings.TrimSpace(r.FormValue("source")),
+		Account:         strings.TrimSpace(r.FormValue("account")),
+		DayMin:          strings.TrimSpace(r.FormValue("day_min")),
+		DayMax:          strings.TrimSpace(r.FormValue("day_max")),
+		CategoryID:      r.FormValue("category"),
+		IsReimbursement: r.FormValue("is_reimbursement") == "on",
+	}
+	rule := model.CategoryRule{
+		MatchType:       form.MatchType,
+		Pattern:         form.Pattern,
+		Source:          nullString(form.Source),
+		Account:         nullString(form.Account),
+		IsReimbursement: form.IsReimbursement,
+	}
+
+	var err error
+	rule.Priority, err = strconv.Atoi(form.Priority)
+	if err != nil {
+		errs["priority"] = "priority must be a whole number"
+	}
+
+	if !slices.Contains(model.RuleMatchTypes, form.MatchType) {
+		errs["match_type"] = "pick a match type"
+	}
+
+	if form.Pattern == "" {
+		errs["pattern"] = "pattern can't be empty"
+	} else if _, ok := errs["match_type"]; !ok {
+		if err := rules.Validate(rule); err != nil {
+			errs["pattern"] = err.Error()
+		}
+	}
+
+	rule.MinAmount = parseOptionalFloat(form.MinAmount, "min_amount", errs)
+	rule.MaxAmount = parseOptionalFloat(form.MaxAmount, "max_amount", errs)
+	if rule.MinAmount.Valid && rule.MaxAmount.Valid && rule.MinAmount.Float64 > rule.MaxAmount.Float64 {
+		errs["max_amount"] = "max amount must be at least the min amount"
+	}
+
+	rule.DayMin = parseOptionalDay(form.DayMin, "day_min", errs)
+	rule.DayMax = parseOptionalDay(form.DayMax, "day_max", errs)
+	if rule.DayMin.Valid && rule.DayMax.Valid && rule.DayMin.Int32 > rule.DayMax.Int32 {
+		errs["day_max"] = "last day must be on or after the first day"
+	}
+
+	rule.CategoryID, err = strconv.Atoi(form.CategoryID)
+	if err != nil {
+		errs["category"] = "pick a category"
+	}
+
+	return form, rule, errs
+}
+
+func parseOptionalFloat(value string, field string, errs map[string]string) sql.NullFloat64 {
+	if value == "" {
+		return sql.NullFloat64{}
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		errs[field] = "must be a number"
+		return sql.NullFloat64{}
+	}
+
+	return sql.NullFloat64{Valid: true, Float64: f}
+}
+
+func parseOptionalDay(value string, field string, errs map[string]string) sql.NullInt32 {
+	if value == "" {
+		return sql.NullInt32{}
+	}
+
+	day, err := strconv.Atoi(value)
+	if err != nil || day < 1 || day > 31 {
+		errs[field] = "must be a day of the month from 1 to 31"
+		return sql.NullInt32{}
+	}
+
+	return sql.NullInt32{Valid: true, Int32: int32(day)}
+}
+
+func (c *Controller) createRule(w http.ResponseWriter, r *http.Request) error {
+	form, rule, errs := validateRuleForm(r)
+	if len(errs) != 0 {
+		return c.renderRules(w, form, errs)
+	}
+
+	_, err := model.CreateCategoryRule(c.db, rule)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error creating category rule: " + err.Error(),
+		}
+	}
+
+	http.Redirect(w, r, "/rules", http.StatusSeeOther)
+	return nil
+}
+
+func (c *Controller) deleteRule(w http.ResponseWriter, r *http.Request) error {
+	id := r.PathValue("id")
+
+	err := model.DeleteCategoryRule(c.db, id)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error deleting category rule: " + err.Error(),
+		}
+	}
+
+	http.Redirect(w, r, "/rules", http.StatusSeeOther)
+	return nil
+}
@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"fin-web/internal/model"
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRulesHandlerShowsWhichRuleFires(t *testing.T) {
+	db := testutil.NewDB(t)
+	home := mustCreateCategory(t, db, "Home", 1, "fixed")
+	_, err := model.CreateCategoryRule(db, model.CategoryRule{
+		Priority:   1,
+		MatchType:  model.RuleMatchContains,
+		Pattern:    "amazon",
+		MinAmount:  sql.NullFloat64{Valid: true, Float64: 200},
+		CategoryID: home,
+	})
+	require.NoError(t, err)
+	seedTransaction(t, db, "tx-1", "AMAZON MKTPL", 250, "2026-02-10", sql.NullInt32{})
+	c := &Controller{db: db}
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.rules(rec, httptest.NewRequest(http.MethodGet, "/rules", nil)))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, `contains "amazon"`)
+	assert.Contains(t, body, "rule #1 → Home")
+}
+
+func TestCreateRule(t *testing.T) {
+	db := testutil.NewDB(t)
+	home := mustCreateCategory(t, db, "Home", 1, "fixed")
+	c := &Controller{db: db}
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.createRule(rec, newFormRequest("/rules", url.Values{
+		"priority":         {"5"},
+		"match_type":       {"regex"},
+		"pattern":          {`^venmo \*`},
+		"min_amount":       {"1000"},
+		"day_min":          {"1"},
+		"day_max":          {"5"},
+		"category":         {strconv.Itoa(home)},
+		"is_reimbursement": {"on"},
+	})))
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+
+	rs, err := model.GetCategoryRules(db)
+	require.NoError(t, err)
+	require.Len(t, rs, 1)
+	assert.Equal(t, model.RuleMatchRegex, rs[0].MatchType)
+	assert.Equal(t, 1000.0, rs[0].MinAmount.Float64)
+	assert.False(t, rs[0].MaxAmount.Valid)
+	assert.Equal(t, int32(5), rs[0].DayMax.Int32)
+	assert.True(t, rs[0].IsReimbursement)
+}
+
+func TestCreateRuleValidation(t *testing.T) {
+	db := testutil.NewDB(t)
+	c := &Controller{db: db}
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.createRule(rec, newFormRequest("/rules", url.Values{
+		"priority":   {"1"},
+		"match_type": {"regex"},
+		"pattern":    {"(unclosed"},
+		"min_amount": {"50"},
+		"max_amount": {"10"},
+		"day_min":    {"32"},
+	})))
+
+	body := rec.Body.String()
+	assert.Contains(t, body, "missing closing )")
+	assert.Contains(t, body, "max amount must be at least the min amount")
+	assert.Contains(t, body, "must be a day of the month from 1 to 31")
+	assert.Contains(t, body, "pick a category")
+
+	rs, err := model.GetCategoryRules(db)
+	require.NoError(t, err)
+	assert.Empty(t, rs)
+}
+
+func TestDeleteRule(t *testing.T) {
+	db := testutil.NewDB(t)
+	home := mustCreateCategory(t, db, "Home", 1, "fixed")
+	id, err := model.CreateCategoryRule(db, model.CategoryRule{Priority: 1, MatchType: model.RuleMatchExact, Pattern: "ikea", CategoryID: home})
+	require.NoError(t, err)
+	c := &Controller{db: db}
+
+	req := httptest.NewRequest(http.MethodPost, "/rules/"+strconv.Itoa(id)+"/delete", nil)
+	req.SetPathValue("id", strconv.Itoa(id))
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.deleteRule(rec, req))
+
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	rs, err := model.GetCategoryRules(db)
+	require.NoError(t, err)
+	assert.Empty(t, rs)
+}
@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"fin-web/internal/model"
+)
+
+// blankSplitRows is how many empty rows the split form offers beyond the
+// saved splits.
+const blankSplitRows = 2
+
+type SplitFormRow struct {
+	CategoryID  string
+	Amount      string
+	Description string
+}
+
+func splitFormRows(splits []model.Split) []SplitFormRow {
+	rows := make([]SplitFormRow, 0, len(splits)+blankSplitRows)
+	for _, s := range splits {
+		row := SplitFormRow{
+			Amount:      strconv.FormatFloat(s.Amount, 'f', 2, 64),
+			Description: s.Description.String,
+		}
+		if s.CategoryID.Valid {
+			row.CategoryID = strconv.Itoa(int(s.CategoryID.Int32))
+		}
+		rows = append(rows, row)
+	}
+
+	for range blankSplitRows {
+		rows = append(rows, SplitFormRow{})
+	}
+
+	return rows
+}
+
+// validateSplitForm reads the split rows, skipping blank ones. No rows at all
+// means the transaction should no longer be split.
+func validateSplitForm(r *http.Request, amount float64) ([]SplitFormRow, []model.Split, map[string]string) {
+	errs := map[string]string{}
+	categories := r.PostForm["split_category"]
+	amounts := r.PostForm["split_amount"]
+	descriptions := r.PostForm["split_description"]
+
+	rows := []SplitFormRow{}
+	splits := []model.Split{}
+	for i := range amounts {
+		row := SplitFormRow{Amount: strings.TrimSpace(amounts[i])}
+		if i < len(categories) {
+			row.CategoryID = categories[i]
+		}
+		if i < len(descriptions) {
+			row.Description = strings.TrimSpace(descriptions[i])
+		}
+		rows = append(rows, row)
+
+		if row.Amount == "" && row.CategoryID == "" && row.Description == "" {
+			continue
+		}
+
+		split := model.Split{Description: nullString(row.Description)}
+
+		value, err := strconv.ParseFloat(row.Amount, 64)
+		if err != nil {
+			errs["splits"] = fmt.Sprintf("row %d: amount must be a number", i+1)
+			continue
+		}
+		split.Amount = value
+
+		categoryID, err := strconv.Atoi(row.CategoryID)
+		if err != nil {
+			errs["splits"] = fmt.Sprintf("row %d: pick a category", i+1)
+			continue
+		}
+		split.CategoryID = sql.NullInt32{Valid: true, Int32: int32(categoryID)}
+
+		splits = append(splits, split)
+	}
+
+	if len(errs) != 0 {
+		return rows, splits, errs
+	}
+
+	if len(splits) == 1 {
+		errs["splits"] = "a split needs at least two parts"
+	} else if len(splits) > 1 && !model.SplitsSum(amount, splits) {
+		total := 0.0
+		for _, s := range splits {
+			total += s.Amount
+		}
+		errs["splits"] = fmt.Sprintf("splits add up to %.2f but the transaction is %.2f", total, amount)
+	}
+
+	return rows, splits, errs
+}
+
+func (c *Controller) updateSplits(w http.ResponseWriter, r *http.Request) error {
+	id := r.PathValue("id")
+
+	err := r.ParseForm()
+	if err != nil {
+		return APIError{
+			Status:  http.StatusBadRequest,
+			Message: "error parsing form: " + err.Error(),
+		}
+	}
+
+	transaction, err := model.GetTransaction(c.db, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return APIError{
+				Status:  http.StatusNotFound,
+				Message: "transaction not found",
+			}
+		}
+
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error fetching transaction: " + err.Error(),
+		}
+	}
+
+	rows, splits, errs := validateSplitForm(r, transaction.Amount)
+	if len(errs) != 0 {
+		return c.renderTransaction(w, r, transactionForms{Splits: rows, SplitErrs: errs})
+	}
+
+	err = model.SaveSplits(c.db, id, splits)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error saving splits: " + err.Error(),
+		}
+	}
+
+	cookie := &http.Cookie{
+		Name:     "response",
+		Value:    "success",
+		Path:     "/",
+		HttpOnly: true,
+		Expires:  time.Now().Add(1 * time.Second),
+	}
+
+	http.SetCookie(w, cookie)
+
+	http.Redirect(w, r, "/transactions/"+id, http.StatusSeeOther)
+	return nil
+}
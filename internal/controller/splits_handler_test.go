@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"fin-web/internal/model"
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateSplits(t *testing.T) {
+	db := testutil.NewDB(t)
+	groceries := mustCreateCategory(t, db, "Groceries", 1, "fixed")
+	household := mustCreateCategory(t, db, "Household", 2, "fun")
+	seedTransaction(t, db, "tx-1", "COSTCO", 100, "2026-03-03", catID(groceries))
+	c := &Controller{db: db}
+
+	req := newFormRequest("/transactions/tx-1/splits", url.Values{
+		"split_category":    {strconv.Itoa(groceries), strconv.Itoa(household), ""},
+		"split_amount":      {"75.50", "24.50", ""},
+		"split_description": {"food", "paper towels", ""},
+	})
+	req.SetPathValue("id", "tx-1")
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.updateSplits(rec, req))
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+
+	splits, err := model.GetSplits(db, "tx-1")
+	require.NoError(t, err)
+	require.Len(t, splits, 2)
+	assert.Equal(t, "paper towels", splits[1].Description.String)
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/transactions/tx-1", nil)
+	req.SetPathValue("id", "tx-1")
+	require.NoError(t, c.transaction(rec, req))
+	assert.Contains(t, rec.Body.String(), `value="24.50"`)
+}
+
+func TestUpdateSplitsValidation(t *testing.T) {
+	db := testutil.NewDB(t)
+	groceries := mustCreateCategory(t, db, "Groceries", 1, "fixed")
+	household := mustCreateCategory(t, db, "Household", 2, "fun")
+	seedTransaction(t, db, "tx-1", "COSTCO", 100, "2026-03-03", catID(groceries))
+	c := &Controller{db: db}
+
+	tests := []struct {
+		name string
+		form url.Values
+		want string
+	}{
+		{
+			name: "does not sum",
+			form: url.Values{
+				"split_category":    {strconv.Itoa(groceries), strconv.Itoa(household)},
+				"split_amount":      {"70", "20"},
+				"split_description": {"", ""},
+			},
+			want: "splits add up to 90.00 but the transaction is 100.00",
+		},
+		{
+			name: "one part",
+			form: url.Values{
+				"split_category":    {strconv.Itoa(groceries), ""},
+				"split_amount":      {"100", ""},
+				"split_description": {"", ""},
+			},
+			want: "a split needs at least two parts",
+		},
+		{
+			name: "missing category",
+			form: url.Values{
+				"split_category":    {strconv.Itoa(groceries), ""},
+				"split_amount":      {"70", "30"},
+				"split_description": {"", ""},
+			},
+			want: "row 2: pick a category",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newFormRequest("/transactions/tx-1/splits", tt.form)
+			req.SetPathValue("id", "tx-1")
+			rec := httptest.NewRecorder()
+			require.NoError(t, c.updateSplits(rec, req))
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.want)
+		})
+	}
+
+	splits, err := model.GetSplits(db, "tx-1")
+	require.NoError(t, err)
+	assert.Empty(t, splits)
+}
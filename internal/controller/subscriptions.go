@@ -2,6 +2,9 @@ package controller
 
 import (
 	"net/http"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	"fin-web/internal/model"
@@ -15,6 +18,8 @@ type SubscriptionsPage struct {
 	MonthlySubTotal  float64
 	AnnualSubTotal   float64
 	MonthlyBillTotal float64
+	// Keys are every merchant key on the page, offered as merge targets.
+	Keys []string
 }
 
 func (c *Controller) subscriptions(w http.ResponseWriter, r *http.Request) error {
@@ -26,7 +31,15 @@ func (c *Controller) subscriptions(w http.ResponseWriter, r *http.Request) error
 		}
 	}
 
-	report := recurring.Detect(charges, time.Now())
+	decisions, err := model.GetRecurringDecisions(c.db)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error fetching recurring decisions: " + err.Error(),
+		}
+	}
+
+	report := recurring.DetectWith(charges, time.Now(), decisions)
 
 	page := SubscriptionsPage{
 		Report:           report,
@@ -36,6 +49,12 @@ func (c *Controller) subscriptions(w http.ResponseWriter, r *http.Request) error
 		AnnualSubTotal:   report.MonthlySubTotal * 12,
 		MonthlyBillTotal: report.MonthlyBillTotal,
 	}
+	for _, rs := range [][]recurring.Recurring{report.Subscriptions, report.Bills, report.Possible, report.Canceled, report.Ignored} {
+		for _, r := range rs {
+			page.Keys = append(page.Keys, r.Merchant)
+		}
+	}
+	sort.Strings(page.Keys)
 
 	if err := renderTemplate(w, Base[SubscriptionsPage]{Data: page}, "layout", []string{"subscriptions.html", "layout.html"}); err != nil {
 		return APIError{
@@ -46,3 +65,76 @@ func (c *Controller) subscriptions(w http.ResponseWriter, r *http.Request) error
 
 	return nil
 }
+
+// setRecurringDecision updates the decision for one merchant key. Fields left
+// out of the form keep their saved values, so the confirm and ignore buttons
+// don't clear a display name.
+func (c *Controller) setRecurringDecision(w http.ResponseWriter, r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return APIError{
+			Status:  http.StatusBadRequest,
+			Message: "error parsing form: " + err.Error(),
+		}
+	}
+
+	key := strings.TrimSpace(r.PostForm.Get("merchant"))
+	if key == "" {
+		return APIError{
+			Status:  http.StatusBadRequest,
+			Message: "merchant is required",
+		}
+	}
+
+	decisions, err := model.GetRecurringDecisions(c.db)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error fetching recurring decisions: " + err.Error(),
+		}
+	}
+
+	decision := decisions[key]
+	decision.Key = key
+	set := func(field string, dst *string) {
+		if _, ok := r.PostForm[field]; ok {
+			*dst = strings.TrimSpace(r.PostForm.Get(field))
+		}
+	}
+	set("status", &decision.Status)
+	set("display_name", &decision.DisplayName)
+	set("merge_into", &decision.MergeInto)
+	set("cadence", &decision.Cadence)
+
+	if !slices.Contains([]string{"", recurring.DecisionConfirmed, recurring.DecisionIgnored}, decision.Status) {
+		return APIError{
+			Status:  http.StatusBadRequest,
+			Message: "status must be confirmed, ignored or empty",
+		}
+	}
+
+	if decision.Cadence != "" && !slices.Contains(recurring.Cadences, decision.Cadence) {
+		return APIError{
+			Status:  http.StatusBadRequest,
+			Message: "cadence must be one of " + strings.Join(recurring.Cadences, ", "),
+		}
+	}
+
+	// A merge that leads back to key would form a cycle.
+	decisions[key] = decision
+	if decision.MergeInto != "" && recurring.Resolve(decision.MergeInto, decisions) == key {
+		return APIError{
+			Status:  http.StatusBadRequest,
+			Message: "can't merge " + key + " into itself",
+		}
+	}
+
+	if err := model.SetRecurringDecision(c.db, decision); err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error saving recurring decision: " + err.Error(),
+		}
+	}
+
+	http.Redirect(w, r, "/subscriptions", http.StatusSeeOther)
+	return nil
+}
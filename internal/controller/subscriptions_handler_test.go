@@ -4,9 +4,12 @@ import (
 	"database/sql"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
+	"fin-web/internal/model"
+	"fin-web/internal/recurring"
 	"fin-web/internal/testutil"
 
 	"github.com/stretchr/testify/assert"
@@ -41,3 +44,68 @@ func TestSubscriptionsHandlerEmpty(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rec.Code)
 	assert.Contains(t, rec.Body.String(), "None detected")
 }
+
+func TestSetRecurringDecision(t *testing.T) {
+	db := testutil.NewDB(t)
+	c := &Controller{db: db}
+
+	post := func(values url.Values) error {
+		return c.setRecurringDecision(httptest.NewRecorder(), newFormRequest("/subscriptions/decisions", values))
+	}
+
+	require.NoError(t, post(url.Values{
+		"merchant":     {"NETFLIX.COM"},
+		"status":       {""},
+		"display_name": {"Netflix"},
+		"merge_into":   {""},
+		"cadence":      {"monthly"},
+	}))
+
+	// The quick buttons only send a status; the rest is kept.
+	require.NoError(t, post(url.Values{"merchant": {"NETFLIX.COM"}, "status": {"ignored"}}))
+
+	decisions, err := model.GetRecurringDecisions(db)
+	require.NoError(t, err)
+	assert.Equal(t, recurring.Decision{
+		Key:         "NETFLIX.COM",
+		Status:      recurring.DecisionIgnored,
+		DisplayName: "Netflix",
+		Cadence:     "monthly",
+	}, decisions["NETFLIX.COM"])
+
+	require.NoError(t, post(url.Values{"merchant": {"HULU"}, "merge_into": {"NETFLIX.COM"}}))
+
+	for name, values := range map[string]url.Values{
+		"no merchant": {"status": {"confirmed"}},
+		"bad status":  {"merchant": {"HULU"}, "status": {"maybe"}},
+		"bad cadence": {"merchant": {"HULU"}, "cadence": {"daily"}},
+		"self merge":  {"merchant": {"HULU"}, "merge_into": {"HULU"}},
+		"cycle":       {"merchant": {"NETFLIX.COM"}, "merge_into": {"HULU"}},
+	} {
+		var apiErr APIError
+		require.ErrorAs(t, post(values), &apiErr, name)
+		assert.Equal(t, http.StatusBadRequest, apiErr.Status, name)
+	}
+}
+
+func TestSubscriptionsHandlerAppliesDecisions(t *testing.T) {
+	db := testutil.NewDB(t)
+
+	last := time.Now().AddDate(0, 0, -5)
+	for i := 4; i >= 0; i-- {
+		d := last.AddDate(0, 0, -30*i).Format("2006-01-02")
+		seedTransaction(t, db, "nf-"+d, "NETFLIX.COM", 15.49, d, sql.NullInt32{})
+		seedTransaction(t, db, "hu-"+d, "HULU", 7.99, d, sql.NullInt32{})
+	}
+	require.NoError(t, model.SetRecurringDecision(db, recurring.Decision{Key: "NETFLIX.COM", DisplayName: "Netflix Premium"}))
+	require.NoError(t, model.SetRecurringDecision(db, recurring.Decision{Key: "HULU", Status: recurring.DecisionIgnored}))
+
+	c := &Controller{db: db}
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.subscriptions(rec, httptest.NewRequest(http.MethodGet, "/subscriptions", nil)))
+
+	body := rec.Body.String()
+	assert.Contains(t, body, "Netflix Premium")
+	assert.Contains(t, body, "<h3>Ignored</h3>")
+	assert.Contains(t, body, "Restore")
+}
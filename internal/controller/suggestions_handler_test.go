@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"fin-web/internal/model"
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUncategorizedShowsSuggestions(t *testing.T) {
+	db := testutil.NewDB(t)
+	dining := mustCreateCategory(t, db, "Dining", 1, "fun")
+	seedTransaction(t, db, "old-1", "SWEETGREEN 1234 NEW YORK NY", 14, "2026-02-03", catID(dining))
+	seedTransaction(t, db, "old-2", "SWEETGREEN 5678 NEW YORK NY", 14, "2026-02-10", catID(dining))
+	seedTransaction(t, db, "new", "SWEETGREEN 9012 NEW YORK NY", 15, "2026-03-03", sql.NullInt32{})
+	c := &Controller{db: db}
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.uncategorizedTransactions(rec, httptest.NewRequest(http.MethodGet, "/transactions/uncategorized", nil)))
+
+	body := rec.Body.String()
+	assert.Contains(t, body, `action="/transactions/new/category"`)
+	assert.Contains(t, body, "Dining (67%)")
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/transactions/new", nil)
+	req.SetPathValue("id", "new")
+	require.NoError(t, c.transaction(rec, req))
+	assert.Contains(t, rec.Body.String(), "Suggested category: Dining (67% confidence")
+}
+
+func TestSetTransactionCategory(t *testing.T) {
+	db := testutil.NewDB(t)
+	dining := mustCreateCategory(t, db, "Dining", 1, "fun")
+	seedTransaction(t, db, "tx-1", "SWEETGREEN", 14, "2026-02-03", sql.NullInt32{})
+	c := &Controller{db: db}
+
+	tests := []struct {
+		redirect string
+		want     string
+	}{
+		{redirect: "/transactions/uncategorized", want: "/transactions/uncategorized"},
+		{redirect: "/transactions/uncategorized?pageSize=50", want: "/transactions/uncategorized?pageSize=50"},
+		{redirect: "//evil.example", want: "/transactions/tx-1"},
+		{redirect: "/\\evil.example", want: "/transactions/tx-1"},
+		{redirect: "https://evil.example/transactions/uncategorized", want: "/transactions/tx-1"},
+		{redirect: "/categories", want: "/transactions/tx-1"},
+		{redirect: "", want: "/transactions/tx-1"},
+	}
+
+	for _, tt := range tests {
+		req := newFormRequest("/transactions/tx-1/category", url.Values{
+			"category": {strconv.Itoa(dining)},
+			"redirect": {tt.redirect},
+		})
+		req.SetPathValue("id", "tx-1")
+		rec := httptest.NewRecorder()
+		require.NoError(t, c.setTransactionCategory(rec, req))
+
+		assert.Equal(t, http.StatusSeeOther, rec.Code)
+		assert.Equal(t, tt.want, rec.Header().Get("Location"))
+	}
+
+	tx, err := model.GetTransaction(db, "tx-1")
+	require.NoError(t, err)
+	assert.Equal(t, int32(dining), tx.CategoryID.Int32)
+	assert.Equal(t, model.CategorySourceManual, tx.CategorySource.String)
+}
@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"fin-web/internal/model"
+)
+
+// TransactionFilters is the filter bar on / beyond dates, sorting and
+// categories. Amount bounds and the reimbursement choice are kept as given so
+// the inputs can be filled back in.
+type TransactionFilters struct {
+	Search        string
+	Accounts      map[string]bool
+	Sources       map[string]bool
+	MinAmount     string
+	MaxAmount     string
+	Reimbursement string
+	Merchant      string
+
+	minAmount       *float64
+	maxAmount       *float64
+	isReimbursement *bool
+}
+
+// parseTransactionFilters reads the filter bar's query parameters:
+//
+//	q              search box query, see model.ParseSearch
+//	accounts       comma-separated accounts
+//	sources        comma-separated sources
+//	minAmount      lowest signed amount; income is negative
+//	maxAmount      highest signed amount
+//	reimbursement  "true" for reimbursements only, "false" to leave them out
+//	merchant       normalized merchant prefix
+func parseTransactionFilters(q url.Values) (TransactionFilters, error) {
+	f := TransactionFilters{
+		Search:        strings.TrimSpace(q.Get("q")),
+		Accounts:      splitSet(q.Get("accounts")),
+		Sources:       splitSet(q.Get("sources")),
+		MinAmount:     strings.TrimSpace(q.Get("minAmount")),
+		MaxAmount:     strings.TrimSpace(q.Get("maxAmount")),
+		Reimbursement: q.Get("reimbursement"),
+		Merchant:      strings.TrimSpace(q.Get("merchant")),
+	}
+
+	for _, bound := range []struct {
+		param string
+		value string
+		dst   **float64
+	}{
+		{"minAmount", f.MinAmount, &f.minAmount},
+		{"maxAmount", f.MaxAmount, &f.maxAmount},
+	} {
+		if bound.value == "" {
+			continue
+		}
+		amount, err := strconv.ParseFloat(bound.value, 64)
+		if err != nil {
+			return f, APIError{
+				Status:  http.StatusBadRequest,
+				Message: bound.param + " must be a number",
+			}
+		}
+		*bound.dst = &amount
+	}
+
+	if f.Reimbursement != "" {
+		isReimbursement, err := strconv.ParseBool(f.Reimbursement)
+		if err != nil {
+			return f, APIError{
+				Status:  http.StatusBadRequest,
+				Message: "reimbursement must be true or false",
+			}
+		}
+		f.isReimbursement = &isReimbursement
+	}
+
+	return f, nil
+}
+
+// query returns the model filters for f, without dates, categories or type.
+func (f TransactionFilters) query() model.QueryTransactionsFilters {
+	return model.QueryTransactionsFilters{
+		Search:          f.Search,
+		Accounts:        setKeys(f.Accounts),
+		Sources:         setKeys(f.Sources),
+		MinAmount:       f.minAmount,
+		MaxAmount:       f.maxAmount,
+		IsReimbursement: f.isReimbursement,
+		Merchant:        f.Merchant,
+	}
+}
+
+func splitSet(value string) map[string]bool {
+	set := map[string]bool{}
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			set[v] = true
+		}
+	}
+	return set
+}
+
+func setKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -4,20 +4,27 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"fin-web/internal/model"
+	"fin-web/internal/suggest"
 )
 
 type TransactionsPage struct {
 	Transactions           []model.Transaction
+	Pagination             Pagination
 	StartDate              string
 	EndDate                string
 	OrderBy                string
 	OrderDirection         string
+	Filters                TransactionFilters
 	Categories             []model.Category
+	Accounts               []string
+	Sources                []string
 	SelectedCategories     map[string]bool
 	ExpensesCategoryCounts []model.GroupByCounts
 	IncomeCategoryCounts   []model.GroupByCounts
@@ -46,8 +53,22 @@ func (c *Controller) transactions(w http.ResponseWriter, r *http.Request) error
 	orderDirection := q.Get("sortDirection")
 	categories := strings.Split(q.Get("categories"), ",")
 
+	filters, err := parseTransactionFilters(q)
+	if err != nil {
+		return err
+	}
+
+	pageReq, err := parsePageRequest(q)
+	if err != nil {
+		return err
+	}
+
 	if orderBy == "" {
 		orderBy = "amount"
+		// A search for words lists the best matches first.
+		if model.ParseSearch(filters.Search).Ranked() {
+			orderBy = "relevance"
+		}
 	}
 
 	if orderDirection == "" {
@@ -78,31 +99,53 @@ func (c *Controller) transactions(w http.ResponseWriter, r *http.Request) error
 		endDate = endOfThisMonth.Format("2006-01-02")
 	}
 
-	transactions, err := model.QueryTransactions(c.db, model.QueryTransactionsFilters{
-		OrderBy:        orderBy,
-		OrderDirection: orderDirection,
-		StartDate:      startDate,
-		EndDate:        endDate,
-		Categories:     categories,
-	})
+	// Everything below shares the filter bar. The income donut and chart
+	// ignore the category selection so income stays whole for comparison.
+	base := filters.query()
+	base.StartDate = startDate
+	base.EndDate = endDate
+
+	withCategories := func(t string) model.QueryTransactionsFilters {
+		f := base
+		f.Categories = categories
+		f.Type = t
+		return f
+	}
+
+	incomeFilters := base
+	incomeFilters.Type = "income"
+
+	listFilters := withCategories("")
+	listFilters.OrderBy = orderBy
+	listFilters.OrderDirection = orderDirection
+
+	page, err := model.QueryTransactionsPage(c.db, listFilters, pageReq)
+	if err != nil {
+		return pageError(err)
+	}
+
+	cs, err := model.GetCategories(c.db)
+	if err != nil {
+		fmt.Println("faile to get categories from DB: ", err.Error())
+	}
+
+	accounts, err := model.GetAccounts(c.db)
 	if err != nil {
 		return APIError{
 			Status:  http.StatusInternalServerError,
-			Message: "error fetching transactions: " + err.Error(),
+			Message: "error fetching accounts: " + err.Error(),
 		}
 	}
 
-	cs, err := model.GetCategories(c.db)
+	sources, err := model.GetSources(c.db)
 	if err != nil {
-		fmt.Println("faile to get categories from DB: ", err.Error())
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error fetching sources: " + err.Error(),
+		}
 	}
 
-	eTotal, err := model.SumTransactions(c.db, model.QueryTransactionsFilters{
-		StartDate:  startDate,
-		EndDate:    endDate,
-		Categories: categories,
-		Type:       "expenses",
-	})
+	eTotal, err := model.SumTransactions(c.db, withCategories("expenses"))
 	if err != nil {
 		return APIError{
 			Status:  http.StatusInternalServerError,
@@ -110,12 +153,7 @@ func (c *Controller) transactions(w http.ResponseWriter, r *http.Request) error
 		}
 	}
 
-	iTotal, err := model.SumTransactions(c.db, model.QueryTransactionsFilters{
-		StartDate:  startDate,
-		EndDate:    endDate,
-		Categories: categories,
-		Type:       "income",
-	})
+	iTotal, err := model.SumTransactions(c.db, withCategories("income"))
 	if err != nil {
 		return APIError{
 			Status:  http.StatusInternalServerError,
@@ -124,12 +162,7 @@ func (c *Controller) transactions(w http.ResponseWriter, r *http.Request) error
 	}
 	iTotal = math.Abs(iTotal)
 
-	fixedCosts, err := model.SumTransactions(c.db, model.QueryTransactionsFilters{
-		StartDate:  startDate,
-		EndDate:    endDate,
-		Categories: categories,
-		Type:       "fixed",
-	})
+	fixedCosts, err := model.SumTransactions(c.db, withCategories("fixed"))
 	if err != nil {
 		return APIError{
 			Status:  http.StatusInternalServerError,
@@ -137,12 +170,7 @@ func (c *Controller) transactions(w http.ResponseWriter, r *http.Request) error
 		}
 	}
 
-	guiltFree, err := model.SumTransactions(c.db, model.QueryTransactionsFilters{
-		StartDate:  startDate,
-		EndDate:    endDate,
-		Categories: categories,
-		Type:       "fun",
-	})
+	guiltFree, err := model.SumTransactions(c.db, withCategories("fun"))
 	if err != nil {
 		return APIError{
 			Status:  http.StatusInternalServerError,
@@ -150,14 +178,10 @@ func (c *Controller) transactions(w http.ResponseWriter, r *http.Request) error
 		}
 	}
 
-	expensesCategoryCounts, err := model.CategoryCounts(c.db, model.QueryTransactionsFilters{
-		OrderBy:        orderBy,
-		OrderDirection: orderDirection,
-		StartDate:      startDate,
-		EndDate:        endDate,
-		Categories:     categories,
-		Type:           "expenses",
-	})
+	expenseCountFilters := withCategories("expenses")
+	expenseCountFilters.OrderBy = orderBy
+	expenseCountFilters.OrderDirection = orderDirection
+	expensesCategoryCounts, err := model.CategoryCounts(c.db, expenseCountFilters)
 	if err != nil {
 		return APIError{
 			Status:  http.StatusInternalServerError,
@@ -165,13 +189,10 @@ func (c *Controller) transactions(w http.ResponseWriter, r *http.Request) error
 		}
 	}
 
-	incomeCategoryCounts, err := model.CategoryCounts(c.db, model.QueryTransactionsFilters{
-		OrderBy:        orderBy,
-		OrderDirection: orderDirection,
-		StartDate:      startDate,
-		EndDate:        endDate,
-		Type:           "income",
-	})
+	incomeCountFilters := incomeFilters
+	incomeCountFilters.OrderBy = orderBy
+	incomeCountFilters.OrderDirection = orderDirection
+	incomeCategoryCounts, err := model.CategoryCounts(c.db, incomeCountFilters)
 	if err != nil {
 		return APIError{
 			Status:  http.StatusInternalServerError,
@@ -186,12 +207,9 @@ func (c *Controller) transactions(w http.ResponseWriter, r *http.Request) error
 
 	startOfMonthOneYearAgo, _ := getStartAndEndOfMonth(date.AddDate(0, -11, 0))
 
-	expenseCountsByMonth, err := model.CountsByDate(c.db, model.QueryTransactionsFilters{
-		StartDate:  startOfMonthOneYearAgo.Format("2006-01-02"),
-		EndDate:    endDate,
-		Categories: categories,
-		Type:       "expenses",
-	}, "%m-%Y")
+	expenseMonthFilters := withCategories("expenses")
+	expenseMonthFilters.StartDate = startOfMonthOneYearAgo.Format("2006-01-02")
+	expenseCountsByMonth, err := model.CountsByDate(c.db, expenseMonthFilters, "%m-%Y")
 	if err != nil {
 		return APIError{
 			Status:  http.StatusInternalServerError,
@@ -199,11 +217,9 @@ func (c *Controller) transactions(w http.ResponseWriter, r *http.Request) error
 		}
 	}
 
-	incomeCountsByMonth, err := model.CountsByDate(c.db, model.QueryTransactionsFilters{
-		StartDate: startOfMonthOneYearAgo.Format("2006-01-02"),
-		EndDate:   endDate,
-		Type:      "income",
-	}, "%m-%Y")
+	incomeMonthFilters := incomeFilters
+	incomeMonthFilters.StartDate = startOfMonthOneYearAgo.Format("2006-01-02")
+	incomeCountsByMonth, err := model.CountsByDate(c.db, incomeMonthFilters, "%m-%Y")
 	if err != nil {
 		return APIError{
 			Status:  http.StatusInternalServerError,
@@ -221,12 +237,16 @@ func (c *Controller) transactions(w http.ResponseWriter, r *http.Request) error
 
 	err = renderTemplate(w, Base[TransactionsPage]{
 		Data: TransactionsPage{
-			Transactions:           transactions,
+			Transactions:           page.Transactions,
+			Pagination:             newPagination(r.URL, page),
 			StartDate:              startDate,
 			EndDate:                endDate,
+			Filters:                filters,
 			OrderBy:                orderBy,
 			OrderDirection:         orderDirection,
 			Categories:             cs,
+			Accounts:               accounts,
+			Sources:                sources,
 			SelectedCategories:     selectedCatMap,
 			ExpensesCategoryCounts: expensesCategoryCounts,
 			IncomeCategoryCounts:   incomeCategoryCounts,
@@ -242,7 +262,7 @@ func (c *Controller) transactions(w http.ResponseWriter, r *http.Request) error
 			GuiltFree:              guiltFree,
 			GuiltFreePercent:       int(math.Round((guiltFree / iTotal) * 100)),
 		},
-	}, "layout", []string{"transactions/transactions.html", "layout.html"})
+	}, "layout", []string{"transactions/transactions.html", "transactions/pagination.html", "layout.html"})
 	if err != nil {
 		return APIError{
 			Status:  http.StatusInternalServerError,
@@ -255,28 +275,54 @@ func (c *Controller) transactions(w http.ResponseWriter, r *http.Request) error
 
 type UncategorizedTransactionsPage struct {
 	Transactions []model.Transaction
+	Pagination   Pagination
+	Categories   []model.Category
+	// Suggestions is keyed by transaction ID.
+	Suggestions map[string]suggest.Suggestion
 }
 
 func (c *Controller) uncategorizedTransactions(w http.ResponseWriter, r *http.Request) error {
+	pageReq, err := parsePageRequest(r.URL.Query())
+	if err != nil {
+		return err
+	}
+
 	emptyCustomCategory := true
-	transactions, err := model.QueryTransactions(
+	page, err := model.QueryTransactionsPage(
 		c.db,
 		model.QueryTransactionsFilters{
 			EmptyCustomCategory: &emptyCustomCategory,
 		},
+		pageReq,
 	)
+	if err != nil {
+		return pageError(err)
+	}
+
+	categories, err := model.GetCategories(c.db)
 	if err != nil {
 		return APIError{
 			Status:  http.StatusInternalServerError,
-			Message: "error fetching transactions: " + err.Error(),
+			Message: "error fetching categories: " + err.Error(),
+		}
+	}
+
+	suggestions, err := suggest.Load(c.db)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error loading category suggestions: " + err.Error(),
 		}
 	}
 
 	err = renderTemplate(w, Base[UncategorizedTransactionsPage]{
 		Data: UncategorizedTransactionsPage{
-			Transactions: transactions,
+			Transactions: page.Transactions,
+			Pagination:   newPagination(r.URL, page),
+			Categories:   categories,
+			Suggestions:  suggestions.ForTransactions(page.Transactions),
 		},
-	}, "layout", []string{"transactions/uncategorized-transactions.html", "layout.html"})
+	}, "layout", []string{"transactions/uncategorized-transactions.html", "transactions/pagination.html", "layout.html"})
 	if err != nil {
 		return APIError{
 			Status:  http.StatusInternalServerError,
@@ -288,12 +334,42 @@ func (c *Controller) uncategorizedTransactions(w http.ResponseWriter, r *http.Re
 }
 
 type TransactionPage struct {
-	Transaction model.Transaction
-	Categories  []model.Category
-	Success     bool
+	Transaction       model.Transaction
+	Categories        []model.Category
+	Suggestion        *suggest.Suggestion
+	Splits            []SplitFormRow
+	SplitErrs         map[string]string
+	Reimbursements    []model.Reimbursement
+	Reimbursable      []model.OutstandingExpense
+	ReimbursementForm ReimbursementFormData
+	ReimbursementErrs map[string]string
+	Success           bool
+}
+
+// Reimbursed totals the linked reimbursements, from whichever side.
+func (p TransactionPage) Reimbursed() float64 {
+	total := 0.0
+	for _, r := range p.Reimbursements {
+		total += r.Amount
+	}
+	return total
+}
+
+// transactionForms carries forms submitted from the transaction page back to
+// it when they fail validation. Zero values show the saved state.
+type transactionForms struct {
+	Splits            []SplitFormRow
+	SplitErrs         map[string]string
+	Reimbursement     ReimbursementFormData
+	ReimbursementErrs map[string]string
 }
 
 func (c *Controller) transaction(w http.ResponseWriter, r *http.Request) error {
+	return c.renderTransaction(w, r, transactionForms{})
+}
+
+// renderTransaction renders the transaction page with any submitted forms.
+func (c *Controller) renderTransaction(w http.ResponseWriter, r *http.Request, forms transactionForms) error {
 	id := r.PathValue("id")
 	transaction, err := model.GetTransaction(
 		c.db,
@@ -318,11 +394,66 @@ func (c *Controller) transaction(w http.ResponseWriter, r *http.Request) error {
 
 	success := responseCookie != nil && responseCookie.Value == "success"
 
+	var suggestion *suggest.Suggestion
+	if !transaction.CategoryID.Valid {
+		idx, err := suggest.Load(c.db)
+		if err != nil {
+			return APIError{
+				Status:  http.StatusInternalServerError,
+				Message: "error loading category suggestions: " + err.Error(),
+			}
+		}
+
+		if s, ok := idx.Suggest(transaction.Name); ok {
+			suggestion = &s
+		}
+	}
+
+	splits := forms.Splits
+	if splits == nil {
+		saved, err := model.GetSplits(c.db, id)
+		if err != nil {
+			return APIError{
+				Status:  http.StatusInternalServerError,
+				Message: "error fetching splits: " + err.Error(),
+			}
+		}
+
+		splits = splitFormRows(saved)
+	}
+
+	reimbursements, err := model.GetReimbursements(c.db, id)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error fetching reimbursements: " + err.Error(),
+		}
+	}
+
+	// Only money coming in can pay back an expense.
+	var reimbursable []model.OutstandingExpense
+	if transaction.Amount < 0 {
+		reimbursable, err = model.ReimbursableExpenses(c.db, transaction.Date, maxReimbursableExpenses)
+		if err != nil {
+			return APIError{
+				Status:  http.StatusInternalServerError,
+				Message: "error fetching expenses: " + err.Error(),
+			}
+		}
+	}
+
 	err = renderTemplate(w, Base[TransactionPage]{
 		Data: TransactionPage{
-			Transaction: transaction,
-			Categories:  cs,
-			Success:     success,
+			Transaction:       transaction,
+			Categories:        cs,
+			Suggestion:        suggestion,
+			Splits:            splits,
+			SplitErrs:         forms.SplitErrs,
+			Reimbursements:    reimbursements,
+			Reimbursable:      reimbursable,
+			ReimbursementForm: forms.Reimbursement,
+			ReimbursementErrs: forms.ReimbursementErrs,
+			Success:           success,
 		},
 	}, "layout", []string{"transactions/transaction.html", "layout.html"})
 	if err != nil {
@@ -341,6 +472,7 @@ func (c *Controller) updateTransaction(w http.ResponseWriter, r *http.Request) e
 	description := r.FormValue("description")
 	category := r.FormValue("category")
 	isReimbursementStr := r.FormValue("is_reimbursement")
+	expectsReimbursement := r.FormValue("expects_reimbursement") == "on"
 
 	var categoryID *int
 	if category != "" {
@@ -361,9 +493,10 @@ func (c *Controller) updateTransaction(w http.ResponseWriter, r *http.Request) e
 	}
 
 	err := model.UpdateTransaction(c.db, id, model.UpdateTransactionParams{
-		Description:     &description,
-		CategoryID:      categoryID,
-		IsReimbursement: &isReimbursement,
+		Description:          &description,
+		CategoryID:           categoryID,
+		IsReimbursement:      &isReimbursement,
+		ExpectsReimbursement: &expectsReimbursement,
 	})
 	if err != nil {
 		return APIError{
@@ -386,6 +519,70 @@ func (c *Controller) updateTransaction(w http.ResponseWriter, r *http.Request) e
 	return nil
 }
 
+// categoryRedirectPaths are the pages that post to setTransactionCategory and
+// may be returned to.
+var categoryRedirectPaths = []string{"/transactions/uncategorized"}
+
+// categoryRedirect returns value when it is one of categoryRedirectPaths,
+// with or without a query string, and fallback otherwise, so the form can't
+// send anyone off-site. Backslashes are refused outright since browsers read
+// them as slashes.
+func categoryRedirect(value string, fallback string) string {
+	if strings.Contains(value, "\\") {
+		return fallback
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.User != nil || u.Opaque != "" {
+		return fallback
+	}
+
+	if !slices.Contains(categoryRedirectPaths, u.Path) {
+		return fallback
+	}
+
+	return value
+}
+
+// setTransactionCategory sets only the category, as accepting a suggestion
+// does, and returns to the page given in redirect.
+func (c *Controller) setTransactionCategory(w http.ResponseWriter, r *http.Request) error {
+	id := r.PathValue("id")
+
+	categoryID, err := strconv.Atoi(r.FormValue("category"))
+	if err != nil {
+		return APIError{
+			Status:  http.StatusBadRequest,
+			Message: "category must be an int",
+		}
+	}
+
+	redirect := categoryRedirect(r.FormValue("redirect"), "/transactions/"+id)
+
+	err = model.UpdateTransaction(c.db, id, model.UpdateTransactionParams{
+		CategoryID: &categoryID,
+	})
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error updating transaction: " + err.Error(),
+		}
+	}
+
+	cookie := &http.Cookie{
+		Name:     "response",
+		Value:    "success",
+		Path:     "/",
+		HttpOnly: true,
+		Expires:  time.Now().Add(1 * time.Second),
+	}
+
+	http.SetCookie(w, cookie)
+
+	http.Redirect(w, r, redirect, http.StatusSeeOther)
+	return nil
+}
+
 func (c *Controller) deleteTransaction(w http.ResponseWriter, r *http.Request) error {
 	id := r.PathValue("id")
 
@@ -2,9 +2,11 @@ package controller
 
 import (
 	"database/sql"
+	"html"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"regexp"
 	"strconv"
 	"testing"
 
@@ -77,6 +79,13 @@ func TestUpdateTransactionSuccess(t *testing.T) {
 	assert.Equal(t, "weekly shop", got.Description.String)
 	assert.True(t, got.IsReimbursement)
 	assert.Equal(t, int32(id), got.CategoryID.Int32)
+	assert.Equal(t, model.CategorySourceManual, got.CategorySource.String)
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/transactions/tx-1", nil)
+	req.SetPathValue("id", "tx-1")
+	require.NoError(t, c.transaction(rec, req))
+	assert.Contains(t, rec.Body.String(), "Categorized by hand")
 }
 
 func TestUpdateTransactionNonIntCategory(t *testing.T) {
@@ -168,6 +177,98 @@ func TestTransactionsHomeRenders(t *testing.T) {
 	assert.Contains(t, rec.Body.String(), "PAYCHECK")
 }
 
+func TestTransactionsHomeSearch(t *testing.T) {
+	db := testutil.NewDB(t)
+	income := mustCreateCategory(t, db, "Salary", 1, "income")
+	rent := mustCreateCategory(t, db, "Rent", 2, "fixed")
+	seedTransaction(t, db, "tx-income", "PAYCHECK", -5000, "2026-02-01", catID(income))
+	seedTransaction(t, db, "tx-rent", "RENT", 2000, "2026-02-05", catID(rent))
+	c := &Controller{db: db}
+
+	req := httptest.NewRequest(http.MethodGet, "/?startDate=2026-02-01&endDate=2026-02-28&q=paycheck", nil)
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.transactions(rec, req))
+
+	body := rec.Body.String()
+	assert.Contains(t, body, `href="/transactions/tx-income"`)
+	assert.NotContains(t, body, `href="/transactions/tx-rent"`)
+	assert.Contains(t, body, `value="paycheck"`)
+	assert.Regexp(t, `value="relevance"\s+selected`, body, "searches for words sort by relevance")
+}
+
+func TestTransactionsHomeFilters(t *testing.T) {
+	db := testutil.NewDB(t)
+	income := mustCreateCategory(t, db, "Salary", 1, "income")
+	dining := mustCreateCategory(t, db, "Dining", 2, "fun")
+	seedTransaction(t, db, "tx-income", "PAYCHECK", -5000, "2026-03-01", catID(income))
+	seedTransaction(t, db, "tx-dinner", "SQ *NOPA", 120, "2026-03-05", catID(dining))
+	seedTransaction(t, db, "tx-coffee", "SQ *BLUE BOTTLE", 6, "2026-03-06", catID(dining))
+	require.NoError(t, model.CreateTransaction(db, model.Transaction{
+		ID: "tx-bofa", Name: "SQ *NOPA", Amount: 80, Date: "2026-03-07", Source: "bofa", Account: "bofa", CategoryID: catID(dining),
+	}))
+	c := &Controller{db: db}
+
+	req := httptest.NewRequest(http.MethodGet, "/?startDate=2026-03-01&endDate=2026-03-31&accounts=citi&minAmount=10&maxAmount=500&merchant=nopa", nil)
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.transactions(rec, req))
+
+	body := rec.Body.String()
+	assert.Contains(t, body, `href="/transactions/tx-dinner"`)
+	assert.NotContains(t, body, `href="/transactions/tx-coffee"`, "under minAmount")
+	assert.NotContains(t, body, `href="/transactions/tx-bofa"`, "other account")
+	assert.NotContains(t, body, `href="/transactions/tx-income"`, "not the merchant")
+	assert.Contains(t, body, `value="nopa"`)
+	assert.Contains(t, body, `<strong>Expenses: </strong><span class="currency">120</span>`, "totals follow the filters")
+
+	for _, bad := range []string{"minAmount=lots", "maxAmount=x", "reimbursement=maybe"} {
+		err := c.transactions(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/?endDate=2026-03-31&"+bad, nil))
+		var apiErr APIError
+		require.ErrorAs(t, err, &apiErr, bad)
+		assert.Equal(t, http.StatusBadRequest, apiErr.Status, bad)
+	}
+}
+
+func TestUncategorizedTransactionsPaginate(t *testing.T) {
+	db := testutil.NewDB(t)
+	seedTransaction(t, db, "tx-1", "FIRST", 10, "2026-03-01", sql.NullInt32{})
+	seedTransaction(t, db, "tx-2", "SECOND", 20, "2026-03-02", sql.NullInt32{})
+	seedTransaction(t, db, "tx-3", "THIRD", 30, "2026-03-03", sql.NullInt32{})
+	c := &Controller{db: db}
+
+	get := func(target string) string {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		require.NoError(t, c.uncategorizedTransactions(rec, httptest.NewRequest(http.MethodGet, target, nil)))
+		return rec.Body.String()
+	}
+	nextURL := regexp.MustCompile(`href="([^"]*after=[^"]*)">Next`)
+
+	body := get("/transactions/uncategorized?pageSize=2")
+	assert.Contains(t, body, "2 of 3")
+	assert.Contains(t, body, `href="/transactions/tx-3"`, "newest first")
+	assert.Contains(t, body, `href="/transactions/tx-2"`)
+	assert.NotContains(t, body, `href="/transactions/tx-1"`)
+	assert.NotContains(t, body, ">Previous<")
+
+	m := nextURL.FindStringSubmatch(body)
+	require.NotNil(t, m)
+	next := html.UnescapeString(m[1])
+	assert.Contains(t, next, "pageSize=2")
+
+	body = get(next)
+	assert.Contains(t, body, "1 of 3")
+	assert.Contains(t, body, `href="/transactions/tx-1"`)
+	assert.Contains(t, body, ">Previous<")
+	assert.NotRegexp(t, nextURL, body)
+
+	for _, bad := range []string{"pageSize=0", "after=junk"} {
+		err := c.uncategorizedTransactions(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/transactions/uncategorized?"+bad, nil))
+		var apiErr APIError
+		require.ErrorAs(t, err, &apiErr, bad)
+		assert.Equal(t, http.StatusBadRequest, apiErr.Status, bad)
+	}
+}
+
 func TestTransactionsHomeNonRootPathRendersNotFound(t *testing.T) {
 	c := &Controller{db: testutil.NewDB(t)}
 
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"fin-web/internal/model"
+	"fin-web/internal/transfers"
+)
+
+// maxTransferWindowDays bounds the detection window; wider windows mostly
+// pair up unrelated charges that happen to share an amount.
+const maxTransferWindowDays = 31
+
+type TransfersPage struct {
+	Transfers []model.Transfer
+	Window    string
+	Errs      map[string]string
+	Success   bool
+}
+
+func (c *Controller) renderTransfers(w http.ResponseWriter, r *http.Request, window string, errs map[string]string) error {
+	ts, err := model.GetTransfers(c.db)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error fetching transfers: " + err.Error(),
+		}
+	}
+
+	responseCookie, err := r.Cookie("response")
+	if err != nil && err != http.ErrNoCookie {
+		fmt.Println("error getting cookie: " + err.Error())
+	}
+
+	success := responseCookie != nil && responseCookie.Value == "success"
+
+	err = renderTemplate(w, Base[TransfersPage]{
+		Data: TransfersPage{
+			Transfers: ts,
+			Window:    window,
+			Errs:      errs,
+			Success:   success,
+		},
+	}, "layout", []string{"transfers.html", "layout.html"})
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+func (c *Controller) transfers(w http.ResponseWriter, r *http.Request) error {
+	return c.renderTransfers(w, r, strconv.Itoa(transfers.DefaultWindowDays), nil)
+}
+
+func (c *Controller) detectTransfers(w http.ResponseWriter, r *http.Request) error {
+	window := strings.TrimSpace(r.FormValue("window"))
+
+	days, err := strconv.Atoi(window)
+	if err != nil || days < 0 || days > maxTransferWindowDays {
+		return c.renderTransfers(w, r, window, map[string]string{
+			"window": fmt.Sprintf("window must be a whole number of days from 0 to %d", maxTransferWindowDays),
+		})
+	}
+
+	_, err = transfers.Detect(c.db, days)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error detecting transfers: " + err.Error(),
+		}
+	}
+
+	cookie := &http.Cookie{
+		Name:     "response",
+		Value:    "success",
+		Path:     "/",
+		HttpOnly: true,
+		Expires:  time.Now().Add(1 * time.Second),
+	}
+
+	http.SetCookie(w, cookie)
+
+	http.Redirect(w, r, "/transfers", http.StatusSeeOther)
+	return nil
+}
+
+func (c *Controller) confirmTransfer(w http.ResponseWriter, r *http.Request) error {
+	return c.setTransferStatus(w, r, model.TransferConfirmed)
+}
+
+func (c *Controller) unlinkTransfer(w http.ResponseWriter, r *http.Request) error {
+	return c.setTransferStatus(w, r, model.TransferUnlinked)
+}
+
+func (c *Controller) setTransferStatus(w http.ResponseWriter, r *http.Request, status string) error {
+	id := r.PathValue("id")
+
+	err := model.SetTransferStatus(c.db, id, status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return APIError{
+				Status:  http.StatusNotFound,
+				Message: "transfer not found",
+			}
+		}
+
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error updating transfer: " + err.Error(),
+		}
+	}
+
+	http.Redirect(w, r, "/transfers", http.StatusSeeOther)
+	return nil
+}
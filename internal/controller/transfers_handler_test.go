@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"fin-web/internal/model"
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seedTransfer(t *testing.T, db *sql.DB) {
+	t.Helper()
+	require.NoError(t, model.CreateTransaction(db, model.Transaction{ID: "out", Name: "CITI AUTOPAY", Account: "bofa", Source: "bofa", Amount: 420, Date: "2026-03-01"}))
+	require.NoError(t, model.CreateTransaction(db, model.Transaction{ID: "in", Name: "PAYMENT THANK YOU", Account: "citi", Source: "citi", Amount: -420, Date: "2026-03-02"}))
+}
+
+func TestDetectAndReviewTransfers(t *testing.T) {
+	db := testutil.NewDB(t)
+	seedTransfer(t, db)
+	c := &Controller{db: db}
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.detectTransfers(rec, newFormRequest("/transfers/detect", url.Values{"window": {"2"}})))
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+
+	rec = httptest.NewRecorder()
+	require.NoError(t, c.transfers(rec, httptest.NewRequest(http.MethodGet, "/transfers", nil)))
+	body := rec.Body.String()
+	assert.Contains(t, body, "CITI AUTOPAY")
+	assert.Contains(t, body, "PAYMENT THANK YOU")
+	assert.Contains(t, body, `action="/transfers/1/confirm"`)
+
+	req := newFormRequest("/transfers/1/confirm", url.Values{})
+	req.SetPathValue("id", "1")
+	rec = httptest.NewRecorder()
+	require.NoError(t, c.confirmTransfer(rec, req))
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+
+	ts, err := model.GetTransfers(db)
+	require.NoError(t, err)
+	require.Len(t, ts, 1)
+	assert.Equal(t, model.TransferConfirmed, ts[0].Status)
+
+	req = newFormRequest("/transfers/1/unlink", url.Values{})
+	req.SetPathValue("id", "1")
+	rec = httptest.NewRecorder()
+	require.NoError(t, c.unlinkTransfer(rec, req))
+
+	ts, err = model.GetTransfers(db)
+	require.NoError(t, err)
+	assert.Empty(t, ts)
+
+	// Unlinking twice is a missing transfer.
+	req = newFormRequest("/transfers/1/unlink", url.Values{})
+	req.SetPathValue("id", "1")
+	err = c.unlinkTransfer(httptest.NewRecorder(), req)
+	var apiErr APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.Status)
+}
+
+func TestDetectTransfersValidatesWindow(t *testing.T) {
+	db := testutil.NewDB(t)
+	c := &Controller{db: db}
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.detectTransfers(rec, newFormRequest("/transfers/detect", url.Values{"window": {"-1"}})))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "window must be a whole number of days from 0 to 31")
+}
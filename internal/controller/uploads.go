@@ -0,0 +1,338 @@
+package controller
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"fin-web/internal/csvprofile"
+	"fin-web/internal/worker"
+
+	"github.com/google/uuid"
+)
+
+// maxUploadSize caps the multipart body held in memory; larger parts spill to
+// temporary files.
+const maxUploadSize = 32 << 20
+
+// stagingTTL is how long an upload may wait to be confirmed or canceled
+// before its staged files are swept.
+const stagingTTL = 24 * time.Hour
+
+type UploadFile struct {
+	Preview worker.FilePreview
+	Err     string
+}
+
+type UploadPage struct {
+	Token        string
+	Files        []UploadFile
+	Unrecognized []string
+}
+
+// Importable reports whether any staged file parsed cleanly.
+func (p UploadPage) Importable() bool {
+	for _, f := range p.Files {
+		if f.Err == "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Controller) newUpload(w http.ResponseWriter, r *http.Request) error {
+	err := renderTemplate(w, Base[UploadPage]{}, "layout", []string{"imports/upload.html", "layout.html"})
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+// upload stages the submitted statement files under a fresh token and renders
+// a preview of what importing them would do. Nothing is written to the
+// database until the preview is confirmed.
+func (c *Controller) upload(w http.ResponseWriter, r *http.Request) error {
+	err := r.ParseMultipartForm(maxUploadSize)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusBadRequest,
+			Message: "error parsing upload: " + err.Error(),
+		}
+	}
+
+	fileHeaders := r.MultipartForm.File["files"]
+	if len(fileHeaders) == 0 {
+		return APIError{
+			Status:  http.StatusBadRequest,
+			Message: "no files uploaded",
+		}
+	}
+
+	names, err := stagingNames(fileHeaders)
+	if err != nil {
+		return err
+	}
+
+	c.sweepStaging(time.Now())
+
+	token := uuid.NewString()
+	stagingDir := filepath.Join(c.uploadDir, token)
+	err = os.MkdirAll(stagingDir, 0o700)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error creating upload directory: " + err.Error(),
+		}
+	}
+
+	providers, err := c.importProviders()
+	if err != nil {
+		os.RemoveAll(stagingDir)
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error loading csv profiles: " + err.Error(),
+		}
+	}
+
+	bw := worker.NewBaseWorker(c.db, stagingDir)
+	page := UploadPage{Token: token}
+	for i, fh := range fileHeaders {
+		name := names[i]
+		filePath := filepath.Join(stagingDir, name)
+
+		err := saveUpload(fh, filePath)
+		if err != nil {
+			os.RemoveAll(stagingDir)
+			return APIError{
+				Status:  http.StatusInternalServerError,
+				Message: "error saving upload: " + err.Error(),
+			}
+		}
+
+		p, err := worker.DetectProvider(providers, filePath)
+		if err != nil {
+			os.RemoveAll(stagingDir)
+			return APIError{
+				Status:  http.StatusInternalServerError,
+				Message: "error reading upload: " + err.Error(),
+			}
+		}
+		if p == nil {
+			os.Remove(filePath)
+			page.Unrecognized = append(page.Unrecognized, name)
+			continue
+		}
+
+		preview, err := bw.PreviewFile(p, filePath, name)
+		if err != nil {
+			// A file that can't be parsed would only fail again on confirm.
+			os.Remove(filePath)
+			page.Files = append(page.Files, UploadFile{Preview: preview, Err: err.Error()})
+			continue
+		}
+
+		page.Files = append(page.Files, UploadFile{Preview: preview})
+	}
+
+	if !page.Importable() {
+		os.RemoveAll(stagingDir)
+	}
+
+	err = renderTemplate(w, Base[UploadPage]{
+		Data: page,
+	}, "layout", []string{"imports/preview.html", "layout.html"})
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+func (c *Controller) confirmUpload(w http.ResponseWriter, r *http.Request) error {
+	stagingDir, err := c.stagingDir(r.PathValue("token"))
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(stagingDir)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusNotFound,
+			Message: "upload not found: " + err.Error(),
+		}
+	}
+	defer os.RemoveAll(stagingDir)
+
+	providers, err := c.importProviders()
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error loading csv profiles: " + err.Error(),
+		}
+	}
+
+	bw := worker.NewBaseWorker(c.db, stagingDir)
+	batchIDs := []int{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		filePath := filepath.Join(stagingDir, entry.Name())
+		p, err := worker.DetectProvider(providers, filePath)
+		if err != nil || p == nil {
+			continue
+		}
+
+		// Failures are recorded as failed batches and shown on /imports.
+		result := bw.ImportFile(p, filePath, entry.Name())
+		if result.Err == nil {
+			batchIDs = append(batchIDs, result.BatchID)
+		}
+	}
+
+	// New rows can trip any alert; don't wait for the feed's next check.
+	if err := c.recordAlerts(time.Now(), batchIDs); err != nil {
+		log.Printf("error checking alerts: %v", err)
+	}
+
+	http.Redirect(w, r, "/imports", http.StatusSeeOther)
+	return nil
+}
+
+func (c *Controller) cancelUpload(w http.ResponseWriter, r *http.Request) error {
+	stagingDir, err := c.stagingDir(r.PathValue("token"))
+	if err != nil {
+		return err
+	}
+
+	err = os.RemoveAll(stagingDir)
+	if err != nil {
+		return APIError{
+			Status:  http.StatusInternalServerError,
+			Message: "error discarding upload: " + err.Error(),
+		}
+	}
+
+	http.Redirect(w, r, "/imports/upload", http.StatusSeeOther)
+	return nil
+}
+
+// importProviders returns the built-in providers plus one per saved CSV
+// profile. Profiles are loaded per request so new ones apply immediately.
+func (c *Controller) importProviders() ([]worker.Provider, error) {
+	profiles, err := csvprofile.LoadProviders(c.db)
+	if err != nil {
+		return nil, err
+	}
+
+	providers := append([]worker.Provider{}, c.providers...)
+	for _, p := range profiles {
+		providers = append(providers, p)
+	}
+
+	return providers, nil
+}
+
+// stagingDir resolves an upload token to its directory. Tokens are UUIDs, which
+// keeps a crafted token from escaping uploadDir.
+func (c *Controller) stagingDir(token string) (string, error) {
+	if _, err := uuid.Parse(token); err != nil {
+		return "", APIError{
+			Status:  http.StatusBadRequest,
+			Message: "invalid upload token",
+		}
+	}
+
+	return filepath.Join(c.uploadDir, token), nil
+}
+
+// stagingNames returns the name each uploaded file is staged and imported
+// under: its last path element, with a " (2)" style suffix on repeats so no
+// file overwrites another. Names that aren't a file name at all, such as
+// "..", are rejected.
+func stagingNames(fileHeaders []*multipart.FileHeader) ([]string, error) {
+	names := make([]string, len(fileHeaders))
+	taken := map[string]bool{}
+	for i, fh := range fileHeaders {
+		name := filepath.Base(fh.Filename)
+		if name == "." || name == ".." || name == string(filepath.Separator) {
+			return nil, APIError{
+				Status:  http.StatusBadRequest,
+				Message: "invalid file name " + strconv.Quote(fh.Filename),
+			}
+		}
+
+		ext := filepath.Ext(name)
+		stem := strings.TrimSuffix(name, ext)
+		for n := 2; taken[name]; n++ {
+			name = fmt.Sprintf("%s (%d)%s", stem, n, ext)
+		}
+
+		taken[name] = true
+		names[i] = name
+	}
+
+	return names, nil
+}
+
+// sweepStaging removes staging directories left by uploads that were never
+// confirmed or canceled.
+func (c *Controller) sweepStaging(now time.Time) {
+	entries, err := os.ReadDir(c.uploadDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("error reading upload directory: %v", err)
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		if _, err := uuid.Parse(entry.Name()); err != nil || !entry.IsDir() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil || now.Sub(info.ModTime()) < stagingTTL {
+			continue
+		}
+
+		if err := os.RemoveAll(filepath.Join(c.uploadDir, entry.Name())); err != nil {
+			log.Printf("error removing stale upload: %v", err)
+		}
+	}
+}
+
+func saveUpload(fh *multipart.FileHeader, filePath string) error {
+	src, err := fh.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+
+	return dst.Close()
+}
@@ -0,0 +1,219 @@
+package controller
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"fin-web/internal/bofa"
+	"fin-web/internal/model"
+	"fin-web/internal/testutil"
+	"fin-web/internal/worker"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const bofaStatement = `Date,Reference Number,Payee,Address,Amount
+02/04/2026,REF001,STARBUCKS STORE 123,SEATTLE WA,-5.75
+02/05/2026,REF002,PAYROLL DEPOSIT,,2500.00
+`
+
+// newUploadRequest builds a multipart POST with each name/content pair as a
+// "files" part.
+func newUploadRequest(t *testing.T, files map[string]string) *http.Request {
+	t.Helper()
+	parts := [][2]string{}
+	for name, content := range files {
+		parts = append(parts, [2]string{name, content})
+	}
+	return newUploadPartsRequest(t, parts)
+}
+
+// newUploadPartsRequest is newUploadRequest for parts in order, which may
+// repeat a name.
+func newUploadPartsRequest(t *testing.T, parts [][2]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, p := range parts {
+		name, content := p[0], p[1]
+		part, err := mw.CreateFormFile("files", name)
+		require.NoError(t, err)
+		_, err = part.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, mw.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/imports/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func newUploadController(t *testing.T) *Controller {
+	t.Helper()
+	db := testutil.NewDB(t)
+	return &Controller{
+		db:        db,
+		providers: []worker.Provider{bofa.NewBofaProvider(db)},
+		uploadDir: t.TempDir(),
+	}
+}
+
+var tokenPattern = regexp.MustCompile(`/imports/upload/([0-9a-f-]{36})/confirm`)
+
+func TestUploadPreviewsWithoutCommitting(t *testing.T) {
+	c := newUploadController(t)
+
+	rec := httptest.NewRecorder()
+	req := newUploadRequest(t, map[string]string{
+		"february.csv": bofaStatement,
+		"notes.txt":    "hello",
+	})
+	require.NoError(t, c.upload(rec, req))
+
+	body := rec.Body.String()
+	assert.Contains(t, body, "STARBUCKS STORE 123")
+	assert.Contains(t, body, "PAYROLL DEPOSIT")
+	assert.Contains(t, body, "notes.txt: no provider recognises this file")
+	assert.Regexp(t, tokenPattern, body)
+
+	txns, err := model.QueryTransactions(c.db, model.QueryTransactionsFilters{})
+	require.NoError(t, err)
+	assert.Empty(t, txns)
+
+	batches, err := model.GetImportBatches(c.db)
+	require.NoError(t, err)
+	assert.Empty(t, batches)
+}
+
+func TestConfirmUploadImportsStagedFiles(t *testing.T) {
+	c := newUploadController(t)
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.upload(rec, newUploadRequest(t, map[string]string{"february.csv": bofaStatement})))
+	token := tokenPattern.FindStringSubmatch(rec.Body.String())[1]
+
+	req := httptest.NewRequest(http.MethodPost, "/imports/upload/"+token+"/confirm", nil)
+	req.SetPathValue("token", token)
+	rec = httptest.NewRecorder()
+	require.NoError(t, c.confirmUpload(rec, req))
+
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Equal(t, "/imports", rec.Header().Get("Location"))
+
+	batches, err := model.GetImportBatches(c.db)
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+	assert.Equal(t, "february.csv", batches[0].FileName)
+	assert.Equal(t, 2, batches[0].Inserted)
+
+	_, err = os.Stat(filepath.Join(c.uploadDir, token))
+	assert.True(t, os.IsNotExist(err), "staged files should be removed after confirm")
+
+	// Re-uploading the same statement previews every row as a duplicate.
+	rec = httptest.NewRecorder()
+	require.NoError(t, c.upload(rec, newUploadRequest(t, map[string]string{"february.csv": bofaStatement})))
+	assert.Contains(t, rec.Body.String(), "0 new")
+}
+
+func TestCancelUploadDiscardsStagedFiles(t *testing.T) {
+	c := newUploadController(t)
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.upload(rec, newUploadRequest(t, map[string]string{"february.csv": bofaStatement})))
+	token := tokenPattern.FindStringSubmatch(rec.Body.String())[1]
+
+	req := httptest.NewRequest(http.MethodPost, "/imports/upload/"+token+"/cancel", nil)
+	req.SetPathValue("token", token)
+	rec = httptest.NewRecorder()
+	require.NoError(t, c.cancelUpload(rec, req))
+
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	_, err := os.Stat(filepath.Join(c.uploadDir, token))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestConfirmUploadRejectsInvalidToken(t *testing.T) {
+	c := newUploadController(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/imports/upload/x/confirm", nil)
+	req.SetPathValue("token", "../../etc")
+	err := c.confirmUpload(httptest.NewRecorder(), req)
+
+	var apiErr APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+}
+
+func TestUploadKeepsFilesWithTheSameName(t *testing.T) {
+	c := newUploadController(t)
+	march := `Date,Reference Number,Payee,Address,Amount
+03/04/2026,REF003,BLUE BOTTLE,OAKLAND CA,-4.50
+`
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, c.upload(rec, newUploadPartsRequest(t, [][2]string{
+		{"statement.csv", bofaStatement},
+		{"statement.csv", march},
+	})))
+	body := rec.Body.String()
+	assert.Contains(t, body, "STARBUCKS STORE 123")
+	assert.Contains(t, body, "BLUE BOTTLE")
+	token := tokenPattern.FindStringSubmatch(body)[1]
+
+	req := httptest.NewRequest(http.MethodPost, "/imports/upload/"+token+"/confirm", nil)
+	req.SetPathValue("token", token)
+	require.NoError(t, c.confirmUpload(httptest.NewRecorder(), req))
+
+	batches, err := model.GetImportBatches(c.db)
+	require.NoError(t, err)
+	names := map[string]int{}
+	for _, b := range batches {
+		names[b.FileName] = b.Inserted
+	}
+	assert.Equal(t, map[string]int{"statement.csv": 2, "statement (2).csv": 1}, names)
+}
+
+func TestUploadRejectsInvalidFileNames(t *testing.T) {
+	c := newUploadController(t)
+
+	for _, name := range []string{"..", "/"} {
+		err := c.upload(httptest.NewRecorder(), newUploadPartsRequest(t, [][2]string{{name, bofaStatement}}))
+
+		var apiErr APIError
+		require.ErrorAs(t, err, &apiErr, name)
+		assert.Equal(t, http.StatusBadRequest, apiErr.Status, name)
+	}
+
+	entries, err := os.ReadDir(c.uploadDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "nothing staged")
+}
+
+func TestUploadSweepsAbandonedStaging(t *testing.T) {
+	c := newUploadController(t)
+
+	stale := filepath.Join(c.uploadDir, uuid.NewString())
+	fresh := filepath.Join(c.uploadDir, uuid.NewString())
+	for _, dir := range []string{stale, fresh} {
+		require.NoError(t, os.MkdirAll(dir, 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "old.csv"), []byte(bofaStatement), 0o600))
+	}
+	old := time.Now().Add(-2 * stagingTTL)
+	require.NoError(t, os.Chtimes(stale, old, old))
+
+	require.NoError(t, c.upload(httptest.NewRecorder(), newUploadRequest(t, map[string]string{"february.csv": bofaStatement})))
+
+	_, err := os.Stat(stale)
+	assert.True(t, os.IsNotExist(err), "abandoned upload should be swept")
+	_, err = os.Stat(fresh)
+	assert.NoError(t, err, "a recent upload may still be confirmed")
+}
@@ -0,0 +1,282 @@
+package csvprofile
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"fin-web/internal/model"
+	"fin-web/internal/util"
+
+	"github.com/google/uuid"
+)
+
+// Provider parses CSV exports described by a saved model.CSVProfile.
+type Provider struct {
+	DB      *sql.DB
+	Profile model.CSVProfile
+}
+
+func NewCSVProvider(db *sql.DB, profile model.CSVProfile) *Provider {
+	return &Provider{
+		DB:      db,
+		Profile: profile,
+	}
+}
+
+// LoadProviders returns a provider for every saved profile.
+func LoadProviders(db *sql.DB) ([]*Provider, error) {
+	profiles, err := model.GetCSVProfiles(db)
+	if err != nil {
+		return nil, err
+	}
+
+	providers := make([]*Provider, len(profiles))
+	for i, profile := range profiles {
+		providers[i] = NewCSVProvider(db, profile)
+	}
+
+	return providers, nil
+}
+
+func (p *Provider) GetPrefix() string {
+	return p.Profile.Name
+}
+
+// Detect checks the header and first data row against the profile. Profiles
+// that name their columns are fairly sure of a match once every name is found;
+// index-only profiles can only check that the first date parses, so they claim
+// a file with less confidence than a dedicated provider would.
+func (p *Provider) Detect(header []byte) int {
+	reader := bufio.NewReader(strings.NewReader(string(header)))
+	headerRow, err := p.skipRows(reader)
+	if err != nil {
+		return 0
+	}
+
+	cols, err := p.resolveColumns(headerRow)
+	if err != nil {
+		return 0
+	}
+
+	// The header may cut the first data row short, so only its date is used.
+	// Without a data row, only named columns are evidence of a match.
+	firstLine, _ := reader.ReadString('\n')
+	record, err := csv.NewReader(strings.NewReader(firstLine)).Read()
+	switch {
+	case err != nil:
+		if !cols.named {
+			return 0
+		}
+	case cols.date >= len(record):
+		return 0
+	default:
+		if _, err := time.Parse(p.Profile.DateLayout, strings.TrimSpace(record[cols.date])); err != nil {
+			return 0
+		}
+	}
+
+	if cols.named {
+		return 90
+	}
+	return 50
+}
+
+func (p *Provider) ParseFile(filePath string) ([]model.Transaction, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	headerRow, err := p.skipRows(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	cols, err := p.resolveColumns(headerRow)
+	if err != nil {
+		return nil, err
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var transactions []model.Transaction
+	for _, r := range records {
+		if isBlank(r) {
+			continue
+		}
+		if cols.max >= len(r) {
+			return nil, fmt.Errorf("row %q has %d columns, profile needs %d", strings.Join(r, ","), len(r), cols.max+1)
+		}
+
+		date, err := time.Parse(p.Profile.DateLayout, strings.TrimSpace(r[cols.date]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse date %q: %w", r[cols.date], err)
+		}
+
+		amount, err := p.amount(r, cols)
+		if err != nil {
+			return nil, err
+		}
+
+		name := strings.TrimSpace(r[cols.name])
+		var cc sql.NullInt32
+		categories, _ := model.SearchCategories(p.DB, []string{strings.ToLower(name)})
+		if len(categories) > 0 {
+			cc = sql.NullInt32{Valid: true, Int32: int32(categories[0].ID)}
+		}
+
+		transactions = append(transactions, model.Transaction{
+			ID:         uuid.NewString(),
+			Name:       name,
+			Source:     p.Profile.Account,
+			Account:    p.Profile.Account,
+			Date:       date.Format("2006-01-02"),
+			Amount:     amount,
+			CategoryID: cc,
+		})
+	}
+
+	return transactions, nil
+}
+
+// amount reads a single signed amount column, or debit and credit columns
+// where either may be blank. Debits are expenses and come out positive
+// whatever sign the bank printed.
+func (p *Provider) amount(r []string, cols columns) (float64, error) {
+	if cols.amount >= 0 {
+		amount, err := util.ParseAmount(strings.TrimSpace(r[cols.amount]))
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse amount %q: %w", r[cols.amount], err)
+		}
+		if p.Profile.NegateAmount {
+			amount = -amount
+		}
+		return amount, nil
+	}
+
+	if debit := strings.TrimSpace(r[cols.debit]); debit != "" {
+		amount, err := util.ParseAmount(debit)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse debit %q: %w", debit, err)
+		}
+		return math.Abs(amount), nil
+	}
+
+	if credit := strings.TrimSpace(r[cols.credit]); credit != "" {
+		amount, err := util.ParseAmount(credit)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse credit %q: %w", credit, err)
+		}
+		return -math.Abs(amount), nil
+	}
+
+	return 0, nil
+}
+
+// skipRows consumes the profile's leading rows and returns the last one parsed
+// as CSV, which is the header when there is one.
+func (p *Provider) skipRows(reader *bufio.Reader) ([]string, error) {
+	var last string
+	for range p.Profile.SkipRows {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if err == io.EOF && line == "" {
+			return nil, fmt.Errorf("file has fewer than %d rows", p.Profile.SkipRows)
+		}
+		last = line
+	}
+
+	if last == "" {
+		return nil, nil
+	}
+
+	return csv.NewReader(strings.NewReader(last)).Read()
+}
+
+type columns struct {
+	date, name, amount, debit, credit int
+	// max is the highest index a row must have.
+	max int
+	// named is set when any column was found by its header name.
+	named bool
+}
+
+func (p *Provider) resolveColumns(header []string) (columns, error) {
+	cols := columns{amount: -1, debit: -1, credit: -1}
+
+	resolve := func(spec string, dst *int) error {
+		idx, named, err := resolveColumn(spec, header)
+		if err != nil {
+			return err
+		}
+		*dst = idx
+		cols.named = cols.named || named
+		cols.max = max(cols.max, idx)
+		return nil
+	}
+
+	if err := resolve(p.Profile.DateColumn, &cols.date); err != nil {
+		return cols, err
+	}
+	if err := resolve(p.Profile.NameColumn, &cols.name); err != nil {
+		return cols, err
+	}
+
+	if p.Profile.AmountColumn.Valid && p.Profile.AmountColumn.String != "" {
+		return cols, resolve(p.Profile.AmountColumn.String, &cols.amount)
+	}
+
+	if !p.Profile.DebitColumn.Valid || !p.Profile.CreditColumn.Valid {
+		return cols, fmt.Errorf("profile %s needs an amount column or debit and credit columns", p.Profile.Name)
+	}
+	if err := resolve(p.Profile.DebitColumn.String, &cols.debit); err != nil {
+		return cols, err
+	}
+	return cols, resolve(p.Profile.CreditColumn.String, &cols.credit)
+}
+
+// resolveColumn reads spec as a 0-based index, or failing that as a header
+// name matched without regard to case or surrounding space.
+func resolveColumn(spec string, header []string) (int, bool, error) {
+	if idx, err := strconv.Atoi(spec); err == nil {
+		if idx < 0 {
+			return 0, false, fmt.Errorf("column index %d is negative", idx)
+		}
+		return idx, false, nil
+	}
+
+	for i, h := range header {
+		h = strings.TrimPrefix(h, "\ufeff")
+		if strings.EqualFold(strings.TrimSpace(h), strings.TrimSpace(spec)) {
+			return i, true, nil
+		}
+	}
+
+	return 0, false, fmt.Errorf("column %q not found in header", spec)
+}
+
+func isBlank(r []string) bool {
+	for _, v := range r {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package csvprofile
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"fin-web/internal/model"
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func namedProfile() model.CSVProfile {
+	return model.CSVProfile{
+		Name:         "capital_one",
+		Account:      "capital_one",
+		SkipRows:     2,
+		DateColumn:   "Transaction Date",
+		DateLayout:   "2006-01-02",
+		NameColumn:   "description",
+		DebitColumn:  sql.NullString{Valid: true, String: "Debit"},
+		CreditColumn: sql.NullString{Valid: true, String: "Credit"},
+	}
+}
+
+func indexedProfile() model.CSVProfile {
+	return model.CSVProfile{
+		Name:         "credit_union",
+		Account:      "credit_union",
+		DateColumn:   "0",
+		DateLayout:   "01/02/2006",
+		NameColumn:   "1",
+		AmountColumn: sql.NullString{Valid: true, String: "2"},
+		NegateAmount: true,
+	}
+}
+
+func TestParseFileNamedDebitCredit(t *testing.T) {
+	db := testutil.NewDB(t)
+	testutil.SeedCategory(t, db, "Coffee", 5, "starbucks")
+
+	p := NewCSVProvider(db, namedProfile())
+	txns, err := p.ParseFile("testdata/named.csv")
+	require.NoError(t, err)
+	require.Len(t, txns, 2, "the trailing blank line is skipped")
+
+	coffee := txns[0]
+	assert.Equal(t, "STARBUCKS STORE 123", coffee.Name)
+	assert.Equal(t, "capital_one", coffee.Source)
+	assert.Equal(t, "capital_one", coffee.Account)
+	assert.Equal(t, "2026-02-04", coffee.Date)
+	assert.InDelta(t, 5.75, coffee.Amount, 1e-9)
+	assert.True(t, coffee.CategoryID.Valid, "starbucks should be categorized")
+
+	payment := txns[1]
+	assert.InDelta(t, -1200.00, payment.Amount, 1e-9)
+	assert.False(t, payment.CategoryID.Valid)
+}
+
+func TestParseFileIndexedNegatedAmount(t *testing.T) {
+	p := NewCSVProvider(testutil.NewDB(t), indexedProfile())
+	txns, err := p.ParseFile("testdata/indexed.csv")
+	require.NoError(t, err)
+	require.Len(t, txns, 2)
+
+	assert.Equal(t, "STARBUCKS STORE 123", txns[0].Name)
+	assert.Equal(t, "2026-02-04", txns[0].Date)
+	assert.InDelta(t, 5.75, txns[0].Amount, 1e-9)
+	assert.InDelta(t, -2500.00, txns[1].Amount, 1e-9)
+}
+
+func TestParseFileMissingColumn(t *testing.T) {
+	profile := namedProfile()
+	profile.NameColumn = "Merchant"
+
+	_, err := NewCSVProvider(testutil.NewDB(t), profile).ParseFile("testdata/named.csv")
+	require.ErrorContains(t, err, `column "Merchant" not found`)
+}
+
+func TestParseFileBadDate(t *testing.T) {
+	profile := indexedProfile()
+	profile.DateLayout = "2006-01-02"
+
+	_, err := NewCSVProvider(testutil.NewDB(t), profile).ParseFile("testdata/indexed.csv")
+	require.ErrorContains(t, err, "failed to parse date")
+}
+
+func TestParseFileMissingFile(t *testing.T) {
+	p := NewCSVProvider(testutil.NewDB(t), indexedProfile())
+	_, err := p.ParseFile("testdata/does-not-exist.csv")
+	require.Error(t, err)
+}
+
+func TestGetPrefix(t *testing.T) {
+	assert.Equal(t, "capital_one", NewCSVProvider(nil, namedProfile()).GetPrefix())
+}
+
+func TestDetect(t *testing.T) {
+	named, err := os.ReadFile("testdata/named.csv")
+	require.NoError(t, err)
+	indexed, err := os.ReadFile("testdata/indexed.csv")
+	require.NoError(t, err)
+
+	namedProvider := NewCSVProvider(nil, namedProfile())
+	indexedProvider := NewCSVProvider(nil, indexedProfile())
+
+	// Named columns are stronger evidence than a date that happens to parse.
+	assert.Equal(t, 90, namedProvider.Detect(named))
+	assert.Equal(t, 50, indexedProvider.Detect(indexed))
+
+	assert.Equal(t, 0, namedProvider.Detect(indexed))
+	assert.Equal(t, 0, indexedProvider.Detect(named))
+	assert.Equal(t, 0, indexedProvider.Detect([]byte("hello")))
+}
+
+func TestLoadProviders(t *testing.T) {
+	db := testutil.NewDB(t)
+	_, err := model.CreateCSVProfile(db, namedProfile())
+	require.NoError(t, err)
+
+	providers, err := LoadProviders(db)
+	require.NoError(t, err)
+	require.Len(t, providers, 1)
+	assert.Equal(t, "capital_one", providers[0].GetPrefix())
+	assert.Equal(t, "Debit", providers[0].Profile.DebitColumn.String)
+}
@@ -2,15 +2,84 @@ package db
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 
-	_ "github.com/mattn/go-sqlite3"
+	"fin-web/internal/util"
+
+	"github.com/mattn/go-sqlite3"
 )
 
+// DriverName is the sqlite3 driver with foreign keys enforced, so the
+// schema's ON DELETE clauses run, and the application's SQL functions
+// registered on every connection:
+//
+//	merchant_key(name)  util.NormalizeMerchant, so queries can group and
+//	                    filter on the same key the detectors use in Go.
+const DriverName = "sqlite3_fin"
+
+// ErrNoFTS5 means go-sqlite3 was compiled without FTS5, which transaction
+// search is built on. It only includes it under the sqlite_fts5 build tag.
+var ErrNoFTS5 = errors.New("sqlite3 was built without FTS5, which transaction search needs: build, run and test with -tags sqlite_fts5")
+
+func init() {
+	sql.Register(DriverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			if err := requireFTS5(conn); err != nil {
+				return err
+			}
+			// SQLite leaves foreign keys off unless each connection asks.
+			if _, err := conn.Exec("PRAGMA foreign_keys = ON", nil); err != nil {
+				return err
+			}
+			return conn.RegisterFunc("merchant_key", util.NormalizeMerchant, true)
+		},
+	})
+}
+
+// NewDbConnection opens the database at path and brings its schema up to date
+// by applying any pending migrations.
 func NewDbConnection(path string) (*sql.DB, error) {
-	conn, err := sql.Open("sqlite3", path)
+	conn, err := Open(path)
 	if err != nil {
 		return nil, err
 	}
 
+	if _, err := Migrate(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
 	return conn, nil
 }
+
+// Open opens the database at path without touching its schema. Most callers
+// want NewDbConnection; this exists for tooling such as cmd/migrate.
+func Open(path string) (*sql.DB, error) {
+	conn, err := sql.Open(DriverName, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// requireFTS5 fails with ErrNoFTS5 when the linked SQLite lacks FTS5, rather
+// than letting the search migration fail with "no such module: fts5".
+func requireFTS5(conn *sqlite3.SQLiteConn) error {
+	rows, err := conn.Query("SELECT sqlite_compileoption_used('ENABLE_FTS5')", nil)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	dest := make([]driver.Value, 1)
+	if err := rows.Next(dest); err != nil {
+		return err
+	}
+	if enabled, _ := dest[0].(int64); enabled != 1 {
+		return ErrNoFTS5
+	}
+
+	return nil
+}
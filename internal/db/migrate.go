@@ -0,0 +1,256 @@
+package db
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration is one embedded schema change. Files live in migrations/ and are
+// named "<version>_<name>.sql"; they are applied in ascending version order and
+// never edited once shipped (the checksum catches that).
+type Migration struct {
+	Version  int
+	Name     string
+	SQL      string
+	Checksum string
+}
+
+// MigrationStatus is a known migration plus whether, and when, the database
+// recorded it as applied.
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt string
+}
+
+type appliedMigration struct {
+	version   int
+	checksum  string
+	appliedAt string
+}
+
+// Migrations returns every embedded migration sorted by version.
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := []Migration{}
+	seen := map[int]string{}
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(fileName, ".sql") {
+			continue
+		}
+
+		versionStr, name, ok := strings.Cut(strings.TrimSuffix(fileName, ".sql"), "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected <version>_<name>.sql", fileName)
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: invalid version %q", fileName, versionStr)
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migration %s: version %d already used by %s", fileName, version, other)
+		}
+		seen[version] = fileName
+
+		data, err := migrationFiles.ReadFile("migrations/" + fileName)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(data)
+
+		migrations = append(migrations, Migration{
+			Version:  version,
+			Name:     name,
+			SQL:      string(data),
+			Checksum: hex.EncodeToString(sum[:]),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+func ensureMigrationsTable(conn *sql.DB) error {
+	_, err := conn.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations(
+			version integer primary key,
+			name text not null,
+			checksum text not null,
+			applied_at text not null
+		)`)
+	return err
+}
+
+func getAppliedMigrations(conn *sql.DB) (map[int]appliedMigration, error) {
+	rows, err := conn.Query("SELECT version, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]appliedMigration{}
+	for rows.Next() {
+		a := appliedMigration{}
+		if err := rows.Scan(&a.version, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[a.version] = a
+	}
+
+	return applied, rows.Err()
+}
+
+// verifyApplied refuses to continue if an applied migration was edited after
+// the fact or the database was migrated by a newer binary.
+func verifyApplied(migrations []Migration, applied map[int]appliedMigration) error {
+	known := map[int]Migration{}
+	for _, m := range migrations {
+		known[m.Version] = m
+	}
+
+	for version, a := range applied {
+		m, ok := known[version]
+		if !ok {
+			return fmt.Errorf("database has migration %d applied, which this build does not know about", version)
+		}
+		if m.Checksum != a.checksum {
+			return fmt.Errorf("migration %d_%s has changed since it was applied (checksum mismatch)", m.Version, m.Name)
+		}
+	}
+
+	return nil
+}
+
+// MigrationStatuses reports every known migration and whether it has been
+// applied. It creates the schema_migrations table if needed but applies
+// nothing else.
+func MigrationStatuses(conn *sql.DB) ([]MigrationStatus, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ensureMigrationsTable(conn); err != nil {
+		return nil, err
+	}
+
+	applied, err := getAppliedMigrations(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []MigrationStatus{}
+	for _, m := range migrations {
+		a, ok := applied[m.Version]
+		statuses = append(statuses, MigrationStatus{
+			Migration: m,
+			Applied:   ok,
+			AppliedAt: a.appliedAt,
+		})
+	}
+
+	return statuses, verifyApplied(migrations, applied)
+}
+
+// Migrate applies every pending migration and returns the ones it ran.
+func Migrate(conn *sql.DB) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(migrations) == 0 {
+		return []Migration{}, nil
+	}
+
+	return MigrateTo(conn, migrations[len(migrations)-1].Version)
+}
+
+// MigrateTo applies pending migrations up to and including target. Each
+// migration runs in its own transaction together with its schema_migrations
+// row, so a failure leaves the database at the last good version. There are no
+// down migrations: asking for a version below one already applied is an error.
+func MigrateTo(conn *sql.DB, target int) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ensureMigrationsTable(conn); err != nil {
+		return nil, err
+	}
+
+	applied, err := getAppliedMigrations(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyApplied(migrations, applied); err != nil {
+		return nil, err
+	}
+
+	for version := range applied {
+		if version > target {
+			return nil, fmt.Errorf("database is already at migration %d, past target %d", version, target)
+		}
+	}
+
+	ran := []Migration{}
+	for _, m := range migrations {
+		if m.Version > target {
+			break
+		}
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		if err := applyMigration(conn, m); err != nil {
+			return ran, err
+		}
+		ran = append(ran, m)
+	}
+
+	return ran, nil
+}
+
+func applyMigration(conn *sql.DB, m Migration) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.SQL); err != nil {
+		return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO schema_migrations(version, name, checksum, applied_at) VALUES(?, ?, ?, ?)",
+		m.Version,
+		m.Name,
+		m.Checksum,
+		time.Now().UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("recording migration %d_%s: %w", m.Version, m.Name, err)
+	}
+
+	return tx.Commit()
+}
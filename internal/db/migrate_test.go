@@ -0,0 +1,126 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMemoryDB(t *testing.T) *sql.DB {
+	t.Helper()
+	conn, err := Open(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	conn.SetMaxOpenConns(1)
+	return conn
+}
+
+func TestMigrationsSortedAndChecksummed(t *testing.T) {
+	migrations, err := Migrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, m := range migrations {
+		assert.NotEmpty(t, m.Name)
+		assert.Len(t, m.Checksum, 64)
+		if i > 0 {
+			assert.Greater(t, m.Version, migrations[i-1].Version)
+		}
+	}
+}
+
+func TestMigrateAppliesOnceThenNoop(t *testing.T) {
+	conn := newMemoryDB(t)
+
+	ran, err := Migrate(conn)
+	require.NoError(t, err)
+	migrations, err := Migrations()
+	require.NoError(t, err)
+	assert.Len(t, ran, len(migrations))
+
+	ran, err = Migrate(conn)
+	require.NoError(t, err)
+	assert.Empty(t, ran)
+
+	statuses, err := MigrationStatuses(conn)
+	require.NoError(t, err)
+	for _, s := range statuses {
+		assert.True(t, s.Applied, "migration %d should be applied", s.Version)
+		assert.NotEmpty(t, s.AppliedAt)
+	}
+}
+
+func TestMigrateAdoptsPreexistingSchema(t *testing.T) {
+	conn := newMemoryDB(t)
+
+	// A database created before migrations existed already has the tables.
+	_, err := conn.Exec("CREATE TABLE transactions(name text, amount int, date text, source text, account text, category text, id text PRIMARY KEY, description text, category_id integer, is_reimbursement BOOLEAN DEFAULT 0)")
+	require.NoError(t, err)
+	_, err = conn.Exec("INSERT INTO transactions(id, name, amount, date) VALUES('keep-me', 'x', 1, '2026-01-01')")
+	require.NoError(t, err)
+
+	_, err = Migrate(conn)
+	require.NoError(t, err)
+
+	var count int
+	require.NoError(t, conn.QueryRow("SELECT COUNT(*) FROM transactions WHERE id = 'keep-me'").Scan(&count))
+	assert.Equal(t, 1, count)
+}
+
+func TestMigrateToStopsAtTarget(t *testing.T) {
+	conn := newMemoryDB(t)
+
+	ran, err := MigrateTo(conn, 1)
+	require.NoError(t, err)
+	require.Len(t, ran, 1)
+	assert.Equal(t, 1, ran[0].Version)
+
+	statuses, err := MigrationStatuses(conn)
+	require.NoError(t, err)
+	for _, s := range statuses {
+		assert.Equal(t, s.Version <= 1, s.Applied)
+	}
+}
+
+func TestMigrateRejectsChangedChecksum(t *testing.T) {
+	conn := newMemoryDB(t)
+	_, err := Migrate(conn)
+	require.NoError(t, err)
+
+	_, err = conn.Exec("UPDATE schema_migrations SET checksum = 'tampered' WHERE version = 1")
+	require.NoError(t, err)
+
+	_, err = Migrate(conn)
+	require.ErrorContains(t, err, "checksum mismatch")
+}
+
+func TestMigrateRejectsUnknownAppliedVersion(t *testing.T) {
+	conn := newMemoryDB(t)
+	_, err := Migrate(conn)
+	require.NoError(t, err)
+
+	_, err = conn.Exec("INSERT INTO schema_migrations(version, name, checksum, applied_at) VALUES(9999, 'future', 'x', 'now')")
+	require.NoError(t, err)
+
+	_, err = Migrate(conn)
+	require.ErrorContains(t, err, "does not know about")
+}
+
+func TestMigrateIndexesExistingTransactionsForSearch(t *testing.T) {
+	conn := newMemoryDB(t)
+
+	// Transactions from before the search index existed.
+	_, err := MigrateTo(conn, 11)
+	require.NoError(t, err)
+	_, err = conn.Exec("INSERT INTO transactions(id, name, amount, date) VALUES('coffee', 'BLUE BOTTLE', 6, '2026-01-01')")
+	require.NoError(t, err)
+
+	_, err = Migrate(conn)
+	require.NoError(t, err)
+
+	var id string
+	require.NoError(t, conn.QueryRow("SELECT r.transaction_id FROM transactions_fts JOIN transactions_fts_rows AS r ON r.id = transactions_fts.rowid WHERE transactions_fts MATCH 'bott*'").Scan(&id))
+	assert.Equal(t, "coffee", id)
+}
@@ -1,8 +1,7 @@
--- Test schema mirroring the production tables (see project schema / sqlite
--- .schema). Kept here so tests stay hermetic and don't depend on a checked-in
--- database file.
+-- Baseline schema. Every statement is IF NOT EXISTS so databases created
+-- before migrations existed are adopted in place rather than rebuilt.
 
-CREATE TABLE categories(
+CREATE TABLE IF NOT EXISTS categories(
 	id integer primary key autoincrement,
 	priority INTEGER not null,
 	label text,
@@ -10,13 +9,13 @@ CREATE TABLE categories(
 	type TEXT CHECK(type IS NULL OR type IN ('income', 'fixed', 'fun', 'neutral'))
 );
 
-CREATE TABLE category_values(
+CREATE TABLE IF NOT EXISTS category_values(
 	id integer primary key autoincrement,
 	category_id integer not null,
 	value text not null
 );
 
-CREATE TRIGGER validate_insert_categories
+CREATE TRIGGER IF NOT EXISTS validate_insert_categories
 BEFORE INSERT ON categories
 FOR EACH ROW
 WHEN EXISTS (SELECT 1 FROM categories WHERE priority = NEW.priority)
@@ -24,7 +23,7 @@ BEGIN
 	SELECT RAISE(ABORT, 'Error: This value already exists in the table.');
 END;
 
-CREATE TRIGGER validate_update_category_priority
+CREATE TRIGGER IF NOT EXISTS validate_update_category_priority
 BEFORE UPDATE OF priority ON categories
 FOR EACH ROW
 WHEN EXISTS (SELECT 1 FROM categories
@@ -34,7 +33,7 @@ BEGIN
 	SELECT RAISE(ABORT, 'Error: This value already exists in another row.');
 END;
 
-CREATE TABLE transactions(
+CREATE TABLE IF NOT EXISTS transactions(
 	name text,
 	amount int,
 	date text,
@@ -47,7 +46,7 @@ CREATE TABLE transactions(
 	is_reimbursement BOOLEAN DEFAULT 0
 );
 
-CREATE TABLE net_worth(
+CREATE TABLE IF NOT EXISTS net_worth(
 	id text PRIMARY KEY,
 	date text,
 	cash real,
@@ -59,7 +58,7 @@ CREATE TABLE net_worth(
 	loans real
 );
 
-CREATE TABLE trades(
+CREATE TABLE IF NOT EXISTS trades(
 	id integer primary key autoincrement,
 	ticker text not null,
 	purchase_date text not null,
@@ -70,7 +69,7 @@ CREATE TABLE trades(
 	name text
 );
 
-CREATE TABLE kv_cache(
+CREATE TABLE IF NOT EXISTS kv_cache(
 	key text primary key,
 	value text,
 	expires_at text
@@ -0,0 +1,6 @@
+-- Stable per-row import key so re-importing an overlapping statement skips
+-- rows that are already stored. Rows imported before this migration keep a
+-- NULL fingerprint; SQLite allows any number of NULLs in a unique index.
+ALTER TABLE transactions ADD COLUMN fingerprint text;
+
+CREATE UNIQUE INDEX IF NOT EXISTS transactions_fingerprint ON transactions(fingerprint);
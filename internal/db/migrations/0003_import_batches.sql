@@ -0,0 +1,18 @@
+-- One row per imported statement file, so every transaction can be traced
+-- back to the run that created it (and a bad run undone as a unit).
+CREATE TABLE IF NOT EXISTS import_batches(
+	id integer primary key autoincrement,
+	file_name text not null,
+	provider text not null,
+	file_hash text not null,
+	inserted integer not null default 0,
+	duplicates integer not null default 0,
+	rejected integer not null default 0,
+	status text not null CHECK(status IN ('running', 'completed', 'failed')),
+	error text,
+	created_at text not null
+);
+
+ALTER TABLE transactions ADD COLUMN batch_id integer REFERENCES import_batches(id);
+
+CREATE INDEX IF NOT EXISTS transactions_batch_id ON transactions(batch_id);
@@ -0,0 +1,3 @@
+-- Set when a batch is undone from the imports page; its transactions are
+-- deleted but the batch row stays as history.
+ALTER TABLE import_batches ADD COLUMN reverted_at text;
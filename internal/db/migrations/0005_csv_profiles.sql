@@ -0,0 +1,17 @@
+-- Column mappings for the generic CSV provider, so a new bank's export can be
+-- imported without writing a provider package. Columns are either 0-based
+-- indexes or header names; either amount_column or both debit_column and
+-- credit_column are set.
+CREATE TABLE IF NOT EXISTS csv_profiles(
+	id integer primary key autoincrement,
+	name text not null unique,
+	account text not null,
+	skip_rows integer not null default 1,
+	date_column text not null,
+	date_layout text not null,
+	name_column text not null,
+	amount_column text,
+	debit_column text,
+	credit_column text,
+	negate_amount boolean not null default 0
+);
@@ -0,0 +1,20 @@
+-- Categorization rules evaluated at import before falling back to
+-- category_values substring matches. The lowest priority that matches wins.
+-- Every condition column is optional; day_min/day_max bound the day of month.
+CREATE TABLE IF NOT EXISTS category_rules(
+	id integer primary key autoincrement,
+	priority integer not null,
+	match_type text not null CHECK(match_type IN ('contains', 'exact', 'prefix', 'regex', 'merchant')),
+	pattern text not null,
+	min_amount real,
+	max_amount real,
+	source text,
+	account text,
+	day_min integer CHECK(day_min BETWEEN 1 AND 31),
+	day_max integer CHECK(day_max BETWEEN 1 AND 31),
+	category_id integer not null REFERENCES categories(id) ON DELETE CASCADE,
+	is_reimbursement boolean not null default 0
+);
+
+-- The rule that categorized a transaction, if any.
+ALTER TABLE transactions ADD COLUMN rule_id integer REFERENCES category_rules(id) ON DELETE SET NULL;
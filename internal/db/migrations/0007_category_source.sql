@@ -0,0 +1,10 @@
+-- Who or what last assigned a transaction's category, and when (RFC 3339).
+-- 'rule' is a category rule (see rule_id), 'import' a category value match
+-- made when the row was parsed, 'manual' a person.
+ALTER TABLE transactions ADD COLUMN category_source text CHECK(category_source IN ('rule', 'import', 'manual'));
+ALTER TABLE transactions ADD COLUMN category_assigned_at text;
+
+-- Earlier rows can't say whether a person picked their category, so every
+-- categorized row without a rule is assumed to have come from its import.
+UPDATE transactions SET category_source = 'rule' WHERE rule_id IS NOT NULL;
+UPDATE transactions SET category_source = 'import' WHERE rule_id IS NULL AND category_id IS NOT NULL;
@@ -0,0 +1,12 @@
+-- Allocations of one transaction across several categories. When a
+-- transaction has splits their amounts sum to its amount, and category totals
+-- count the splits instead of the transaction's own category.
+CREATE TABLE IF NOT EXISTS transaction_splits(
+	id integer primary key autoincrement,
+	transaction_id text not null REFERENCES transactions(id) ON DELETE CASCADE,
+	amount real not null,
+	category_id integer REFERENCES categories(id) ON DELETE SET NULL,
+	description text
+);
+
+CREATE INDEX IF NOT EXISTS transaction_splits_transaction_id ON transaction_splits(transaction_id);
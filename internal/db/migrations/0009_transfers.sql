@@ -0,0 +1,16 @@
+-- Pairs of transactions that move money between two of our own accounts, such
+-- as a card payment leaving checking and arriving on the card. The outflow is
+-- the positive (expense-signed) side. Confirmed pairs are left out of income
+-- and expense totals; detected pairs await review and still count; unlinked
+-- pairs are kept so detection doesn't propose them again.
+CREATE TABLE IF NOT EXISTS transfers(
+	id integer primary key autoincrement,
+	outflow_id text not null REFERENCES transactions(id) ON DELETE CASCADE,
+	inflow_id text not null REFERENCES transactions(id) ON DELETE CASCADE,
+	status text not null CHECK(status IN ('detected', 'confirmed', 'unlinked')),
+	created_at text not null
+);
+
+-- A transaction is in at most one live pair.
+CREATE UNIQUE INDEX IF NOT EXISTS transfers_outflow_id ON transfers(outflow_id) WHERE status != 'unlinked';
+CREATE UNIQUE INDEX IF NOT EXISTS transfers_inflow_id ON transfers(inflow_id) WHERE status != 'unlinked';
@@ -0,0 +1,18 @@
+-- Expenses someone has promised to pay back, such as a work trip or a shared
+-- bill. Totals are unaffected until a reimbursement is linked.
+ALTER TABLE transactions ADD COLUMN expects_reimbursement boolean not null default 0;
+
+-- Links a reimbursement (income-signed) to an expense it pays back. amount is
+-- the positive part of the reimbursement applied to that expense; one
+-- reimbursement can cover several expenses and one expense can be paid back
+-- in several reimbursements. Totals net each linked amount out of both sides.
+CREATE TABLE IF NOT EXISTS reimbursements(
+	id integer primary key autoincrement,
+	reimbursement_id text not null REFERENCES transactions(id) ON DELETE CASCADE,
+	expense_id text not null REFERENCES transactions(id) ON DELETE CASCADE,
+	amount real not null CHECK(amount > 0),
+	created_at text not null
+);
+
+CREATE INDEX IF NOT EXISTS reimbursements_reimbursement_id ON reimbursements(reimbursement_id);
+CREATE INDEX IF NOT EXISTS reimbursements_expense_id ON reimbursements(expense_id);
@@ -0,0 +1,11 @@
+-- Banks list card charges as pending before they post, often with a slightly
+-- different name, date or amount (a restaurant tip, say). Rows imported
+-- before statuses were tracked came from posted statements.
+ALTER TABLE transactions ADD COLUMN status text not null default 'posted' CHECK(status IN ('pending', 'posted'));
+
+-- Posted rows that settled a stored pending row instead of being inserted.
+ALTER TABLE import_batches ADD COLUMN reconciled integer not null default 0;
+
+-- The import whose posted row settled a pending row. Reverting that import
+-- removes the row; reverting the one that saw it pending leaves it be.
+ALTER TABLE transactions ADD COLUMN posted_batch_id integer REFERENCES import_batches(id);
@@ -0,0 +1,37 @@
+-- Full-text index over the words people search transactions by: the name,
+-- their own description and the category the bank exported. It is
+-- contentless: transactions already holds the text, so only the index is
+-- stored, and contentless_delete lets the triggers remove a row by rowid
+-- alone. FTS5 rows are keyed by an integer rowid, which VACUUM may renumber
+-- on transactions, so transactions_fts_rows gives each transaction id one of
+-- its own. Needs go-sqlite3 built with the sqlite_fts5 tag.
+CREATE TABLE IF NOT EXISTS transactions_fts_rows(
+	id integer primary key,
+	transaction_id text not null unique
+);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS transactions_fts USING fts5(name, description, category, content='', contentless_delete=1, prefix='2 3');
+
+INSERT INTO transactions_fts_rows(transaction_id) SELECT id FROM transactions WHERE id IS NOT NULL;
+
+INSERT INTO transactions_fts(rowid, name, description, category)
+	SELECT r.id, COALESCE(t.name, ''), COALESCE(t.description, ''), COALESCE(t.category, '')
+	FROM transactions AS t JOIN transactions_fts_rows AS r ON r.transaction_id = t.id;
+
+CREATE TRIGGER IF NOT EXISTS transactions_fts_insert AFTER INSERT ON transactions BEGIN
+	INSERT INTO transactions_fts_rows(transaction_id) VALUES(new.id);
+	INSERT INTO transactions_fts(rowid, name, description, category)
+		VALUES((SELECT id FROM transactions_fts_rows WHERE transaction_id = new.id), COALESCE(new.name, ''), COALESCE(new.description, ''), COALESCE(new.category, ''));
+END;
+
+CREATE TRIGGER IF NOT EXISTS transactions_fts_update AFTER UPDATE OF id, name, description, category ON transactions BEGIN
+	DELETE FROM transactions_fts WHERE rowid = (SELECT id FROM transactions_fts_rows WHERE transaction_id = old.id);
+	UPDATE transactions_fts_rows SET transaction_id = new.id WHERE transaction_id = old.id;
+	INSERT INTO transactions_fts(rowid, name, description, category)
+		VALUES((SELECT id FROM transactions_fts_rows WHERE transaction_id = new.id), COALESCE(new.name, ''), COALESCE(new.description, ''), COALESCE(new.category, ''));
+END;
+
+CREATE TRIGGER IF NOT EXISTS transactions_fts_delete AFTER DELETE ON transactions BEGIN
+	DELETE FROM transactions_fts WHERE rowid = (SELECT id FROM transactions_fts_rows WHERE transaction_id = old.id);
+	DELETE FROM transactions_fts_rows WHERE transaction_id = old.id;
+END;
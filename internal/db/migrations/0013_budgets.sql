@@ -0,0 +1,11 @@
+-- A monthly spending budget per category. With rollover, whatever was left
+-- over or overspent in each month since start_month (YYYY-MM) carries into
+-- the next; without it every month starts fresh.
+CREATE TABLE IF NOT EXISTS budgets(
+	id integer primary key autoincrement,
+	category_id integer not null unique REFERENCES categories(id) ON DELETE CASCADE,
+	amount real not null CHECK(amount >= 0),
+	rollover boolean not null default 0,
+	start_month text not null,
+	created_at text not null
+);
@@ -0,0 +1,11 @@
+-- What someone has said about a recurring series, keyed by the normalized
+-- merchant key detection groups charges under. Detection is still derived
+-- from transactions on every request; these rows only steer it.
+CREATE TABLE IF NOT EXISTS recurring_decisions(
+	merchant_key text primary key,
+	status text not null default '' CHECK(status IN ('', 'confirmed', 'ignored')),
+	display_name text not null default '',
+	merge_into text not null default '',
+	cadence text not null default '' CHECK(cadence IN ('', 'weekly', 'biweekly', 'monthly', 'quarterly', 'annual')),
+	updated_at text not null
+);
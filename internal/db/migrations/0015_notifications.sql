@@ -0,0 +1,14 @@
+-- Things the app has noticed and wants someone to see, such as a
+-- subscription changing price. dedupe_key is unique so re-running whatever
+-- noticed it doesn't repeat a notification.
+CREATE TABLE IF NOT EXISTS notifications(
+	id integer primary key autoincrement,
+	kind text not null,
+	dedupe_key text not null unique,
+	message text not null,
+	link text not null default '',
+	created_at text not null,
+	read_at text
+);
+
+CREATE INDEX IF NOT EXISTS notifications_read_at ON notifications(read_at);
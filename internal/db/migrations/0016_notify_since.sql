@@ -0,0 +1,7 @@
+-- When alerts started going out to notifiers. The first run of the alert
+-- rules finds everything already in the database; it fills the feed, but only
+-- what happens from this day on is sent by email or webhook.
+CREATE TABLE IF NOT EXISTS notify_since(
+	id integer primary key check (id = 1),
+	since text not null
+);
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Budget caps monthly spending in one category. StartMonth (YYYY-MM) is when
+// rollover starts counting.
+type Budget struct {
+	ID            int
+	CategoryID    int
+	CategoryLabel string
+	Amount        float64
+	Rollover      bool
+	StartMonth    string
+	CreatedAt     string
+}
+
+// GetBudgets returns every budget in category priority order. Budgets whose
+// category has been deleted are left out.
+func GetBudgets(conn *sql.DB) ([]Budget, error) {
+	rows, err := conn.Query(
+		"SELECT b.id, b.category_id, c.label, b.amount, b.rollover, b.start_month, b.created_at FROM budgets AS b JOIN categories AS c ON b.category_id = c.id ORDER BY c.priority, c.id",
+	)
+	if err != nil {
+		return []Budget{}, err
+	}
+	defer rows.Close()
+
+	budgets := []Budget{}
+	for rows.Next() {
+		budget := Budget{}
+		if err := rows.Scan(
+			&budget.ID,
+			&budget.CategoryID,
+			&budget.CategoryLabel,
+			&budget.Amount,
+			&budget.Rollover,
+			&budget.StartMonth,
+			&budget.CreatedAt,
+		); err != nil {
+			return []Budget{}, err
+		}
+
+		budgets = append(budgets, budget)
+	}
+
+	return budgets, rows.Err()
+}
+
+// SetBudget creates the budget for budget.CategoryID, or replaces its
+// amount, rollover and start month if the category already has one.
+func SetBudget(conn *sql.DB, budget Budget) (int, error) {
+	var id int
+	err := conn.QueryRow(
+		"INSERT INTO budgets(category_id, amount, rollover, start_month, created_at) VALUES(?, ?, ?, ?, ?) ON CONFLICT(category_id) DO UPDATE SET amount = excluded.amount, rollover = excluded.rollover, start_month = excluded.start_month RETURNING id",
+		budget.CategoryID,
+		budget.Amount,
+		budget.Rollover,
+		budget.StartMonth,
+		time.Now().UTC().Format(time.RFC3339),
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func DeleteBudget(conn *sql.DB, ID string) error {
+	_, err := conn.Exec("DELETE FROM budgets WHERE id = ?", ID)
+	return err
+}
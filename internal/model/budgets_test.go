@@ -0,0 +1,48 @@
+package model
+
+import (
+	"strconv"
+	"testing"
+
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetBudgetReplacesCategoryBudget(t *testing.T) {
+	db := testutil.NewDB(t)
+	dining := seedTypedCategory(t, db, "dining", 1, "fun")
+	rent := seedTypedCategory(t, db, "rent", 2, "fixed")
+
+	id, err := SetBudget(db, Budget{CategoryID: dining, Amount: 300, StartMonth: "2026-01"})
+	require.NoError(t, err)
+	_, err = SetBudget(db, Budget{CategoryID: rent, Amount: 2000, StartMonth: "2026-01"})
+	require.NoError(t, err)
+
+	again, err := SetBudget(db, Budget{CategoryID: dining, Amount: 250, Rollover: true, StartMonth: "2026-02"})
+	require.NoError(t, err)
+	assert.Equal(t, id, again, "one budget per category")
+
+	budgets, err := GetBudgets(db)
+	require.NoError(t, err)
+	require.Len(t, budgets, 2)
+	assert.Equal(t, "dining", budgets[0].CategoryLabel)
+	assert.InDelta(t, 250, budgets[0].Amount, 1e-9)
+	assert.True(t, budgets[0].Rollover)
+	assert.Equal(t, "2026-02", budgets[0].StartMonth)
+
+	// Deleting a category deletes its budget.
+	require.NoError(t, DeleteCategory(db, strconv.Itoa(rent)))
+	var n int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM budgets WHERE category_id = ?", rent).Scan(&n))
+	assert.Zero(t, n)
+	budgets, err = GetBudgets(db)
+	require.NoError(t, err)
+	assert.Len(t, budgets, 1)
+
+	require.NoError(t, DeleteBudget(db, strconv.Itoa(id)))
+	budgets, err = GetBudgets(db)
+	require.NoError(t, err)
+	assert.Empty(t, budgets)
+}
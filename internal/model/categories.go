@@ -226,7 +226,7 @@ func DeleteCategory(conn *sql.DB, ID string) error {
 	return nil
 }
 
-func CreateCategoryValue(conn *sql.DB, categoryID int, value string) (int, error) {
+func CreateCategoryValue(conn Querier, categoryID int, value string) (int, error) {
 	queryStr := "INSERT INTO category_values (category_id, value) VALUES(?, ?) RETURNING id"
 	args := []any{
 		categoryID,
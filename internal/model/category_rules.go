@@ -0,0 +1,128 @@
+package model
+
+import (
+	"database/sql"
+)
+
+const (
+	RuleMatchContains = "contains"
+	RuleMatchExact    = "exact"
+	RuleMatchPrefix   = "prefix"
+	RuleMatchRegex    = "regex"
+	RuleMatchMerchant = "merchant"
+)
+
+var RuleMatchTypes = []string{
+	RuleMatchContains,
+	RuleMatchExact,
+	RuleMatchPrefix,
+	RuleMatchRegex,
+	RuleMatchMerchant,
+}
+
+// CategoryRule assigns CategoryID to transactions whose name matches Pattern
+// under MatchType and which meet every set condition. The lowest Priority
+// wins, as with categories.
+type CategoryRule struct {
+	ID              int
+	Priority        int
+	MatchType       string
+	Pattern         string
+	MinAmount       sql.NullFloat64
+	MaxAmount       sql.NullFloat64
+	Source          sql.NullString
+	Account         sql.NullString
+	DayMin          sql.NullInt32
+	DayMax          sql.NullInt32
+	CategoryID      int
+	CategoryLabel   string
+	IsReimbursement bool
+	// Applied counts the transactions this rule categorized.
+	Applied int
+}
+
+// GetCategoryRules returns every rule in evaluation order. Rules whose
+// category has been deleted are left out.
+func GetCategoryRules(conn *sql.DB) ([]CategoryRule, error) {
+	rows, err := conn.Query(
+		"SELECT r.id, r.priority, r.match_type, r.pattern, r.min_amount, r.max_amount, r.source, r.account, r.day_min, r.day_max, r.category_id, c.label, r.is_reimbursement, (SELECT COUNT(*) FROM transactions AS t WHERE t.rule_id = r.id) FROM category_rules AS r JOIN categories AS c ON r.category_id = c.id ORDER BY r.priority, r.id",
+	)
+	if err != nil {
+		return []CategoryRule{}, err
+	}
+	defer rows.Close()
+
+	rules := []CategoryRule{}
+	for rows.Next() {
+		rule := CategoryRule{}
+		if err := rows.Scan(
+			&rule.ID,
+			&rule.Priority,
+			&rule.MatchType,
+			&rule.Pattern,
+			&rule.MinAmount,
+			&rule.MaxAmount,
+			&rule.Source,
+			&rule.Account,
+			&rule.DayMin,
+			&rule.DayMax,
+			&rule.CategoryID,
+			&rule.CategoryLabel,
+			&rule.IsReimbursement,
+			&rule.Applied,
+		); err != nil {
+			return []CategoryRule{}, err
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+func CreateCategoryRule(conn *sql.DB, rule CategoryRule) (int, error) {
+	queryStr := "INSERT INTO category_rules(priority, match_type, pattern, min_amount, max_amount, source, account, day_min, day_max, category_id, is_reimbursement) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id"
+
+	var lastInsertID int
+	err := conn.QueryRow(
+		queryStr,
+		rule.Priority,
+		rule.MatchType,
+		rule.Pattern,
+		rule.MinAmount,
+		rule.MaxAmount,
+		rule.Source,
+		rule.Account,
+		rule.DayMin,
+		rule.DayMax,
+		rule.CategoryID,
+		rule.IsReimbursement,
+	).Scan(&lastInsertID)
+	if err != nil {
+		return 0, err
+	}
+
+	return lastInsertID, nil
+}
+
+// DeleteCategoryRule removes a rule and forgets it on the transactions it
+// categorized; their categories are kept.
+func DeleteCategoryRule(conn *sql.DB, ID string) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE transactions SET rule_id = NULL WHERE rule_id = ?", ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM category_rules WHERE id = ?", ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"database/sql"
+	"strconv"
+	"testing"
+
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCategoryRuleTracksTransactions(t *testing.T) {
+	db := testutil.NewDB(t)
+	home := seedTypedCategory(t, db, "home", 1, "fun")
+	other := seedTypedCategory(t, db, "other", 2, "fun")
+
+	ruleID, err := CreateCategoryRule(db, CategoryRule{Priority: 1, MatchType: RuleMatchContains, Pattern: "ikea", CategoryID: home})
+	require.NoError(t, err)
+
+	for _, id := range []string{"a", "b"} {
+		require.NoError(t, CreateTransaction(db, Transaction{
+			ID:         id,
+			Name:       "IKEA",
+			Amount:     300,
+			Date:       "2026-02-04",
+			CategoryID: sql.NullInt32{Valid: true, Int32: int32(home)},
+			RuleID:     sql.NullInt64{Valid: true, Int64: int64(ruleID)},
+		}))
+	}
+
+	rules, err := GetCategoryRules(db)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, "home", rules[0].CategoryLabel)
+	assert.Equal(t, 2, rules[0].Applied)
+
+	// Resaving the same category keeps the rule; picking another drops it.
+	require.NoError(t, UpdateTransaction(db, "a", UpdateTransactionParams{CategoryID: &home}))
+	require.NoError(t, UpdateTransaction(db, "b", UpdateTransactionParams{CategoryID: &other}))
+
+	a, err := GetTransaction(db, "a")
+	require.NoError(t, err)
+	assert.True(t, a.RuleID.Valid)
+	b, err := GetTransaction(db, "b")
+	require.NoError(t, err)
+	assert.False(t, b.RuleID.Valid)
+
+	require.NoError(t, DeleteCategoryRule(db, strconv.Itoa(ruleID)))
+	a, err = GetTransaction(db, "a")
+	require.NoError(t, err)
+	assert.False(t, a.RuleID.Valid)
+	assert.Equal(t, int32(home), a.CategoryID.Int32, "deleting a rule keeps the categories it set")
+}
@@ -0,0 +1,107 @@
+package model
+
+import (
+	"database/sql"
+)
+
+// CSVProfile maps one bank's CSV export onto transactions. SkipRows lines are
+// skipped before the data starts; when columns are given by name the last
+// skipped line is the header they are looked up in. NegateAmount is set for
+// exports that show expenses as negative amounts.
+type CSVProfile struct {
+	ID           int
+	Name         string
+	Account      string
+	SkipRows     int
+	DateColumn   string
+	DateLayout   string
+	NameColumn   string
+	AmountColumn sql.NullString
+	DebitColumn  sql.NullString
+	CreditColumn sql.NullString
+	NegateAmount bool
+}
+
+const csvProfileColumns = "id, name, account, skip_rows, date_column, date_layout, name_column, amount_column, debit_column, credit_column, negate_amount"
+
+func scanCSVProfile(row interface{ Scan(...any) error }, profile *CSVProfile) error {
+	return row.Scan(
+		&profile.ID,
+		&profile.Name,
+		&profile.Account,
+		&profile.SkipRows,
+		&profile.DateColumn,
+		&profile.DateLayout,
+		&profile.NameColumn,
+		&profile.AmountColumn,
+		&profile.DebitColumn,
+		&profile.CreditColumn,
+		&profile.NegateAmount,
+	)
+}
+
+func GetCSVProfiles(conn *sql.DB) ([]CSVProfile, error) {
+	rows, err := conn.Query("SELECT " + csvProfileColumns + " FROM csv_profiles ORDER BY name")
+	if err != nil {
+		return []CSVProfile{}, err
+	}
+	defer rows.Close()
+
+	profiles := []CSVProfile{}
+	for rows.Next() {
+		profile := CSVProfile{}
+		if err := scanCSVProfile(rows, &profile); err != nil {
+			return []CSVProfile{}, err
+		}
+
+		profiles = append(profiles, profile)
+	}
+
+	return profiles, rows.Err()
+}
+
+func GetCSVProfile(conn *sql.DB, ID string) (CSVProfile, error) {
+	profile := CSVProfile{}
+	err := scanCSVProfile(
+		conn.QueryRow("SELECT "+csvProfileColumns+" FROM csv_profiles WHERE id = ?", ID),
+		&profile,
+	)
+	if err != nil {
+		return CSVProfile{}, err
+	}
+
+	return profile, nil
+}
+
+func CreateCSVProfile(conn *sql.DB, profile CSVProfile) (int, error) {
+	queryStr := "INSERT INTO csv_profiles(name, account, skip_rows, date_column, date_layout, name_column, amount_column, debit_column, credit_column, negate_amount) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id"
+
+	var lastInsertID int
+	err := conn.QueryRow(
+		queryStr,
+		profile.Name,
+		profile.Account,
+		profile.SkipRows,
+		profile.DateColumn,
+		profile.DateLayout,
+		profile.NameColumn,
+		profile.AmountColumn,
+		profile.DebitColumn,
+		profile.CreditColumn,
+		profile.NegateAmount,
+	).Scan(&lastInsertID)
+	if err != nil {
+		return 0, err
+	}
+
+	return lastInsertID, nil
+}
+
+func DeleteCSVProfile(conn *sql.DB, ID string) error {
+	_, err := conn.Exec("DELETE FROM csv_profiles WHERE id = ?", ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
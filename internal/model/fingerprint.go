@@ -0,0 +1,90 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Fingerprint derives a deterministic import key for a parsed row from its
+// source, account, date, amount and normalized name. occurrence is the row's
+// position among otherwise identical rows in the same file (two $5.75 coffees
+// on one day are 0 and 1), so genuine repeats survive while a second import of
+// the same statement collapses onto the rows already stored. Pending rows are
+// keyed apart from posted ones, so a charge that posts unchanged isn't taken
+// for a duplicate of its pending row.
+func Fingerprint(t Transaction, occurrence int) string {
+	parts := []string{
+		t.Source,
+		t.Account,
+		t.Date,
+		fmt.Sprintf("%.2f", t.Amount),
+		normalizeFingerprintName(t.Name),
+		fmt.Sprint(occurrence),
+	}
+	if t.Pending() {
+		parts = append(parts, TransactionPending)
+	}
+
+	key := strings.Join(parts, "|")
+
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// AssignFingerprints sets Fingerprint on every transaction that doesn't
+// already carry one (providers with a native unique ID may set their own),
+// numbering identical rows in slice order.
+func AssignFingerprints(transactions []Transaction) {
+	occurrences := map[string]int{}
+	for i, t := range transactions {
+		if t.Fingerprint != "" {
+			continue
+		}
+
+		base := Fingerprint(t, 0)
+		transactions[i].Fingerprint = Fingerprint(t, occurrences[base])
+		occurrences[base]++
+	}
+}
+
+// normalizeFingerprintName ignores case and whitespace differences between
+// exports of the same row.
+func normalizeFingerprintName(name string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(name), " "))
+}
+
+// ExistingFingerprints reports which of the given fingerprints are already
+// stored.
+func ExistingFingerprints(conn Querier, fingerprints []string) (map[string]bool, error) {
+	existing := map[string]bool{}
+	if len(fingerprints) == 0 {
+		return existing, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(fingerprints)), ",")
+	args := make([]any, len(fingerprints))
+	for i, f := range fingerprints {
+		args[i] = f
+	}
+
+	rows, err := conn.Query(
+		"SELECT fingerprint FROM transactions WHERE fingerprint IN ("+placeholders+")",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var f string
+		if err := rows.Scan(&f); err != nil {
+			return nil, err
+		}
+		existing[f] = true
+	}
+
+	return existing, rows.Err()
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprintIgnoresCaseAndWhitespace(t *testing.T) {
+	a := Transaction{Source: "citi", Account: "citi", Date: "2026-02-04", Amount: 5.75, Name: "STARBUCKS  STORE 123"}
+	b := a
+	b.Name = " starbucks store 123 "
+
+	assert.Equal(t, Fingerprint(a, 0), Fingerprint(b, 0))
+	assert.NotEqual(t, Fingerprint(a, 0), Fingerprint(a, 1))
+
+	c := a
+	c.Account = "bank_of_america"
+	assert.NotEqual(t, Fingerprint(a, 0), Fingerprint(c, 0))
+}
+
+func TestAssignFingerprintsNumbersIdenticalRows(t *testing.T) {
+	coffee := Transaction{Source: "citi", Account: "citi", Date: "2026-02-04", Amount: 5.75, Name: "STARBUCKS"}
+	preset := Transaction{Source: "ofx", Name: "X", Fingerprint: "fitid-1"}
+	txns := []Transaction{coffee, coffee, preset}
+
+	AssignFingerprints(txns)
+
+	assert.Equal(t, Fingerprint(coffee, 0), txns[0].Fingerprint)
+	assert.Equal(t, Fingerprint(coffee, 1), txns[1].Fingerprint)
+	assert.Equal(t, "fitid-1", txns[2].Fingerprint, "provider-supplied fingerprints are kept")
+}
@@ -0,0 +1,240 @@
+package model
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const (
+	ImportBatchRunning   = "running"
+	ImportBatchCompleted = "completed"
+	ImportBatchFailed    = "failed"
+)
+
+// ImportBatch records one run of a provider over one statement file.
+type ImportBatch struct {
+	ID         int
+	FileName   string
+	Provider   string
+	FileHash   string
+	Inserted   int
+	Duplicates int
+	Rejected   int
+	// Reconciled counts posted rows that replaced a stored pending row.
+	Reconciled int
+	Status     string
+	Error      sql.NullString
+	CreatedAt  string
+	RevertedAt sql.NullString
+}
+
+// Reverted reports whether the batch's transactions have been undone.
+func (b ImportBatch) Reverted() bool {
+	return b.RevertedAt.Valid
+}
+
+var ErrImportBatchNotRevertible = errors.New("only completed, unreverted import batches can be reverted")
+
+func CreateImportBatch(conn Querier, batch ImportBatch) (int, error) {
+	queryStr := "INSERT INTO import_batches(file_name, provider, file_hash, inserted, duplicates, rejected, status, error, created_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id"
+	args := []any{
+		batch.FileName,
+		batch.Provider,
+		batch.FileHash,
+		batch.Inserted,
+		batch.Duplicates,
+		batch.Rejected,
+		batch.Status,
+		batch.Error,
+		time.Now().UTC().Format(time.RFC3339),
+	}
+
+	var lastInsertID int
+	err := conn.QueryRow(
+		queryStr,
+		args...,
+	).Scan(&lastInsertID)
+	if err != nil {
+		return 0, err
+	}
+
+	return lastInsertID, nil
+}
+
+type FinishImportBatchParams struct {
+	Inserted   int
+	Duplicates int
+	Rejected   int
+	Reconciled int
+	Status     string
+	Error      sql.NullString
+}
+
+// FinishImportBatch stores the final counts and status of a running batch.
+func FinishImportBatch(conn Querier, ID int, params FinishImportBatchParams) error {
+	queryStr := "UPDATE import_batches SET inserted = ?, duplicates = ?, rejected = ?, reconciled = ?, status = ?, error = ? WHERE id = ?"
+
+	_, err := conn.Exec(
+		queryStr,
+		params.Inserted,
+		params.Duplicates,
+		params.Rejected,
+		params.Reconciled,
+		params.Status,
+		params.Error,
+		ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func GetImportBatches(conn *sql.DB) ([]ImportBatch, error) {
+	rows, err := conn.Query(
+		"SELECT id, file_name, provider, file_hash, inserted, duplicates, rejected, reconciled, status, error, created_at, reverted_at FROM import_batches ORDER BY id DESC",
+	)
+	if err != nil {
+		return []ImportBatch{}, err
+	}
+	defer rows.Close()
+
+	batches := []ImportBatch{}
+	for rows.Next() {
+		batch := ImportBatch{}
+		if err := rows.Scan(
+			&batch.ID,
+			&batch.FileName,
+			&batch.Provider,
+			&batch.FileHash,
+			&batch.Inserted,
+			&batch.Duplicates,
+			&batch.Rejected,
+			&batch.Reconciled,
+			&batch.Status,
+			&batch.Error,
+			&batch.CreatedAt,
+			&batch.RevertedAt,
+		); err != nil {
+			return []ImportBatch{}, err
+		}
+
+		batches = append(batches, batch)
+	}
+
+	return batches, rows.Err()
+}
+
+func GetImportBatch(conn Querier, ID string) (ImportBatch, error) {
+	queryStr := "SELECT id, file_name, provider, file_hash, inserted, duplicates, rejected, reconciled, status, error, created_at, reverted_at FROM import_batches WHERE id = ?"
+
+	batch := ImportBatch{}
+	err := conn.QueryRow(
+		queryStr,
+		ID,
+	).Scan(
+		&batch.ID,
+		&batch.FileName,
+		&batch.Provider,
+		&batch.FileHash,
+		&batch.Inserted,
+		&batch.Duplicates,
+		&batch.Rejected,
+		&batch.Reconciled,
+		&batch.Status,
+		&batch.Error,
+		&batch.CreatedAt,
+		&batch.RevertedAt,
+	)
+	if err != nil {
+		return ImportBatch{}, err
+	}
+
+	return batch, nil
+}
+
+// GetImportBatchTransactions returns every transaction a batch created,
+// including ones in ignored categories, so the batch page shows exactly what a
+// revert would remove.
+func GetImportBatchTransactions(conn *sql.DB, batchID string) ([]Transaction, error) {
+	rows, err := conn.Query(
+		"SELECT t.id, name, amount, date, account, source, description, c.id, c.label as category, is_reimbursement FROM transactions as t LEFT JOIN categories as c ON category_id = c.id WHERE t.batch_id = ? ORDER BY date, name",
+		batchID,
+	)
+	if err != nil {
+		return []Transaction{}, err
+	}
+	defer rows.Close()
+
+	transactions := []Transaction{}
+	for rows.Next() {
+		transaction := Transaction{}
+		if err := rows.Scan(
+			&transaction.ID,
+			&transaction.Name,
+			&transaction.Amount,
+			&transaction.Date,
+			&transaction.Account,
+			&transaction.Source,
+			&transaction.Description,
+			&transaction.CategoryID,
+			&transaction.CustomCategory,
+			&transaction.IsReimbursement,
+		); err != nil {
+			return []Transaction{}, err
+		}
+
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, rows.Err()
+}
+
+// RevertImportBatch deletes every transaction the batch inserted or posted and
+// marks the batch reverted, all in one SQL transaction. Rows it inserted as
+// pending that a later import has since posted belong to that import and are
+// kept. It returns how many transactions were removed.
+func RevertImportBatch(conn *sql.DB, ID string) (int, error) {
+	tx, err := conn.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	batch, err := GetImportBatch(tx, ID)
+	if err != nil {
+		return 0, err
+	}
+
+	if batch.Status != ImportBatchCompleted || batch.Reverted() {
+		return 0, ErrImportBatchNotRevertible
+	}
+
+	// Splits, transfers and reimbursement links cascade with their rows.
+	res, err := tx.Exec("DELETE FROM transactions WHERE COALESCE(posted_batch_id, batch_id) = ?", batch.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(
+		"UPDATE import_batches SET reverted_at = ? WHERE id = ?",
+		time.Now().UTC().Format(time.RFC3339),
+		batch.ID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return int(deleted), nil
+}
@@ -0,0 +1,110 @@
+package model
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Notification is one entry in the alert feed. DedupeKey identifies what it
+// is about, so the same thing is only ever notified once. Link is a page to
+// open from the feed, possibly empty.
+type Notification struct {
+	ID        int
+	Kind      string
+	DedupeKey string
+	Message   string
+	Link      string
+	CreatedAt string
+	ReadAt    sql.NullString
+}
+
+// CreateNotification stores n unless a notification with its DedupeKey
+// already exists, and reports whether it was stored.
+func CreateNotification(conn Querier, n Notification) (bool, error) {
+	res, err := conn.Exec(
+		"INSERT INTO notifications(kind, dedupe_key, message, link, created_at) VALUES(?, ?, ?, ?, ?) ON CONFLICT(dedupe_key) DO NOTHING",
+		n.Kind,
+		n.DedupeKey,
+		n.Message,
+		n.Link,
+		time.Now().UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// GetNotifications returns up to limit notifications, newest first.
+func GetNotifications(conn *sql.DB, limit int) ([]Notification, error) {
+	rows, err := conn.Query(
+		"SELECT id, kind, dedupe_key, message, link, created_at, read_at FROM notifications ORDER BY id DESC LIMIT ?",
+		limit,
+	)
+	if err != nil {
+		return []Notification{}, err
+	}
+	defer rows.Close()
+
+	notifications := []Notification{}
+	for rows.Next() {
+		n := Notification{}
+		if err := rows.Scan(&n.ID, &n.Kind, &n.DedupeKey, &n.Message, &n.Link, &n.CreatedAt, &n.ReadAt); err != nil {
+			return []Notification{}, err
+		}
+		notifications = append(notifications, n)
+	}
+
+	return notifications, rows.Err()
+}
+
+func CountUnreadNotifications(conn *sql.DB) (int, error) {
+	var count int
+	err := conn.QueryRow("SELECT COUNT(*) FROM notifications WHERE read_at IS NULL").Scan(&count)
+	return count, err
+}
+
+// MarkNotificationsRead marks the notifications with the given IDs read, or
+// every unread notification when IDs is empty.
+func MarkNotificationsRead(conn *sql.DB, IDs []int) error {
+	queryStr := "UPDATE notifications SET read_at = ? WHERE read_at IS NULL"
+	args := []any{time.Now().UTC().Format(time.RFC3339)}
+
+	if len(IDs) > 0 {
+		queryStr += " AND id IN (" + strings.TrimSuffix(strings.Repeat("?,", len(IDs)), ",") + ")"
+		for _, id := range IDs {
+			args = append(args, id)
+		}
+	}
+
+	_, err := conn.Exec(queryStr, args...)
+	return err
+}
+
+// NotifySince returns when alerts started going to notifiers, recording now
+// the first time it is asked. first reports whether that was this call.
+func NotifySince(conn *sql.DB, now time.Time) (since time.Time, first bool, err error) {
+	res, err := conn.Exec(
+		"INSERT INTO notify_since(id, since) VALUES(1, ?) ON CONFLICT(id) DO NOTHING",
+		now.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	var sinceStr string
+	if err := conn.QueryRow("SELECT since FROM notify_since WHERE id = 1").Scan(&sinceStr); err != nil {
+		return time.Time{}, false, err
+	}
+
+	since, err = time.Parse(time.RFC3339, sinceStr)
+	return since, affected > 0, err
+}
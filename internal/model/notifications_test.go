@@ -0,0 +1,62 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifications(t *testing.T) {
+	db := testutil.NewDB(t)
+
+	for _, key := range []string{"a", "b", "c"} {
+		created, err := CreateNotification(db, Notification{Kind: "test", DedupeKey: key, Message: "about " + key})
+		require.NoError(t, err)
+		assert.True(t, created)
+	}
+
+	created, err := CreateNotification(db, Notification{Kind: "test", DedupeKey: "a", Message: "again"})
+	require.NoError(t, err)
+	assert.False(t, created, "dedupe key already notified")
+
+	notifications, err := GetNotifications(db, 2)
+	require.NoError(t, err)
+	require.Len(t, notifications, 2)
+	assert.Equal(t, "about c", notifications[0].Message)
+	assert.Equal(t, "about b", notifications[1].Message)
+
+	require.NoError(t, MarkNotificationsRead(db, []int{notifications[0].ID}))
+	unread, err := CountUnreadNotifications(db)
+	require.NoError(t, err)
+	assert.Equal(t, 2, unread)
+
+	require.NoError(t, MarkNotificationsRead(db, nil))
+	unread, err = CountUnreadNotifications(db)
+	require.NoError(t, err)
+	assert.Equal(t, 0, unread)
+
+	notifications, err = GetNotifications(db, 10)
+	require.NoError(t, err)
+	for _, n := range notifications {
+		assert.True(t, n.ReadAt.Valid)
+	}
+}
+
+func TestNotifySinceIsSetOnce(t *testing.T) {
+	db := testutil.NewDB(t)
+	first := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	since, isFirst, err := NotifySince(db, first)
+	require.NoError(t, err)
+	assert.True(t, isFirst)
+	assert.True(t, first.Equal(since))
+
+	since, isFirst, err = NotifySince(db, first.AddDate(0, 1, 0))
+	require.NoError(t, err)
+	assert.False(t, isFirst)
+	assert.True(t, first.Equal(since), "kept from the first call")
+}
@@ -0,0 +1,187 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
+
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+// Cursor marks a row in a sorted listing by its value in the order column and
+// its id, which breaks ties. Column is kept so a cursor from one sort isn't
+// applied to another.
+type Cursor struct {
+	Column string `json:"c"`
+	Value  any    `json:"v"`
+	ID     string `json:"id"`
+}
+
+// Encode returns c as an opaque token for a query string.
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor reads a token from Cursor.Encode.
+func DecodeCursor(token string) (Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	c := Cursor{}
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	switch c.Value.(type) {
+	case string, float64:
+	default:
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+func cursorFor(column string, t Transaction) Cursor {
+	c := Cursor{Column: column, ID: t.ID}
+	switch column {
+	case "date":
+		c.Value = t.Date
+	case "name":
+		c.Value = t.Name
+	case "relevance":
+		c.Value = t.relevance
+	default:
+		c.Value = t.Amount
+	}
+	return c
+}
+
+func reverseDirection(direction string) string {
+	if direction == "ASC" {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// PageRequest asks for Size rows after the After cursor, or before the
+// Before cursor. With neither set it asks for the first page.
+type PageRequest struct {
+	Size   int
+	After  string
+	Before string
+}
+
+// Page is one page of a transaction listing. Next and Prev are cursors for
+// the neighbouring pages, empty at either end; Total counts every matching
+// transaction, not just this page.
+type Page struct {
+	Transactions []Transaction
+	Size         int
+	Total        int
+	Next         string
+	Prev         string
+}
+
+// QueryTransactionsPage lists one page of the transactions matching filters,
+// sorted by filters.OrderBy or, without one, newest first. filters.Limit is
+// ignored in favour of req.Size, which is clamped to MaxPageSize.
+func QueryTransactionsPage(conn *sql.DB, filters QueryTransactionsFilters, req PageRequest) (Page, error) {
+	size := req.Size
+	if size <= 0 {
+		size = DefaultPageSize
+	}
+	size = min(size, MaxPageSize)
+
+	if filters.OrderBy == "" {
+		filters.OrderBy = "date"
+		filters.OrderDirection = "DESC"
+	}
+	column, _ := orderColumn(filters)
+
+	var (
+		cursor   *Cursor
+		backward bool
+	)
+	for _, token := range []string{req.After, req.Before} {
+		if token == "" {
+			continue
+		}
+		c, err := DecodeCursor(token)
+		if err != nil {
+			return Page{Size: size}, err
+		}
+		if c.Column != column {
+			return Page{Size: size}, ErrInvalidCursor
+		}
+		cursor = &c
+		backward = token == req.Before
+		break
+	}
+
+	total, err := CountTransactions(conn, filters)
+	if err != nil {
+		return Page{Size: size}, err
+	}
+
+	// One extra row says whether there is a page beyond this one.
+	filters.Limit = size + 1
+	transactions, err := queryTransactions(conn, filters, cursor, backward)
+	if err != nil {
+		return Page{Size: size}, err
+	}
+
+	more := len(transactions) > size
+	if more {
+		transactions = transactions[:size]
+	}
+	if backward {
+		for i, j := 0, len(transactions)-1; i < j; i, j = i+1, j-1 {
+			transactions[i], transactions[j] = transactions[j], transactions[i]
+		}
+	}
+
+	page := Page{Transactions: transactions, Size: size, Total: total}
+	if len(transactions) == 0 {
+		return page, nil
+	}
+
+	first := cursorFor(column, transactions[0]).Encode()
+	last := cursorFor(column, transactions[len(transactions)-1]).Encode()
+	switch {
+	case backward:
+		page.Next = last
+		if more {
+			page.Prev = first
+		}
+	default:
+		if more {
+			page.Next = last
+		}
+		if cursor != nil {
+			page.Prev = first
+		}
+	}
+
+	return page, nil
+}
+
+// CountTransactions counts the transactions matching filters.
+func CountTransactions(conn *sql.DB, filters QueryTransactionsFilters) (int, error) {
+	queryStr := "SELECT COUNT(*) FROM transactions AS t LEFT JOIN categories AS c ON t.category_id = c.id"
+	args := []any{}
+
+	queryStr, args = buildWhere(queryStr, args, filters, "t.amount")
+
+	var count int
+	err := conn.QueryRow(queryStr, args...).Scan(&count)
+	return count, err
+}
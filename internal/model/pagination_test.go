@@ -0,0 +1,87 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pageIDs(p Page) []string {
+	ids := []string{}
+	for _, tx := range p.Transactions {
+		ids = append(ids, tx.ID)
+	}
+	return ids
+}
+
+func TestQueryTransactionsPage(t *testing.T) {
+	db := testutil.NewDB(t)
+	// Seven rows, with ties on both date and amount so the id has to break them.
+	for i := range 7 {
+		require.NoError(t, CreateTransaction(db, Transaction{
+			ID:      fmt.Sprintf("tx-%d", i),
+			Name:    "SHOP",
+			Amount:  float64(10 * (i / 2)),
+			Date:    fmt.Sprintf("2026-03-0%d", 1+i/3),
+			Source:  "citi",
+			Account: "citi",
+		}))
+	}
+
+	filters := QueryTransactionsFilters{OrderBy: "amount", OrderDirection: "ASC"}
+	first, err := QueryTransactionsPage(db, filters, PageRequest{Size: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tx-0", "tx-1", "tx-2"}, pageIDs(first))
+	assert.Equal(t, 7, first.Total)
+	assert.Empty(t, first.Prev)
+	require.NotEmpty(t, first.Next)
+
+	second, err := QueryTransactionsPage(db, filters, PageRequest{Size: 3, After: first.Next})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tx-3", "tx-4", "tx-5"}, pageIDs(second))
+	require.NotEmpty(t, second.Prev)
+
+	last, err := QueryTransactionsPage(db, filters, PageRequest{Size: 3, After: second.Next})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tx-6"}, pageIDs(last))
+	assert.Empty(t, last.Next)
+
+	back, err := QueryTransactionsPage(db, filters, PageRequest{Size: 3, Before: last.Prev})
+	require.NoError(t, err)
+	assert.Equal(t, pageIDs(second), pageIDs(back))
+	assert.NotEmpty(t, back.Next)
+
+	back, err = QueryTransactionsPage(db, filters, PageRequest{Size: 3, Before: back.Prev})
+	require.NoError(t, err)
+	assert.Equal(t, pageIDs(first), pageIDs(back))
+	assert.Empty(t, back.Prev, "first page")
+
+	// Without a sort the newest come first.
+	newest, err := QueryTransactionsPage(db, QueryTransactionsFilters{}, PageRequest{Size: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tx-6", "tx-5"}, pageIDs(newest))
+}
+
+func TestQueryTransactionsPageRejectsBadCursors(t *testing.T) {
+	db := testutil.NewDB(t)
+	require.NoError(t, CreateTransaction(db, Transaction{ID: "a", Name: "SHOP", Amount: 1, Date: "2026-03-01", Source: "citi", Account: "citi"}))
+	require.NoError(t, CreateTransaction(db, Transaction{ID: "b", Name: "SHOP", Amount: 2, Date: "2026-03-02", Source: "citi", Account: "citi"}))
+
+	page, err := QueryTransactionsPage(db, QueryTransactionsFilters{OrderBy: "date"}, PageRequest{Size: 1})
+	require.NoError(t, err)
+	require.NotEmpty(t, page.Next)
+
+	_, err = QueryTransactionsPage(db, QueryTransactionsFilters{OrderBy: "amount"}, PageRequest{After: page.Next})
+	assert.ErrorIs(t, err, ErrInvalidCursor, "cursor from another sort")
+
+	_, err = QueryTransactionsPage(db, QueryTransactionsFilters{}, PageRequest{After: "not a cursor"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	page, err = QueryTransactionsPage(db, QueryTransactionsFilters{}, PageRequest{Size: MaxPageSize + 1})
+	require.NoError(t, err)
+	assert.Equal(t, MaxPageSize, page.Size)
+}
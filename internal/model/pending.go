@@ -0,0 +1,251 @@
+package model
+
+import (
+	"database/sql"
+	"math"
+	"strconv"
+	"time"
+
+	"fin-web/internal/util"
+)
+
+// ReconcileTolerance bounds how far a posted row may drift from the pending
+// row it settles. Posting happens on or up to Days after the pending date,
+// and the amount may change by up to AmountPct of the pending amount, as a
+// tip does. Merchants must normalize to the same key.
+type ReconcileTolerance struct {
+	Days      int
+	AmountPct float64
+}
+
+// DefaultReconcileTolerance covers card authorizations, which usually post
+// within a few days and may gain a tip.
+var DefaultReconcileTolerance = ReconcileTolerance{Days: 5, AmountPct: 0.25}
+
+func (tol ReconcileTolerance) amountMatches(pending float64, posted float64) bool {
+	return math.Abs(posted-pending) <= math.Abs(pending)*tol.AmountPct+0.005
+}
+
+// FindPendingMatch returns the stored pending row that posted most likely
+// settles: same account and merchant, within tolerance, closest in date and
+// then amount. Rows from posted's own import batch are left out, since one
+// export doesn't list a charge as both pending and posted.
+func FindPendingMatch(conn Querier, posted Transaction, tol ReconcileTolerance) (Transaction, bool, error) {
+	rows, err := conn.Query(
+		"SELECT id, name, amount, date, account, source FROM transactions WHERE status = ? AND account = ? AND (? IS NULL OR batch_id IS NOT ?) AND date <= ? AND date >= date(?, ?) AND (amount > 0) = (? > 0) ORDER BY date DESC, ABS(amount - ?), id",
+		TransactionPending,
+		posted.Account,
+		posted.BatchID,
+		posted.BatchID,
+		posted.Date,
+		posted.Date,
+		"-"+strconv.Itoa(tol.Days)+" days",
+		posted.Amount,
+		posted.Amount,
+	)
+	if err != nil {
+		return Transaction{}, false, err
+	}
+	defer rows.Close()
+
+	merchant := util.NormalizeMerchant(posted.Name)
+	for rows.Next() {
+		pending := Transaction{}
+		if err := rows.Scan(
+			&pending.ID,
+			&pending.Name,
+			&pending.Amount,
+			&pending.Date,
+			&pending.Account,
+			&pending.Source,
+		); err != nil {
+			return Transaction{}, false, err
+		}
+
+		if tol.amountMatches(pending.Amount, posted.Amount) && util.NormalizeMerchant(pending.Name) == merchant {
+			pending.Status = TransactionPending
+			return pending, true, nil
+		}
+	}
+
+	return Transaction{}, false, rows.Err()
+}
+
+// HasPostedMatch reports whether a pending row has already posted, so an
+// export that still lists it doesn't bring it back. The same tolerance
+// applies as in FindPendingMatch, seen from the other side.
+func HasPostedMatch(conn Querier, pending Transaction, tol ReconcileTolerance) (bool, error) {
+	rows, err := conn.Query(
+		"SELECT name, amount FROM transactions WHERE status = ? AND account = ? AND (? IS NULL OR batch_id IS NOT ?) AND date >= ? AND date <= date(?, ?)",
+		TransactionPosted,
+		pending.Account,
+		pending.BatchID,
+		pending.BatchID,
+		pending.Date,
+		pending.Date,
+		"+"+strconv.Itoa(tol.Days)+" days",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	merchant := util.NormalizeMerchant(pending.Name)
+	for rows.Next() {
+		var (
+			name   string
+			amount float64
+		)
+		if err := rows.Scan(&name, &amount); err != nil {
+			return false, err
+		}
+
+		if tol.amountMatches(pending.Amount, amount) && util.NormalizeMerchant(name) == merchant {
+			return true, nil
+		}
+	}
+
+	return false, rows.Err()
+}
+
+// ReconcilePending settles a stored pending row with its posted counterpart.
+// The row keeps its ID, category, splits and links, and takes the posted
+// name, date, amount and fingerprint. It records posted's import batch, so it
+// is reverted with the import that posted it rather than the one that saw it
+// pending. A category the pending row lacked is taken from posted, splits are
+// rescaled to the posted amount, and reimbursement links are cut back to fit
+// it.
+func ReconcilePending(conn Querier, pending Transaction, posted Transaction) error {
+	if err := rescaleSplits(conn, pending.ID, pending.Amount, posted.Amount); err != nil {
+		return err
+	}
+
+	if err := clampReimbursements(conn, pending.ID, posted.Amount); err != nil {
+		return err
+	}
+
+	var fingerprint sql.NullString
+	if posted.Fingerprint != "" {
+		fingerprint = sql.NullString{Valid: true, String: posted.Fingerprint}
+	}
+
+	_, err := conn.Exec(
+		"UPDATE transactions SET name = ?, date = ?, amount = ?, fingerprint = ?, status = ?, posted_batch_id = ?, category_id = COALESCE(category_id, ?), rule_id = CASE WHEN category_id IS NULL THEN ? ELSE rule_id END, category_source = CASE WHEN category_id IS NULL THEN ? ELSE category_source END, category_assigned_at = CASE WHEN category_id IS NULL AND ? IS NOT NULL THEN ? ELSE category_assigned_at END WHERE id = ? AND status = ?",
+		posted.Name,
+		posted.Date,
+		posted.Amount,
+		fingerprint,
+		TransactionPosted,
+		posted.BatchID,
+		posted.CategoryID,
+		posted.RuleID,
+		posted.CategorySource,
+		posted.CategoryID,
+		time.Now().UTC().Format(time.RFC3339),
+		pending.ID,
+		TransactionPending,
+	)
+	return err
+}
+
+// rescaleSplits keeps a transaction's splits summing to its amount when the
+// amount changes, proportionally and to the cent; the last split takes up the
+// rounding.
+func rescaleSplits(conn Querier, transactionID string, from float64, to float64) error {
+	if from == to || from == 0 {
+		return nil
+	}
+
+	rows, err := conn.Query("SELECT id, amount FROM transaction_splits WHERE transaction_id = ? ORDER BY id", transactionID)
+	if err != nil {
+		return err
+	}
+
+	type split struct {
+		id     int
+		amount float64
+	}
+	splits := []split{}
+	for rows.Next() {
+		s := split{}
+		if err := rows.Scan(&s.id, &s.amount); err != nil {
+			rows.Close()
+			return err
+		}
+		splits = append(splits, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	remaining := to
+	for i, s := range splits {
+		amount := math.Round(s.amount*to/from*100) / 100
+		if i == len(splits)-1 {
+			amount = math.Round(remaining*100) / 100
+		}
+		remaining -= amount
+
+		if _, err := conn.Exec("UPDATE transaction_splits SET amount = ? WHERE id = ?", amount, s.id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// clampReimbursements scales down the reimbursement links on a transaction
+// whose amount no longer covers them, proportionally and to the cent, so
+// netting can't take it past zero. Links that round to nothing are removed.
+func clampReimbursements(conn Querier, transactionID string, amount float64) error {
+	rows, err := conn.Query("SELECT id, amount FROM reimbursements WHERE expense_id = ? OR reimbursement_id = ? ORDER BY id", transactionID, transactionID)
+	if err != nil {
+		return err
+	}
+
+	type link struct {
+		id     int
+		amount float64
+	}
+	links := []link{}
+	total := 0.0
+	for rows.Next() {
+		l := link{}
+		if err := rows.Scan(&l.id, &l.amount); err != nil {
+			rows.Close()
+			return err
+		}
+		links = append(links, l)
+		total += l.amount
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	limit := math.Abs(amount)
+	if total <= limit+0.005 {
+		return nil
+	}
+
+	remaining := limit
+	for i, l := range links {
+		clamped := math.Round(l.amount*limit/total*100) / 100
+		if i == len(links)-1 {
+			clamped = math.Round(remaining*100) / 100
+		}
+		remaining -= clamped
+
+		if clamped < 0.005 {
+			_, err = conn.Exec("DELETE FROM reimbursements WHERE id = ?", l.id)
+		} else {
+			_, err = conn.Exec("UPDATE reimbursements SET amount = ? WHERE id = ?", clamped, l.id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package model
+
+import (
+	"database/sql"
+	"strconv"
+	"testing"
+
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seedPending(t *testing.T, db Querier, id string, name string, amount float64, date string) {
+	t.Helper()
+	_, err := db.Exec(
+		"INSERT INTO transactions(id, name, amount, date, source, account, status) VALUES(?, ?, ?, ?, 'bank', 'bank', ?)",
+		id, name, amount, date, TransactionPending,
+	)
+	require.NoError(t, err)
+}
+
+func TestFindPendingMatchTolerance(t *testing.T) {
+	db := testutil.NewDB(t)
+	seedPending(t, db, "dinner", "TST* OAK ROOM 0042", 80, "2026-03-01")
+
+	posted := func(amount float64, date string) Transaction {
+		return Transaction{Name: "TST* OAK ROOM", Amount: amount, Date: date, Source: "bank", Account: "bank"}
+	}
+
+	cases := []struct {
+		name  string
+		tx    Transaction
+		match bool
+	}{
+		{"same day with tip", posted(96, "2026-03-01"), true},
+		{"posts within window", posted(80, "2026-03-06"), true},
+		{"posts too late", posted(80, "2026-03-07"), false},
+		{"posts before pending", posted(80, "2026-02-28"), false},
+		{"amount too far off", posted(120, "2026-03-02"), false},
+		{"refund", posted(-80, "2026-03-02"), false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pending, ok, err := FindPendingMatch(db, tc.tx, DefaultReconcileTolerance)
+			require.NoError(t, err)
+			assert.Equal(t, tc.match, ok)
+			if ok {
+				assert.Equal(t, "dinner", pending.ID)
+			}
+		})
+	}
+
+	other := posted(80, "2026-03-02")
+	other.Name = "OAK TREE NURSERY"
+	_, ok, err := FindPendingMatch(db, other, DefaultReconcileTolerance)
+	require.NoError(t, err)
+	assert.False(t, ok, "a different merchant")
+}
+
+func TestReconcilePendingRescalesSplits(t *testing.T) {
+	db := testutil.NewDB(t)
+	dining := seedTypedCategory(t, db, "dining", 1, "fun")
+	drinks := seedTypedCategory(t, db, "drinks", 2, "fun")
+	seedPending(t, db, "dinner", "OAK ROOM", 90, "2026-03-01")
+	require.NoError(t, SaveSplits(db, "dinner", []Split{split(60, dining), split(30, drinks)}))
+
+	pending, err := GetTransaction(db, "dinner")
+	require.NoError(t, err)
+	require.True(t, pending.Pending())
+
+	require.NoError(t, ReconcilePending(db, pending, Transaction{Name: "OAK ROOM", Amount: 100, Date: "2026-03-03", Fingerprint: "posted"}))
+
+	tx, err := GetTransaction(db, "dinner")
+	require.NoError(t, err)
+	assert.False(t, tx.Pending())
+	assert.Equal(t, 100.0, tx.Amount)
+
+	splits, err := GetSplits(db, "dinner")
+	require.NoError(t, err)
+	require.Len(t, splits, 2)
+	assert.Equal(t, 66.67, splits[0].Amount)
+	assert.Equal(t, 33.33, splits[1].Amount)
+	assert.True(t, SplitsSum(100, splits))
+}
+
+func TestReconcilePendingMovesToPostedBatch(t *testing.T) {
+	db := testutil.NewDB(t)
+	pendingBatch, err := CreateImportBatch(db, ImportBatch{FileName: "pending.csv", Provider: "bank", Status: ImportBatchCompleted})
+	require.NoError(t, err)
+	postedBatch, err := CreateImportBatch(db, ImportBatch{FileName: "posted.csv", Provider: "bank", Status: ImportBatchCompleted})
+	require.NoError(t, err)
+
+	seedPending(t, db, "dinner", "OAK ROOM", 90, "2026-03-01")
+	_, err = db.Exec("UPDATE transactions SET batch_id = ? WHERE id = 'dinner'", pendingBatch)
+	require.NoError(t, err)
+	pending, err := GetTransaction(db, "dinner")
+	require.NoError(t, err)
+
+	require.NoError(t, ReconcilePending(db, pending, Transaction{
+		Name: "OAK ROOM", Amount: 100, Date: "2026-03-03", Fingerprint: "posted",
+		BatchID: sql.NullInt64{Valid: true, Int64: int64(postedBatch)},
+	}))
+
+	// The pending import no longer owns the row that has posted.
+	deleted, err := RevertImportBatch(db, strconv.Itoa(pendingBatch))
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+
+	deleted, err = RevertImportBatch(db, strconv.Itoa(postedBatch))
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+}
+
+func TestReconcilePendingClampsReimbursements(t *testing.T) {
+	db := testutil.NewDB(t)
+	income := seedTypedCategory(t, db, "venmo", 1, "income")
+	dining := seedTypedCategory(t, db, "dining", 2, "fun")
+	seedPending(t, db, "dinner", "OAK ROOM", 100, "2026-03-01")
+	_, err := db.Exec("UPDATE transactions SET category_id = ? WHERE id = 'dinner'", dining)
+	require.NoError(t, err)
+	seedTransaction(t, db, "roommate", -80, "2026-03-02", income)
+	_, err = LinkReimbursement(db, "roommate", "dinner", 80)
+	require.NoError(t, err)
+
+	// The pending charge was held high and posts below what was paid back.
+	pending, err := GetTransaction(db, "dinner")
+	require.NoError(t, err)
+	require.NoError(t, ReconcilePending(db, pending, Transaction{Name: "OAK ROOM", Amount: 70, Date: "2026-03-03", Fingerprint: "posted"}))
+
+	reimbursements, err := GetReimbursements(db, "dinner")
+	require.NoError(t, err)
+	require.Len(t, reimbursements, 1)
+	assert.Equal(t, 70.0, reimbursements[0].Amount)
+
+	expenses, err := SumTransactions(db, QueryTransactionsFilters{Type: "expenses"})
+	require.NoError(t, err)
+	assert.InDelta(t, 0, expenses, 1e-9, "netted to zero, not below")
+
+	in, err := SumTransactions(db, QueryTransactionsFilters{Type: "income"})
+	require.NoError(t, err)
+	assert.InDelta(t, -10, in, 1e-9)
+}
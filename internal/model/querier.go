@@ -0,0 +1,12 @@
+package model
+
+import "database/sql"
+
+// Querier is the subset of *sql.DB that model functions need. *sql.Tx satisfies
+// it too, so functions that accept a Querier can run inside a caller's
+// transaction.
+type Querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
@@ -2,22 +2,35 @@ package model
 
 import (
 	"database/sql"
+	"time"
 
 	"fin-web/internal/recurring"
 )
 
 // RecurringCandidates returns every expense charge eligible for recurring
 // detection: positive amounts (the app stores expenses positive), excluding
-// reimbursements and ignored categories. Uncategorized rows are kept, since
-// subscriptions are frequently uncategorized.
+// reimbursements, transfers and ignored categories. Uncategorized rows are
+// kept, since subscriptions are frequently uncategorized.
 func RecurringCandidates(conn *sql.DB) ([]recurring.Charge, error) {
+	return recurringCharges(conn, "t.amount > 0", "t.amount")
+}
+
+// RecurringIncomeCandidates returns income eligible for recurring detection,
+// such as paychecks, with amounts flipped positive so recurring.Detect reads
+// them the way it reads charges. Exclusions match RecurringCandidates.
+func RecurringIncomeCandidates(conn *sql.DB) ([]recurring.Charge, error) {
+	return recurringCharges(conn, "t.amount < 0", "-t.amount")
+}
+
+func recurringCharges(conn *sql.DB, sign string, amount string) ([]recurring.Charge, error) {
 	rows, err := conn.Query(`
-		SELECT t.name, t.amount, t.date
+		SELECT t.name, ` + amount + `, t.date
 		FROM transactions AS t
 		LEFT JOIN categories AS c ON t.category_id = c.id
-		WHERE t.amount > 0
+		WHERE ` + sign + `
 		  AND COALESCE(t.is_reimbursement, 0) = 0
 		  AND COALESCE(c.is_ignored, 0) = 0
+		  AND t.id NOT IN (` + linkedTransfers + `)
 		  AND t.date IS NOT NULL AND t.date != ''`)
 	if err != nil {
 		return nil, err
@@ -35,3 +48,45 @@ func RecurringCandidates(conn *sql.DB) ([]recurring.Charge, error) {
 
 	return charges, rows.Err()
 }
+
+// GetRecurringDecisions returns every saved decision keyed by merchant key,
+// ready for recurring.DetectWith.
+func GetRecurringDecisions(conn *sql.DB) (map[string]recurring.Decision, error) {
+	rows, err := conn.Query("SELECT merchant_key, status, display_name, merge_into, cadence FROM recurring_decisions")
+	if err != nil {
+		return map[string]recurring.Decision{}, err
+	}
+	defer rows.Close()
+
+	decisions := map[string]recurring.Decision{}
+	for rows.Next() {
+		var d recurring.Decision
+		if err := rows.Scan(&d.Key, &d.Status, &d.DisplayName, &d.MergeInto, &d.Cadence); err != nil {
+			return map[string]recurring.Decision{}, err
+		}
+		decisions[d.Key] = d
+	}
+
+	return decisions, rows.Err()
+}
+
+// SetRecurringDecision saves d over any earlier decision for d.Key. A
+// decision with nothing set is removed, returning the key to plain
+// detection.
+func SetRecurringDecision(conn *sql.DB, d recurring.Decision) error {
+	if d == (recurring.Decision{Key: d.Key}) {
+		_, err := conn.Exec("DELETE FROM recurring_decisions WHERE merchant_key = ?", d.Key)
+		return err
+	}
+
+	_, err := conn.Exec(
+		"INSERT INTO recurring_decisions(merchant_key, status, display_name, merge_into, cadence, updated_at) VALUES(?, ?, ?, ?, ?, ?) ON CONFLICT(merchant_key) DO UPDATE SET status = excluded.status, display_name = excluded.display_name, merge_into = excluded.merge_into, cadence = excluded.cadence, updated_at = excluded.updated_at",
+		d.Key,
+		d.Status,
+		d.DisplayName,
+		d.MergeInto,
+		d.Cadence,
+		time.Now().UTC().Format(time.RFC3339),
+	)
+	return err
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"testing"
+
+	"fin-web/internal/recurring"
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetRecurringDecision(t *testing.T) {
+	db := testutil.NewDB(t)
+
+	require.NoError(t, SetRecurringDecision(db, recurring.Decision{Key: "NETFLIX.COM", Status: recurring.DecisionConfirmed}))
+	require.NoError(t, SetRecurringDecision(db, recurring.Decision{Key: "ITUNES.COM", MergeInto: "APPLE.COM/BILL"}))
+	require.NoError(t, SetRecurringDecision(db, recurring.Decision{Key: "NETFLIX.COM", DisplayName: "Netflix", Cadence: "monthly"}))
+
+	decisions, err := GetRecurringDecisions(db)
+	require.NoError(t, err)
+	require.Len(t, decisions, 2)
+	assert.Equal(t, recurring.Decision{Key: "NETFLIX.COM", DisplayName: "Netflix", Cadence: "monthly"}, decisions["NETFLIX.COM"])
+	assert.Equal(t, "APPLE.COM/BILL", decisions["ITUNES.COM"].MergeInto)
+
+	// Clearing every field forgets the key.
+	require.NoError(t, SetRecurringDecision(db, recurring.Decision{Key: "ITUNES.COM"}))
+	decisions, err = GetRecurringDecisions(db)
+	require.NoError(t, err)
+	assert.NotContains(t, decisions, "ITUNES.COM")
+
+	// The table checks cadences too.
+	assert.Error(t, SetRecurringDecision(db, recurring.Decision{Key: "HULU", Cadence: "daily"}))
+}
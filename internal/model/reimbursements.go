@@ -0,0 +1,229 @@
+package model
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+)
+
+// ErrReimbursementAmount is returned when a link would pay back more than the
+// expense has outstanding or apply more than the reimbursement has left.
+var ErrReimbursementAmount = errors.New("amount is more than is left to reimburse")
+
+// ErrReimbursementSides is returned when the reimbursement isn't money in or
+// the expense isn't money out.
+var ErrReimbursementSides = errors.New("a reimbursement must be money in and the expense money out")
+
+// reimbursedAmounts selects, per linked transaction, how much its amount is
+// netted by: the total paid back on an expense, or the negated total applied
+// from a reimbursement. A transaction can't be both, as their signs differ.
+const reimbursedAmounts = "SELECT expense_id AS id, SUM(amount) AS netted FROM reimbursements GROUP BY expense_id UNION ALL SELECT reimbursement_id, -SUM(amount) FROM reimbursements GROUP BY reimbursement_id"
+
+// Reimbursement links part of a reimbursement to an expense it pays back.
+// Amount is positive.
+type Reimbursement struct {
+	ID            int
+	Reimbursement Transaction
+	Expense       Transaction
+	Amount        float64
+	CreatedAt     string
+}
+
+// OutstandingExpense is an expense with what has been paid back on it so far.
+type OutstandingExpense struct {
+	Transaction Transaction
+	Reimbursed  float64
+}
+
+// Outstanding is what is still to be paid back.
+func (e OutstandingExpense) Outstanding() float64 {
+	return e.Transaction.Amount - e.Reimbursed
+}
+
+// GetReimbursements returns the links on either side of a transaction, oldest
+// first.
+func GetReimbursements(conn *sql.DB, transactionID string) ([]Reimbursement, error) {
+	rows, err := conn.Query(
+		"SELECT r.id, r.amount, r.created_at, ri.id, ri.name, ri.amount, ri.date, ri.account, e.id, e.name, e.amount, e.date, e.account FROM reimbursements AS r JOIN transactions AS ri ON r.reimbursement_id = ri.id JOIN transactions AS e ON r.expense_id = e.id WHERE r.reimbursement_id = ? OR r.expense_id = ? ORDER BY r.id",
+		transactionID,
+		transactionID,
+	)
+	if err != nil {
+		return []Reimbursement{}, err
+	}
+	defer rows.Close()
+
+	reimbursements := []Reimbursement{}
+	for rows.Next() {
+		r := Reimbursement{}
+		if err := rows.Scan(
+			&r.ID,
+			&r.Amount,
+			&r.CreatedAt,
+			&r.Reimbursement.ID,
+			&r.Reimbursement.Name,
+			&r.Reimbursement.Amount,
+			&r.Reimbursement.Date,
+			&r.Reimbursement.Account,
+			&r.Expense.ID,
+			&r.Expense.Name,
+			&r.Expense.Amount,
+			&r.Expense.Date,
+			&r.Expense.Account,
+		); err != nil {
+			return []Reimbursement{}, err
+		}
+
+		reimbursements = append(reimbursements, r)
+	}
+
+	return reimbursements, rows.Err()
+}
+
+// AwaitingReimbursement returns the expenses marked as expecting
+// reimbursement that haven't been paid back in full, oldest first.
+func AwaitingReimbursement(conn *sql.DB) ([]OutstandingExpense, error) {
+	return queryOutstandingExpenses(
+		conn,
+		"WHERE t.expects_reimbursement = 1 ORDER BY t.date, t.id",
+	)
+}
+
+// ReimbursableExpenses returns expenses on or before date that haven't been
+// paid back in full, for linking to a reimbursement on that date. Expenses
+// marked as expecting reimbursement come first, then the most recent, up to
+// limit.
+func ReimbursableExpenses(conn *sql.DB, date string, limit int) ([]OutstandingExpense, error) {
+	return queryOutstandingExpenses(
+		conn,
+		"WHERE t.date <= ? ORDER BY t.expects_reimbursement DESC, t.date DESC, t.id LIMIT ?",
+		date,
+		limit,
+	)
+}
+
+func queryOutstandingExpenses(conn *sql.DB, where string, args ...any) ([]OutstandingExpense, error) {
+	rows, err := conn.Query(
+		"SELECT id, name, amount, date, account, source, description, expects_reimbursement, reimbursed FROM (SELECT t.*, COALESCE((SELECT SUM(r.amount) FROM reimbursements AS r WHERE r.expense_id = t.id), 0) AS reimbursed FROM transactions AS t WHERE t.amount > 0) AS t "+where,
+		args...,
+	)
+	if err != nil {
+		return []OutstandingExpense{}, err
+	}
+	defer rows.Close()
+
+	expenses := []OutstandingExpense{}
+	for rows.Next() {
+		e := OutstandingExpense{}
+		if err := rows.Scan(
+			&e.Transaction.ID,
+			&e.Transaction.Name,
+			&e.Transaction.Amount,
+			&e.Transaction.Date,
+			&e.Transaction.Account,
+			&e.Transaction.Source,
+			&e.Transaction.Description,
+			&e.Transaction.ExpectsReimbursement,
+			&e.Reimbursed,
+		); err != nil {
+			return []OutstandingExpense{}, err
+		}
+
+		// Amounts are floats, so anything under a cent counts as settled.
+		if e.Outstanding() < 0.005 {
+			continue
+		}
+
+		expenses = append(expenses, e)
+	}
+
+	return expenses, rows.Err()
+}
+
+// LinkReimbursement applies amount of a reimbursement to an expense and flags
+// the reimbursement as one. Neither side can be taken past its own amount; an
+// amount of zero applies as much as both sides allow.
+func LinkReimbursement(conn *sql.DB, reimbursementID string, expenseID string, amount float64) (int, error) {
+	tx, err := conn.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var reimbursement, applied float64
+	err = tx.QueryRow(
+		"SELECT amount, COALESCE((SELECT SUM(amount) FROM reimbursements WHERE reimbursement_id = ?), 0) FROM transactions WHERE id = ?",
+		reimbursementID,
+		reimbursementID,
+	).Scan(&reimbursement, &applied)
+	if err != nil {
+		return 0, err
+	}
+
+	var expense, reimbursed float64
+	err = tx.QueryRow(
+		"SELECT amount, COALESCE((SELECT SUM(amount) FROM reimbursements WHERE expense_id = ?), 0) FROM transactions WHERE id = ?",
+		expenseID,
+		expenseID,
+	).Scan(&expense, &reimbursed)
+	if err != nil {
+		return 0, err
+	}
+
+	if reimbursement >= 0 || expense <= 0 {
+		return 0, ErrReimbursementSides
+	}
+
+	left := min(-reimbursement-applied, expense-reimbursed)
+	if amount == 0 {
+		amount = math.Round(left*100) / 100
+	}
+	if amount < 0.005 || amount > left+0.005 {
+		return 0, ErrReimbursementAmount
+	}
+
+	var ID int
+	err = tx.QueryRow(
+		"INSERT INTO reimbursements(reimbursement_id, expense_id, amount, created_at) VALUES(?, ?, ?, ?) RETURNING id",
+		reimbursementID,
+		expenseID,
+		amount,
+		time.Now().UTC().Format(time.RFC3339),
+	).Scan(&ID)
+	if err != nil {
+		return 0, err
+	}
+
+	isReimbursement := true
+	err = UpdateTransaction(tx, reimbursementID, UpdateTransactionParams{IsReimbursement: &isReimbursement})
+	if err != nil {
+		return 0, err
+	}
+
+	return ID, tx.Commit()
+}
+
+// DeleteReimbursement removes a link on either side of a transaction. It
+// returns sql.ErrNoRows when the transaction has no such link.
+func DeleteReimbursement(conn *sql.DB, transactionID string, ID string) error {
+	res, err := conn.Exec(
+		"DELETE FROM reimbursements WHERE id = ? AND (reimbursement_id = ? OR expense_id = ?)",
+		ID,
+		transactionID,
+		transactionID,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package model
+
+import (
+	"database/sql"
+	"testing"
+
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkedReimbursementsNetTotals(t *testing.T) {
+	db := testutil.NewDB(t)
+	income := seedTypedCategory(t, db, "venmo", 1, "income")
+	dining := seedTypedCategory(t, db, "dining", 2, "fun")
+	seedTransaction(t, db, "dinner", 120, "2026-03-03", dining)
+	seedTransaction(t, db, "roommate", -80, "2026-03-05", income)
+
+	_, err := LinkReimbursement(db, "roommate", "dinner", 60)
+	require.NoError(t, err)
+
+	expenses, err := SumTransactions(db, QueryTransactionsFilters{Type: "expenses"})
+	require.NoError(t, err)
+	assert.InDelta(t, 60, expenses, 1e-9)
+
+	// The unapplied 20 is still income.
+	in, err := SumTransactions(db, QueryTransactionsFilters{Type: "income"})
+	require.NoError(t, err)
+	assert.InDelta(t, -20, in, 1e-9)
+
+	counts, err := CategoryCounts(db, QueryTransactionsFilters{Type: "expenses"})
+	require.NoError(t, err)
+	require.Len(t, counts, 1)
+	assert.InDelta(t, 60, counts[0].Value, 1e-9)
+
+	tx, err := GetTransaction(db, "roommate")
+	require.NoError(t, err)
+	assert.True(t, tx.IsReimbursement)
+
+	// Lists still show the full amounts.
+	txns, err := QueryTransactions(db, QueryTransactionsFilters{OrderBy: "amount"})
+	require.NoError(t, err)
+	assert.Equal(t, 120.0, txns[0].Amount)
+}
+
+func TestLinkedReimbursementNetsSplitsProportionally(t *testing.T) {
+	db := testutil.NewDB(t)
+	income := seedTypedCategory(t, db, "work", 1, "income")
+	travel := seedTypedCategory(t, db, "travel", 2, "fun")
+	dining := seedTypedCategory(t, db, "dining", 3, "fun")
+	seedTransaction(t, db, "trip", 400, "2026-03-03", travel)
+	seedTransaction(t, db, "expense-report", -200, "2026-03-20", income)
+	require.NoError(t, SaveSplits(db, "trip", []Split{split(300, travel), split(100, dining)}))
+
+	_, err := LinkReimbursement(db, "expense-report", "trip", 0)
+	require.NoError(t, err)
+
+	counts, err := CategoryCounts(db, QueryTransactionsFilters{Type: "expenses"})
+	require.NoError(t, err)
+	byLabel := map[string]float64{}
+	for _, c := range counts {
+		byLabel[c.Key] = c.Value
+	}
+	assert.InDelta(t, 150, byLabel["travel"], 1e-9)
+	assert.InDelta(t, 50, byLabel["dining"], 1e-9)
+}
+
+func TestLinkedReimbursementOnZeroAmount(t *testing.T) {
+	db := testutil.NewDB(t)
+	income := seedTypedCategory(t, db, "venmo", 1, "income")
+	dining := seedTypedCategory(t, db, "dining", 2, "fun")
+	seedTransaction(t, db, "dinner", 120, "2026-03-03", dining)
+	seedTransaction(t, db, "roommate", -80, "2026-03-05", income)
+
+	_, err := LinkReimbursement(db, "roommate", "dinner", 60)
+	require.NoError(t, err)
+
+	// An edit that zeroes the expense leaves its link behind.
+	_, err = db.Exec("UPDATE transactions SET amount = 0 WHERE id = 'dinner'")
+	require.NoError(t, err)
+
+	expenses, err := SumTransactions(db, QueryTransactionsFilters{Type: "expenses"})
+	require.NoError(t, err)
+	assert.Zero(t, expenses)
+
+	counts, err := CategoryCounts(db, QueryTransactionsFilters{})
+	require.NoError(t, err)
+	byLabel := map[string]float64{}
+	for _, c := range counts {
+		byLabel[c.Key] = c.Value
+	}
+	assert.Zero(t, byLabel["dining"])
+	assert.InDelta(t, -20, byLabel["venmo"], 1e-9)
+}
+
+func TestLinkReimbursementLimits(t *testing.T) {
+	db := testutil.NewDB(t)
+	income := seedTypedCategory(t, db, "venmo", 1, "income")
+	dining := seedTypedCategory(t, db, "dining", 2, "fun")
+	seedTransaction(t, db, "dinner", 50, "2026-03-03", dining)
+	seedTransaction(t, db, "lunch", 30, "2026-03-04", dining)
+	seedTransaction(t, db, "roommate", -60, "2026-03-05", income)
+
+	_, err := LinkReimbursement(db, "dinner", "lunch", 10)
+	require.ErrorIs(t, err, ErrReimbursementSides)
+
+	_, err = LinkReimbursement(db, "roommate", "dinner", 55)
+	require.ErrorIs(t, err, ErrReimbursementAmount, "more than the expense")
+
+	_, err = LinkReimbursement(db, "roommate", "dinner", 0)
+	require.NoError(t, err)
+
+	// Only 10 of the reimbursement is left for lunch.
+	_, err = LinkReimbursement(db, "roommate", "lunch", 20)
+	require.ErrorIs(t, err, ErrReimbursementAmount)
+	_, err = LinkReimbursement(db, "roommate", "lunch", 0)
+	require.NoError(t, err)
+
+	links, err := GetReimbursements(db, "roommate")
+	require.NoError(t, err)
+	require.Len(t, links, 2)
+	assert.Equal(t, 50.0, links[0].Amount)
+	assert.Equal(t, 10.0, links[1].Amount)
+	assert.Equal(t, "lunch", links[1].Expense.ID)
+
+	_, err = LinkReimbursement(db, "roommate", "missing", 0)
+	require.Error(t, err)
+
+	require.NoError(t, DeleteReimbursement(db, "lunch", "2"))
+	require.ErrorIs(t, DeleteReimbursement(db, "lunch", "2"), sql.ErrNoRows)
+}
+
+func TestAwaitingReimbursement(t *testing.T) {
+	db := testutil.NewDB(t)
+	income := seedTypedCategory(t, db, "work", 1, "income")
+	travel := seedTypedCategory(t, db, "travel", 2, "fun")
+	seedTransaction(t, db, "flight", 300, "2026-03-01", travel)
+	seedTransaction(t, db, "hotel", 200, "2026-03-02", travel)
+	seedTransaction(t, db, "souvenir", 20, "2026-03-02", travel)
+	seedTransaction(t, db, "expense-report", -350, "2026-03-20", income)
+
+	expects := true
+	for _, id := range []string{"flight", "hotel"} {
+		require.NoError(t, UpdateTransaction(db, id, UpdateTransactionParams{ExpectsReimbursement: &expects}))
+	}
+
+	_, err := LinkReimbursement(db, "expense-report", "flight", 0)
+	require.NoError(t, err)
+	_, err = LinkReimbursement(db, "expense-report", "hotel", 0)
+	require.NoError(t, err)
+
+	awaiting, err := AwaitingReimbursement(db)
+	require.NoError(t, err)
+	require.Len(t, awaiting, 1)
+	assert.Equal(t, "hotel", awaiting[0].Transaction.ID)
+	assert.InDelta(t, 150, awaiting[0].Outstanding(), 1e-9)
+
+	// Expected expenses are offered first, settled ones not at all.
+	expenses, err := ReimbursableExpenses(db, "2026-03-20", 10)
+	require.NoError(t, err)
+	require.Len(t, expenses, 2)
+	assert.Equal(t, "hotel", expenses[0].Transaction.ID)
+	assert.Equal(t, "souvenir", expenses[1].Transaction.ID)
+
+	// Deleting the reimbursement puts the expense back in full.
+	require.NoError(t, DeleteTransaction(db, "expense-report"))
+	awaiting, err = AwaitingReimbursement(db)
+	require.NoError(t, err)
+	assert.Len(t, awaiting, 2)
+}
+
+func TestReimbursementsFollowTheirTransactions(t *testing.T) {
+	db := testutil.NewDB(t)
+	income := seedTypedCategory(t, db, "venmo", 1, "income")
+	dining := seedTypedCategory(t, db, "dining", 2, "fun")
+	seedTransaction(t, db, "dinner", 120, "2026-03-03", dining)
+	seedTransaction(t, db, "roommate", -80, "2026-03-05", income)
+	_, err := LinkReimbursement(db, "roommate", "dinner", 60)
+	require.NoError(t, err)
+
+	_, err = db.Exec("DELETE FROM transactions WHERE id = 'dinner'")
+	require.NoError(t, err)
+
+	var n int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM reimbursements").Scan(&n))
+	assert.Zero(t, n)
+}
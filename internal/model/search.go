@@ -0,0 +1,199 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+
+	"fin-web/internal/util"
+)
+
+// Search is a parsed search box query. Words match the start of words in the
+// name, description or bank category; every other part narrows by a field.
+// All parts must match.
+//
+//	coffee "blue bottle"   words and phrases
+//	>100  <=20.50  50..80  amount bounds, inclusive for ranges
+//	account:citi           account, by prefix
+//	source:ofx             source, by prefix
+//	merchant:amazon        words in the name only
+type Search struct {
+	Words     []string
+	Merchants []string
+	Accounts  []string
+	Sources   []string
+	Amounts   []AmountBound
+}
+
+// AmountBound compares the signed amount, so expenses are positive and
+// income negative, as stored. It bounds the whole transaction, even where
+// totals count its splits separately.
+type AmountBound struct {
+	Op    string
+	Value float64
+}
+
+// ParseSearch reads a search box query. It never fails: anything that isn't
+// a recognised filter is searched for as words.
+func ParseSearch(query string) Search {
+	s := Search{}
+	for _, token := range splitSearch(query) {
+		key, value, ok := strings.Cut(token, ":")
+		if ok && value != "" {
+			switch strings.ToLower(key) {
+			case "merchant":
+				s.Merchants = append(s.Merchants, value)
+				continue
+			case "account":
+				s.Accounts = append(s.Accounts, value)
+				continue
+			case "source":
+				s.Sources = append(s.Sources, value)
+				continue
+			}
+		}
+
+		if bounds, ok := parseAmountBounds(token); ok {
+			s.Amounts = append(s.Amounts, bounds...)
+			continue
+		}
+
+		s.Words = append(s.Words, token)
+	}
+
+	return s
+}
+
+// Empty reports whether the query had nothing to search for.
+func (s Search) Empty() bool {
+	return len(s.Words) == 0 && len(s.Merchants) == 0 && len(s.Accounts) == 0 && len(s.Sources) == 0 && len(s.Amounts) == 0
+}
+
+// Ranked reports whether s has words to sort matches by relevance with.
+func (s Search) Ranked() bool {
+	return s.match() != ""
+}
+
+// where returns the conditions matching s, for buildWhere, comparing amount
+// bounds against the amount column.
+func (s Search) where(amount string) ([]string, []any) {
+	conditions := []string{}
+	args := []any{}
+
+	if match := s.match(); match != "" {
+		conditions = append(conditions, "t.id IN (SELECT r.transaction_id FROM transactions_fts JOIN transactions_fts_rows AS r ON r.id = transactions_fts.rowid WHERE transactions_fts MATCH ?)")
+		args = append(args, match)
+	}
+
+	for _, account := range s.Accounts {
+		conditions = append(conditions, `t.account LIKE ? ESCAPE '\'`)
+		args = append(args, likePrefix(account))
+	}
+
+	for _, source := range s.Sources {
+		conditions = append(conditions, `t.source LIKE ? ESCAPE '\'`)
+		args = append(args, likePrefix(source))
+	}
+
+	for _, bound := range s.Amounts {
+		conditions = append(conditions, amount+" "+bound.Op+" ?")
+		args = append(args, bound.Value)
+	}
+
+	return conditions, args
+}
+
+// ranked returns a join adding s.relevance, the negated bm25() score, to
+// every transaction the words match, so higher is more relevant. It is empty
+// when there are no words to rank by.
+func (s Search) ranked() (string, []any) {
+	match := s.match()
+	if match == "" {
+		return "", nil
+	}
+
+	return " JOIN (SELECT r.transaction_id, -bm25(transactions_fts) AS relevance FROM transactions_fts JOIN transactions_fts_rows AS r ON r.id = transactions_fts.rowid WHERE transactions_fts MATCH ?) AS s ON s.transaction_id = t.id", []any{match}
+}
+
+// match builds the full-text query. Each word or phrase becomes a quoted
+// phrase whose last word matches as a prefix; merchant phrases are limited
+// to the name column.
+func (s Search) match() string {
+	parts := []string{}
+	for _, w := range s.Words {
+		if terms := searchTerms(w); len(terms) > 0 {
+			parts = append(parts, `"`+strings.Join(terms, " ")+`"*`)
+		}
+	}
+
+	for _, m := range s.Merchants {
+		if terms := searchTerms(m); len(terms) > 0 {
+			parts = append(parts, `name:"`+strings.Join(terms, " ")+`"*`)
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// searchTerms splits text the way FTS's simple tokenizer does, so punctuation
+// can't be read as query syntax.
+func searchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// parseAmountBounds reads ">100", ">=100", "<100", "<=100" and "50..80".
+func parseAmountBounds(token string) ([]AmountBound, bool) {
+	if low, high, ok := strings.Cut(token, ".."); ok {
+		min, err := util.ParseAmount(low)
+		if err != nil {
+			return nil, false
+		}
+		max, err := util.ParseAmount(high)
+		if err != nil {
+			return nil, false
+		}
+		if min > max {
+			min, max = max, min
+		}
+		return []AmountBound{{Op: ">=", Value: min}, {Op: "<=", Value: max}}, true
+	}
+
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		if rest, ok := strings.CutPrefix(token, op); ok {
+			value, err := util.ParseAmount(rest)
+			if err != nil {
+				return nil, false
+			}
+			return []AmountBound{{Op: op, Value: value}}, true
+		}
+	}
+
+	return nil, false
+}
+
+// splitSearch splits a query on spaces, keeping double-quoted phrases, with
+// or without a "key:" in front, together.
+func splitSearch(query string) []string {
+	tokens := []string{}
+	var current strings.Builder
+	quoted := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case unicode.IsSpace(r) && !quoted:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+
+	return tokens
+}
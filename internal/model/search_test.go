@@ -0,0 +1,129 @@
+package model
+
+import (
+	"database/sql"
+	"testing"
+
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSearch(t *testing.T) {
+	s := ParseSearch(`coffee "blue bottle" >20 <=$1,000 50..80 account:citi source:ofx merchant:"whole foods" merchant:`)
+
+	assert.Equal(t, []string{"coffee", "blue bottle", "merchant:"}, s.Words)
+	assert.Equal(t, []string{"whole foods"}, s.Merchants)
+	assert.Equal(t, []string{"citi"}, s.Accounts)
+	assert.Equal(t, []string{"ofx"}, s.Sources)
+	assert.Equal(t, []AmountBound{
+		{Op: ">", Value: 20},
+		{Op: "<=", Value: 1000},
+		{Op: ">=", Value: 50},
+		{Op: "<=", Value: 80},
+	}, s.Amounts)
+
+	assert.Equal(t, `"coffee"* "blue bottle"* "merchant"* name:"whole foods"*`, s.match())
+
+	assert.True(t, ParseSearch("   ").Empty())
+	assert.Equal(t, []string{">abc"}, ParseSearch(">abc").Words, "not an amount")
+}
+
+func searchIDs(t *testing.T, db *sql.DB, query string) []string {
+	t.Helper()
+	txns, err := QueryTransactions(db, QueryTransactionsFilters{Search: query, OrderBy: "date", OrderDirection: "ASC"})
+	require.NoError(t, err)
+
+	ids := []string{}
+	for _, tx := range txns {
+		ids = append(ids, tx.ID)
+	}
+	return ids
+}
+
+func TestQueryTransactionsSearch(t *testing.T) {
+	db := testutil.NewDB(t)
+	for _, tx := range []Transaction{
+		{ID: "coffee", Name: "SQ *BLUE BOTTLE 4417", Amount: 6.5, Date: "2026-03-01", Source: "citi", Account: "citi", Category: "Restaurants"},
+		{ID: "groceries", Name: "WHOLE FOODS MARKET", Amount: 64.2, Date: "2026-03-02", Source: "ofx", Account: "ofx_1234", Category: "Groceries"},
+		{ID: "flight", Name: "UNITED AIRLINES", Amount: 412, Date: "2026-03-03", Source: "ofx", Account: "ofx_1234"},
+		{ID: "pay", Name: "ACME PAYROLL", Amount: -2500, Date: "2026-03-04", Source: "bofa", Account: "bofa"},
+	} {
+		require.NoError(t, CreateTransaction(db, tx))
+	}
+
+	assert.Equal(t, []string{"coffee"}, searchIDs(t, db, "bottle"))
+	assert.Equal(t, []string{"coffee"}, searchIDs(t, db, "restaur"), "bank category")
+	assert.Equal(t, []string{"groceries", "flight"}, searchIDs(t, db, "account:ofx"))
+	assert.Equal(t, []string{"coffee"}, searchIDs(t, db, "source:CITI"))
+	assert.Equal(t, []string{"groceries", "flight"}, searchIDs(t, db, ">50"))
+	assert.Equal(t, []string{"groceries"}, searchIDs(t, db, "50..80"))
+	assert.Equal(t, []string{"pay"}, searchIDs(t, db, "<0"))
+	assert.Equal(t, []string{"flight"}, searchIDs(t, db, "account:ofx >100"))
+	assert.Equal(t, []string{"groceries"}, searchIDs(t, db, "merchant:whole"))
+	assert.Empty(t, searchIDs(t, db, "merchant:groceries"), "merchant only searches the name")
+	assert.Equal(t, []string{"groceries"}, searchIDs(t, db, `"whole foods"`))
+	assert.Empty(t, searchIDs(t, db, `"foods whole"`))
+
+	// Totals take the same search.
+	total, err := SumTransactions(db, QueryTransactionsFilters{Search: "account:ofx"})
+	require.NoError(t, err)
+	assert.InDelta(t, 476.2, total, 1e-9)
+}
+
+func TestSearchIndexFollowsChanges(t *testing.T) {
+	db := testutil.NewDB(t)
+	require.NoError(t, CreateTransaction(db, Transaction{ID: "tx", Name: "VENMO", Amount: 40, Date: "2026-03-01", Source: "bofa", Account: "bofa"}))
+	assert.Empty(t, searchIDs(t, db, "concert"))
+
+	description := "concert tickets with sam"
+	require.NoError(t, UpdateTransaction(db, "tx", UpdateTransactionParams{Description: &description}))
+	assert.Equal(t, []string{"tx"}, searchIDs(t, db, "concert"))
+	assert.Equal(t, []string{"tx"}, searchIDs(t, db, "venmo"))
+
+	require.NoError(t, DeleteTransaction(db, "tx"))
+	assert.Empty(t, searchIDs(t, db, "venmo"))
+
+	var rows int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM transactions_fts").Scan(&rows))
+	assert.Zero(t, rows)
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM transactions_fts_rows").Scan(&rows))
+	assert.Zero(t, rows)
+}
+
+func TestQueryTransactionsByRelevance(t *testing.T) {
+	db := testutil.NewDB(t)
+	for _, tx := range []Transaction{
+		{ID: "once", Name: "CORNER STORE", Description: sql.NullString{Valid: true, String: "coffee filters and a lot of other things"}, Amount: 30, Date: "2026-03-01"},
+		{ID: "twice", Name: "COFFEE COFFEE", Amount: 5, Date: "2026-03-02"},
+		{ID: "name", Name: "COFFEE BAR", Amount: 4, Date: "2026-03-03"},
+		{ID: "other", Name: "HARDWARE", Amount: 12, Date: "2026-03-04"},
+	} {
+		require.NoError(t, CreateTransaction(db, tx))
+	}
+
+	filters := QueryTransactionsFilters{Search: "coffee", OrderBy: "relevance", OrderDirection: "DESC"}
+	txns, err := QueryTransactions(db, filters)
+	require.NoError(t, err)
+	ids := []string{}
+	for _, tx := range txns {
+		ids = append(ids, tx.ID)
+	}
+	assert.Equal(t, []string{"twice", "name", "once"}, ids, "best match first")
+
+	// Pages follow the same order.
+	first, err := QueryTransactionsPage(db, filters, PageRequest{Size: 2})
+	require.NoError(t, err)
+	require.Len(t, first.Transactions, 2)
+	next, err := QueryTransactionsPage(db, filters, PageRequest{Size: 2, After: first.Next})
+	require.NoError(t, err)
+	require.Len(t, next.Transactions, 1)
+	assert.Equal(t, "once", next.Transactions[0].ID)
+
+	// Without words there is nothing to rank, so it sorts by amount.
+	txns, err = QueryTransactions(db, QueryTransactionsFilters{Search: ">10", OrderBy: "relevance"})
+	require.NoError(t, err)
+	require.Len(t, txns, 2)
+	assert.Equal(t, "once", txns[0].ID)
+}
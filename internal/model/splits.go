@@ -0,0 +1,129 @@
+package model
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+)
+
+// ErrSplitsDontSum is returned when split amounts don't add up to the
+// transaction they divide.
+var ErrSplitsDontSum = errors.New("split amounts must sum to the transaction amount")
+
+// Split allocates part of a transaction's amount to a category.
+type Split struct {
+	ID            int
+	TransactionID string
+	Amount        float64
+	CategoryID    sql.NullInt32
+	CategoryLabel sql.NullString
+	Description   sql.NullString
+}
+
+// allocations has a row per category allocation: a transaction's own row
+// when it isn't split, otherwise one per split carrying the parent's other
+// columns. Totals select from it so each split counts toward its category.
+// Linked reimbursements are netted out of both the expense and the
+// reimbursement, shared across splits in proportion to their amounts; a
+// zero-amount transaction has nothing left to net and counts as zero.
+// transaction_amount is the whole transaction's, for filters on it.
+const allocations = "(SELECT t.id, t.name, t.date, t.account, t.source, t.is_reimbursement, t.amount AS transaction_amount, CASE WHEN r.netted IS NULL THEN COALESCE(s.amount, t.amount) WHEN t.amount = 0 THEN 0 ELSE COALESCE(s.amount, t.amount) * (t.amount - r.netted) / t.amount END AS amount, CASE WHEN s.id IS NULL THEN t.category_id ELSE s.category_id END AS category_id FROM transactions AS t LEFT JOIN transaction_splits AS s ON s.transaction_id = t.id LEFT JOIN (" + reimbursedAmounts + ") AS r ON r.id = t.id)"
+
+// SplitsSum reports whether splits add up to amount, to the cent.
+func SplitsSum(amount float64, splits []Split) bool {
+	total := 0.0
+	for _, s := range splits {
+		total += s.Amount
+	}
+
+	return math.Abs(total-amount) < 0.005
+}
+
+func GetSplits(conn *sql.DB, transactionID string) ([]Split, error) {
+	rows, err := conn.Query(
+		"SELECT s.id, s.transaction_id, s.amount, c.id, c.label, s.description FROM transaction_splits AS s LEFT JOIN categories AS c ON s.category_id = c.id WHERE s.transaction_id = ? ORDER BY s.id",
+		transactionID,
+	)
+	if err != nil {
+		return []Split{}, err
+	}
+	defer rows.Close()
+
+	splits := []Split{}
+	for rows.Next() {
+		split := Split{}
+		if err := rows.Scan(
+			&split.ID,
+			&split.TransactionID,
+			&split.Amount,
+			&split.CategoryID,
+			&split.CategoryLabel,
+			&split.Description,
+		); err != nil {
+			return []Split{}, err
+		}
+
+		splits = append(splits, split)
+	}
+
+	return splits, rows.Err()
+}
+
+// SaveSplits replaces a transaction's splits in one SQL transaction. The
+// transaction itself takes the category of its largest split, picked by hand,
+// so lists and the uncategorized queue still show it sensibly. No splits
+// removes them and leaves the transaction's category as it is.
+func SaveSplits(conn *sql.DB, transactionID string, splits []Split) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var amount float64
+	err = tx.QueryRow("SELECT amount FROM transactions WHERE id = ?", transactionID).Scan(&amount)
+	if err != nil {
+		return err
+	}
+
+	if len(splits) > 0 && !SplitsSum(amount, splits) {
+		return ErrSplitsDontSum
+	}
+
+	if err := deleteSplits(tx, transactionID); err != nil {
+		return err
+	}
+
+	var largest *Split
+	for i, s := range splits {
+		_, err := tx.Exec(
+			"INSERT INTO transaction_splits(transaction_id, amount, category_id, description) VALUES(?, ?, ?, ?)",
+			transactionID,
+			s.Amount,
+			s.CategoryID,
+			s.Description,
+		)
+		if err != nil {
+			return err
+		}
+
+		if s.CategoryID.Valid && (largest == nil || math.Abs(s.Amount) > math.Abs(largest.Amount)) {
+			largest = &splits[i]
+		}
+	}
+
+	if largest != nil {
+		categoryID := int(largest.CategoryID.Int32)
+		err := UpdateTransaction(tx, transactionID, UpdateTransactionParams{CategoryID: &categoryID})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func deleteSplits(conn Querier, transactionID string) error {
+	_, err := conn.Exec("DELETE FROM transaction_splits WHERE transaction_id = ?", transactionID)
+	return err
+}
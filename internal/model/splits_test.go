@@ -0,0 +1,165 @@
+package model
+
+import (
+	"database/sql"
+	"strconv"
+	"testing"
+
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func split(amount float64, categoryID int) Split {
+	return Split{Amount: amount, CategoryID: sql.NullInt32{Valid: true, Int32: int32(categoryID)}}
+}
+
+func TestSaveSplitsMustSum(t *testing.T) {
+	db := testutil.NewDB(t)
+	groceries := seedTypedCategory(t, db, "groceries", 1, "fixed")
+	household := seedTypedCategory(t, db, "household", 2, "fun")
+	seedTransaction(t, db, "costco", 100, "2026-03-03", groceries)
+
+	err := SaveSplits(db, "costco", []Split{split(70, groceries), split(20, household)})
+	require.ErrorIs(t, err, ErrSplitsDontSum)
+
+	splits, err := GetSplits(db, "costco")
+	require.NoError(t, err)
+	assert.Empty(t, splits)
+}
+
+func TestSaveSplitsReplacesAndRemoves(t *testing.T) {
+	db := testutil.NewDB(t)
+	groceries := seedTypedCategory(t, db, "groceries", 1, "fixed")
+	household := seedTypedCategory(t, db, "household", 2, "fun")
+	seedTransaction(t, db, "costco", 100, "2026-03-03", groceries)
+
+	require.NoError(t, SaveSplits(db, "costco", []Split{split(30, groceries), split(70, household)}))
+	require.NoError(t, SaveSplits(db, "costco", []Split{split(60.01, groceries), split(39.99, household)}))
+
+	splits, err := GetSplits(db, "costco")
+	require.NoError(t, err)
+	require.Len(t, splits, 2)
+	assert.Equal(t, 60.01, splits[0].Amount)
+	assert.Equal(t, "groceries", splits[0].CategoryLabel.String)
+
+	tx, err := GetTransaction(db, "costco")
+	require.NoError(t, err)
+	assert.Equal(t, int32(groceries), tx.CategoryID.Int32, "the largest split's category")
+
+	require.NoError(t, SaveSplits(db, "costco", nil))
+	splits, err = GetSplits(db, "costco")
+	require.NoError(t, err)
+	assert.Empty(t, splits)
+}
+
+func TestTotalsAggregateBySplit(t *testing.T) {
+	db := testutil.NewDB(t)
+	income := seedTypedCategory(t, db, "salary", 1, "income")
+	groceries := seedTypedCategory(t, db, "groceries", 2, "fixed")
+	household := seedTypedCategory(t, db, "household", 3, "fun")
+	seedTransaction(t, db, "pay", -1000, "2026-03-01", income)
+	seedTransaction(t, db, "costco", 100, "2026-03-03", groceries)
+	seedTransaction(t, db, "aldi", 50, "2026-03-04", groceries)
+	require.NoError(t, SaveSplits(db, "costco", []Split{split(80, groceries), split(20, household)}))
+
+	counts, err := CategoryCounts(db, QueryTransactionsFilters{Type: "expenses"})
+	require.NoError(t, err)
+	byLabel := map[string]float64{}
+	for _, c := range counts {
+		byLabel[c.Key] = c.Value
+	}
+	assert.Equal(t, map[string]float64{"groceries": 130, "household": 20}, byLabel)
+
+	fun, err := SumTransactions(db, QueryTransactionsFilters{Type: "fun"})
+	require.NoError(t, err)
+	assert.Equal(t, 20.0, fun)
+
+	total, err := SumTransactions(db, QueryTransactionsFilters{Type: "expenses"})
+	require.NoError(t, err)
+	assert.Equal(t, 150.0, total, "splits replace their parent rather than adding to it")
+
+	byMonth, err := CountsByDate(db, QueryTransactionsFilters{Type: "expenses"}, "%Y-%m")
+	require.NoError(t, err)
+	require.Len(t, byMonth, 1)
+	assert.Equal(t, 150.0, byMonth[0].Value)
+
+	b, err := SpendingBreakdown(db, QueryTransactionsFilters{})
+	require.NoError(t, err)
+	assert.Equal(t, Breakdown{Income: 1000, Needs: 130, Wants: 20}, b)
+
+	// Transactions listed are still whole rows.
+	txns, err := QueryTransactions(db, QueryTransactionsFilters{})
+	require.NoError(t, err)
+	assert.Len(t, txns, 3)
+}
+
+func TestDeleteTransactionRemovesSplits(t *testing.T) {
+	db := testutil.NewDB(t)
+	groceries := seedTypedCategory(t, db, "groceries", 1, "fixed")
+	household := seedTypedCategory(t, db, "household", 2, "fun")
+	seedTransaction(t, db, "costco", 100, "2026-03-03", groceries)
+	require.NoError(t, SaveSplits(db, "costco", []Split{split(80, groceries), split(20, household)}))
+
+	require.NoError(t, DeleteTransaction(db, "costco"))
+
+	var n int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM transaction_splits").Scan(&n))
+	assert.Zero(t, n)
+}
+
+func TestDeleteCategoryClearsSplitCategory(t *testing.T) {
+	db := testutil.NewDB(t)
+	groceries := seedTypedCategory(t, db, "groceries", 1, "fixed")
+	household := seedTypedCategory(t, db, "household", 2, "fun")
+	seedTransaction(t, db, "costco", 100, "2026-03-03", groceries)
+	require.NoError(t, SaveSplits(db, "costco", []Split{split(80, groceries), split(20, household)}))
+
+	require.NoError(t, DeleteCategory(db, strconv.Itoa(household)))
+
+	var n int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM transaction_splits WHERE category_id = ?", household).Scan(&n))
+	assert.Zero(t, n, "the deleted category's split is uncategorized")
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM transaction_splits WHERE category_id IS NULL").Scan(&n))
+	assert.Equal(t, 1, n)
+}
+
+func TestAmountBoundsApplyToWholeSplitTransaction(t *testing.T) {
+	db := testutil.NewDB(t)
+	groceries := seedTypedCategory(t, db, "groceries", 1, "fixed")
+	household := seedTypedCategory(t, db, "household", 2, "fun")
+	seedTransaction(t, db, "costco", 100, "2026-03-03", groceries)
+	seedTransaction(t, db, "snack", 30, "2026-03-04", groceries)
+	require.NoError(t, SaveSplits(db, "costco", []Split{split(70, groceries), split(30, household)}))
+
+	min := 50.0
+	for _, filters := range []QueryTransactionsFilters{
+		{Type: "expenses", MinAmount: &min},
+		{Type: "expenses", Search: ">=50"},
+	} {
+		// The 30 split is under the bound but its transaction isn't.
+		total, err := SumTransactions(db, filters)
+		require.NoError(t, err)
+		assert.InDelta(t, 100, total, 1e-9)
+
+		counts, err := CategoryCounts(db, filters)
+		require.NoError(t, err)
+		byLabel := map[string]float64{}
+		for _, c := range counts {
+			byLabel[c.Key] = c.Value
+		}
+		assert.Equal(t, map[string]float64{"groceries": 70, "household": 30}, byLabel)
+
+		txns, err := QueryTransactions(db, filters)
+		require.NoError(t, err)
+		require.Len(t, txns, 1)
+		assert.Equal(t, "costco", txns[0].ID)
+	}
+
+	// A bound the whole transaction misses excludes every split.
+	max := 50.0
+	total, err := SumTransactions(db, QueryTransactionsFilters{Type: "expenses", MaxAmount: &max})
+	require.NoError(t, err)
+	assert.InDelta(t, 30, total, 1e-9)
+}
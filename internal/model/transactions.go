@@ -2,9 +2,31 @@ package model
 
 import (
 	"database/sql"
+	"errors"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
+
+	"fin-web/internal/util"
+)
+
+// Category sources record what assigned a transaction's category.
+const (
+	// CategorySourceRule is a category rule; RuleID says which.
+	CategorySourceRule = "rule"
+	// CategorySourceImport is a category value matched when the row was
+	// parsed, or when it was recategorized the same way.
+	CategorySourceImport = "import"
+	// CategorySourceManual is a person, and bulk recategorization leaves it be.
+	CategorySourceManual = "manual"
+)
+
+// Transaction statuses. A pending row is replaced by its posted counterpart
+// when a later import brings it in; see ReconcilePending.
+const (
+	TransactionPending = "pending"
+	TransactionPosted  = "posted"
 )
 
 type Transaction struct {
@@ -19,6 +41,25 @@ type Transaction struct {
 	Source          string
 	CategoryID      sql.NullInt32
 	IsReimbursement bool
+	Fingerprint     string
+	BatchID         sql.NullInt64
+	RuleID          sql.NullInt64
+	CategorySource  sql.NullString
+	// CategoryAssignedAt is when CategorySource last assigned the category,
+	// unknown for rows categorized before sources were tracked.
+	CategoryAssignedAt sql.NullString
+	// ExpectsReimbursement marks an expense someone is due to pay back.
+	ExpectsReimbursement bool
+	// Status is TransactionPending or TransactionPosted; empty is posted.
+	Status string
+	// relevance is the search score when sorting by relevance, kept for the
+	// page cursor.
+	relevance float64
+}
+
+// Pending reports whether the bank hasn't posted the transaction yet.
+func (t Transaction) Pending() bool {
+	return t.Status == TransactionPending
 }
 
 type QueryTransactionsFilters struct {
@@ -31,9 +72,49 @@ type QueryTransactionsFilters struct {
 	Type                string
 	EmptyCustomCategory *bool
 	Types               []string
+	// Search is a search box query; see ParseSearch.
+	Search string
+	// Accounts and Sources match exactly; a transaction in any of them passes.
+	Accounts []string
+	Sources  []string
+	// MinAmount and MaxAmount bound the signed amount, so income is negative.
+	// They apply to the whole transaction, so its splits pass or fail as one.
+	MinAmount       *float64
+	MaxAmount       *float64
+	IsReimbursement *bool
+	// Merchant matches transactions whose normalized merchant key starts
+	// with the normalized value; see util.NormalizeMerchant.
+	Merchant string
 }
 
-func buildWhere(queryStr string, args []any, filters QueryTransactionsFilters) (string, []any) {
+// inList returns a condition matching column against the non-empty values,
+// or false when there are none.
+func inList(column string, values []string, args []any) (string, []any, bool) {
+	count := 0
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		args = append(args, v)
+		count++
+	}
+	if count == 0 {
+		return "", args, false
+	}
+
+	return column + " IN (" + strings.TrimSuffix(strings.Repeat("?,", count), ",") + ")", args, true
+}
+
+// likePrefix escapes value for use as a LIKE prefix with ESCAPE '\'.
+func likePrefix(value string) string {
+	r := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	return r.Replace(value) + "%"
+}
+
+// buildWhere appends the conditions matching filters to queryStr. amount is
+// the column holding each transaction's whole amount, which amount bounds
+// compare: t.amount on transactions, t.transaction_amount on allocations.
+func buildWhere(queryStr string, args []any, filters QueryTransactionsFilters, amount string) (string, []any) {
 	filterStrings := []string{}
 
 	if filters.StartDate != "" {
@@ -46,18 +127,51 @@ func buildWhere(queryStr string, args []any, filters QueryTransactionsFilters) (
 		args = append(args, filters.EndDate)
 	}
 
-	if len(filters.Categories) > 0 && filters.Categories[0] != "" {
-		cStr := "("
-		for idx, val := range filters.Categories {
-			args = append(args, val)
+	var (
+		cond string
+		ok   bool
+	)
+	if cond, args, ok = inList("c.id", filters.Categories, args); ok {
+		filterStrings = append(filterStrings, cond)
+	}
 
-			if idx == len(filters.Categories)-1 {
-				cStr += " c.id = ?)"
-			} else {
-				cStr += " c.id = ? OR"
-			}
-		}
-		filterStrings = append(filterStrings, cStr)
+	if cond, args, ok = inList("t.account", filters.Accounts, args); ok {
+		filterStrings = append(filterStrings, cond)
+	}
+
+	if cond, args, ok = inList("t.source", filters.Sources, args); ok {
+		filterStrings = append(filterStrings, cond)
+	}
+
+	if filters.MinAmount != nil {
+		filterStrings = append(filterStrings, amount+" >= ?")
+		args = append(args, *filters.MinAmount)
+	}
+
+	if filters.MaxAmount != nil {
+		filterStrings = append(filterStrings, amount+" <= ?")
+		args = append(args, *filters.MaxAmount)
+	}
+
+	if filters.IsReimbursement != nil {
+		filterStrings = append(filterStrings, "COALESCE(t.is_reimbursement, 0) = ?")
+		args = append(args, *filters.IsReimbursement)
+	}
+
+	if merchant := util.NormalizeMerchant(filters.Merchant); merchant != "" {
+		filterStrings = append(filterStrings, `merchant_key(t.name) LIKE ? ESCAPE '\'`)
+		args = append(args, likePrefix(merchant))
+	}
+
+	if filters.Search != "" {
+		conditions, searchArgs := ParseSearch(filters.Search).where(amount)
+		filterStrings = append(filterStrings, conditions...)
+		args = append(args, searchArgs...)
+	}
+
+	// Money moved between our own accounts is neither income nor spending.
+	if filters.Type != "" {
+		filterStrings = append(filterStrings, "t.id NOT IN ("+linkedTransfers+")")
 	}
 
 	if filters.Type == "income" {
@@ -105,35 +219,83 @@ func buildWhere(queryStr string, args []any, filters QueryTransactionsFilters) (
 }
 
 func QueryTransactions(conn *sql.DB, filters QueryTransactionsFilters) ([]Transaction, error) {
-	queryStr := "select t.id, name, amount, date, account, source, description, c.id, c.label as category, is_reimbursement from transactions as t left join categories as c on category_id = c.id"
+	return queryTransactions(conn, filters, nil, false)
+}
+
+// orderColumn returns the column and direction filters sort by, falling
+// back to amount and DESC for anything unrecognized.
+func orderColumn(filters QueryTransactionsFilters) (string, string) {
+	var cleanOrderBy string
+	switch strings.ToLower(filters.OrderBy) {
+	case "date":
+		cleanOrderBy = "date"
+	case "amount":
+		cleanOrderBy = "amount"
+	case "name":
+		cleanOrderBy = "name"
+	case "relevance":
+		// Only words are ranked; without any, sort as if unrecognized.
+		cleanOrderBy = "amount"
+		if ParseSearch(filters.Search).Ranked() {
+			cleanOrderBy = "relevance"
+		}
+	default:
+		cleanOrderBy = "amount" // safe fallback
+	}
+
+	var cleanDirection string
+	switch strings.ToUpper(filters.OrderDirection) {
+	case "ASC":
+		cleanDirection = "ASC"
+	case "DESC":
+		cleanDirection = "DESC"
+	default:
+		cleanDirection = "DESC" // safe fallback
+	}
+
+	return cleanOrderBy, cleanDirection
+}
+
+// queryTransactions lists transactions, starting after cursor when it is
+// set. backward walks the order in reverse from cursor, which is how the
+// previous page is read; rows still come back in reverse order.
+func queryTransactions(conn *sql.DB, filters QueryTransactionsFilters, cursor *Cursor, backward bool) ([]Transaction, error) {
+	queryStr := "select t.id, name, amount, date, account, source, description, c.id, c.label as category, is_reimbursement, rule_id, status"
+	from := " from transactions as t left join categories as c on category_id = c.id"
 	args := []any{}
 
-	queryStr, args = buildWhere(queryStr, args, filters)
-
-	if filters.OrderBy != "" {
-		var cleanOrderBy string
-		switch strings.ToLower(filters.OrderBy) {
-		case "date":
-			cleanOrderBy = "date"
-		case "amount":
-			cleanOrderBy = "amount"
-		case "name":
-			cleanOrderBy = "name"
-		default:
-			cleanOrderBy = "amount" // safe fallback
+	sorted := filters.OrderBy != "" || cursor != nil
+	column, direction := orderColumn(filters)
+	orderBy := "t." + column
+	ranked := sorted && column == "relevance"
+	if ranked {
+		join, joinArgs := ParseSearch(filters.Search).ranked()
+		queryStr += ", s.relevance"
+		from += join
+		args = append(args, joinArgs...)
+		orderBy = "s.relevance"
+	}
+
+	queryStr, args = buildWhere(queryStr+from, args, filters, "t.amount")
+
+	if sorted {
+		if backward {
+			direction = reverseDirection(direction)
 		}
 
-		var cleanDirection string
-		switch strings.ToUpper(filters.OrderDirection) {
-		case "ASC":
-			cleanDirection = "ASC"
-		case "DESC":
-			cleanDirection = "DESC"
-		default:
-			cleanDirection = "DESC" // safe fallback
+		// buildWhere always filters ignored categories, so there is a WHERE
+		// to extend. Ties on the column are broken by id so no row sits on
+		// a page boundary twice.
+		if cursor != nil {
+			op := "<"
+			if direction == "ASC" {
+				op = ">"
+			}
+			queryStr += " AND (" + orderBy + ", t.id) " + op + " (?, ?)"
+			args = append(args, cursor.Value, cursor.ID)
 		}
 
-		queryStr += " ORDER BY " + cleanOrderBy + " " + cleanDirection
+		queryStr += " ORDER BY " + orderBy + " " + direction + ", t.id " + direction
 	}
 
 	if filters.Limit > 0 {
@@ -152,7 +314,7 @@ func QueryTransactions(conn *sql.DB, filters QueryTransactionsFilters) ([]Transa
 	transactions := []Transaction{}
 	for rows.Next() {
 		transaction := Transaction{}
-		if err := rows.Scan(
+		dest := []any{
 			&transaction.ID,
 			&transaction.Name,
 			&transaction.Amount,
@@ -163,21 +325,27 @@ func QueryTransactions(conn *sql.DB, filters QueryTransactionsFilters) ([]Transa
 			&transaction.CategoryID,
 			&transaction.CustomCategory,
 			&transaction.IsReimbursement,
-		); err != nil {
+			&transaction.RuleID,
+			&transaction.Status,
+		}
+		if ranked {
+			dest = append(dest, &transaction.relevance)
+		}
+		if err := rows.Scan(dest...); err != nil {
 			return []Transaction{}, err
 		}
 
 		transactions = append(transactions, transaction)
 	}
 
-	return transactions, nil
+	return transactions, rows.Err()
 }
 
 func CategoryCounts(conn *sql.DB, filters QueryTransactionsFilters) ([]GroupByCounts, error) {
-	queryStr := "SELECT c.id, c.label as category, SUM(t.amount) FROM transactions as t left join categories as c on t.category_id = c.id"
+	queryStr := "SELECT c.id, c.label as category, SUM(t.amount) FROM " + allocations + " as t left join categories as c on t.category_id = c.id"
 	args := []any{}
 
-	queryStr, args = buildWhere(queryStr, args, filters)
+	queryStr, args = buildWhere(queryStr, args, filters, "t.transaction_amount")
 
 	queryStr += " GROUP BY c.id"
 
@@ -207,11 +375,40 @@ func CategoryCounts(conn *sql.DB, filters QueryTransactionsFilters) ([]GroupByCo
 	return counts, nil
 }
 
+// GetAccounts returns every account with a transaction, for filter choices.
+func GetAccounts(conn *sql.DB) ([]string, error) {
+	return distinctValues(conn, "SELECT DISTINCT account FROM transactions ORDER BY account")
+}
+
+// GetSources returns every source with a transaction, for filter choices.
+func GetSources(conn *sql.DB) ([]string, error) {
+	return distinctValues(conn, "SELECT DISTINCT source FROM transactions ORDER BY source")
+}
+
+func distinctValues(conn *sql.DB, queryStr string) ([]string, error) {
+	rows, err := conn.Query(queryStr)
+	if err != nil {
+		return []string{}, err
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return []string{}, err
+		}
+		values = append(values, v)
+	}
+
+	return values, rows.Err()
+}
+
 func SumTransactions(conn *sql.DB, filters QueryTransactionsFilters) (float64, error) {
-	queryStr := "select COALESCE(SUM(amount), 0) from transactions as t left join categories as c on category_id = c.id"
+	queryStr := "select COALESCE(SUM(amount), 0) from " + allocations + " as t left join categories as c on category_id = c.id"
 	args := []any{}
 
-	queryStr, args = buildWhere(queryStr, args, filters)
+	queryStr, args = buildWhere(queryStr, args, filters, "t.transaction_amount")
 
 	var count float64
 	err := conn.QueryRow(
@@ -228,10 +425,10 @@ func SumTransactions(conn *sql.DB, filters QueryTransactionsFilters) (float64, e
 }
 
 func CountsByDate(conn *sql.DB, filters QueryTransactionsFilters, dateStr string) ([]GroupByCounts, error) {
-	queryStr := "SELECT strftime(\"" + dateStr + "\", date), SUM(amount) FROM transactions as t left join categories as c on t.category_id = c.id"
+	queryStr := "SELECT strftime(\"" + dateStr + "\", date), SUM(amount) FROM " + allocations + " as t left join categories as c on t.category_id = c.id"
 	args := []any{}
 
-	queryStr, args = buildWhere(queryStr, args, filters)
+	queryStr, args = buildWhere(queryStr, args, filters, "t.transaction_amount")
 
 	queryStr += " GROUP BY strftime(\"" + dateStr + "\", date)"
 
@@ -364,7 +561,7 @@ func MonthlyFlows(conn *sql.DB, filters QueryTransactionsFilters) ([]MonthlyFlow
 }
 
 func GetTransaction(conn *sql.DB, ID string) (Transaction, error) {
-	queryStr := "select t.id, name, amount, date, account, source, description, c.id, is_reimbursement from transactions as t left join categories as c on category_id = c.id where t.id = ?"
+	queryStr := "select t.id, name, amount, date, account, source, description, c.id, is_reimbursement, rule_id, category_source, category_assigned_at, expects_reimbursement, status from transactions as t left join categories as c on category_id = c.id where t.id = ?"
 
 	transaction := Transaction{}
 	err := conn.QueryRow(
@@ -380,6 +577,11 @@ func GetTransaction(conn *sql.DB, ID string) (Transaction, error) {
 		&transaction.Description,
 		&transaction.CategoryID,
 		&transaction.IsReimbursement,
+		&transaction.RuleID,
+		&transaction.CategorySource,
+		&transaction.CategoryAssignedAt,
+		&transaction.ExpectsReimbursement,
+		&transaction.Status,
 	)
 	if err != nil {
 		return Transaction{}, err
@@ -389,19 +591,37 @@ func GetTransaction(conn *sql.DB, ID string) (Transaction, error) {
 }
 
 type UpdateTransactionParams struct {
-	CategoryID      *int
-	Description     *string
-	IsReimbursement *bool
+	CategoryID           *int
+	Description          *string
+	IsReimbursement      *bool
+	ExpectsReimbursement *bool
 }
 
-func UpdateTransaction(conn *sql.DB, ID string, params UpdateTransactionParams) error {
+func UpdateTransaction(conn Querier, ID string, params UpdateTransactionParams) error {
 	queryStr := "UPDATE transactions SET"
 	updates := []string{}
 	args := []any{}
 
 	if params.CategoryID != nil {
-		updates = append(updates, " category_id = ?")
-		args = append(args, *params.CategoryID)
+		// A hand-picked category is no longer the work of a rule. SET sees
+		// the old category_id, so resubmitting the same category keeps its
+		// rule and source.
+		updates = append(
+			updates,
+			" category_id = ?",
+			" rule_id = CASE WHEN category_id IS ? THEN rule_id END",
+			" category_source = CASE WHEN category_id IS ? THEN category_source ELSE ? END",
+			" category_assigned_at = CASE WHEN category_id IS ? THEN category_assigned_at ELSE ? END",
+		)
+		args = append(
+			args,
+			*params.CategoryID,
+			*params.CategoryID,
+			*params.CategoryID,
+			CategorySourceManual,
+			*params.CategoryID,
+			time.Now().UTC().Format(time.RFC3339),
+		)
 	}
 
 	if params.Description != nil {
@@ -414,6 +634,11 @@ func UpdateTransaction(conn *sql.DB, ID string, params UpdateTransactionParams)
 		args = append(args, *params.IsReimbursement)
 	}
 
+	if params.ExpectsReimbursement != nil {
+		updates = append(updates, " expects_reimbursement = ?")
+		args = append(args, *params.ExpectsReimbursement)
+	}
+
 	if len(updates) == 0 {
 		return nil
 	}
@@ -433,8 +658,67 @@ func UpdateTransaction(conn *sql.DB, ID string, params UpdateTransactionParams)
 	return nil
 }
 
+// UpdateTransactions applies params to every transaction in IDs in one SQL
+// transaction. categoryValues are added to params.CategoryID in the same
+// transaction, so future imports categorize these merchants the same way.
+func UpdateTransactions(conn *sql.DB, IDs []string, params UpdateTransactionParams, categoryValues []string) error {
+	if len(categoryValues) > 0 && params.CategoryID == nil {
+		return errors.New("category values need a category")
+	}
+
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, ID := range IDs {
+		if err := UpdateTransaction(tx, ID, params); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range categoryValues {
+		if _, err := CreateCategoryValue(tx, *params.CategoryID, v); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
 func CreateTransaction(conn *sql.DB, transaction Transaction) error {
-	queryStr := "INSERT INTO transactions(id, name, amount, date, source, account, category, category_id, description, is_reimbursement) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	_, err := conn.Exec(
+		"INSERT INTO transactions(id, name, amount, date, source, account, category, category_id, description, is_reimbursement, fingerprint, batch_id, rule_id, category_source, category_assigned_at, status) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		transactionInsertArgs(transaction)...,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CreateTransactionIfNew inserts transaction unless a row with the same
+// fingerprint is already stored. It reports whether the row was inserted.
+func CreateTransactionIfNew(conn Querier, transaction Transaction) (bool, error) {
+	res, err := conn.Exec(
+		"INSERT INTO transactions(id, name, amount, date, source, account, category, category_id, description, is_reimbursement, fingerprint, batch_id, rule_id, category_source, category_assigned_at, status) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT(fingerprint) DO NOTHING",
+		transactionInsertArgs(transaction)...,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+func transactionInsertArgs(transaction Transaction) []any {
 	args := []any{
 		transaction.ID,
 		transaction.Name,
@@ -454,18 +738,34 @@ func CreateTransaction(conn *sql.DB, transaction Transaction) error {
 	args = append(args, transaction.Description)
 	args = append(args, transaction.IsReimbursement)
 
-	_, err := conn.Exec(
-		queryStr,
-		args...,
-	)
-	if err != nil {
-		return err
+	if transaction.Fingerprint != "" {
+		args = append(args, transaction.Fingerprint)
+	} else {
+		args = append(args, nil)
 	}
 
-	return nil
+	args = append(args, transaction.BatchID)
+	args = append(args, transaction.RuleID)
+	args = append(args, transaction.CategorySource)
+
+	if transaction.CategorySource.Valid && !transaction.CategoryAssignedAt.Valid {
+		args = append(args, time.Now().UTC().Format(time.RFC3339))
+	} else {
+		args = append(args, transaction.CategoryAssignedAt)
+	}
+
+	if transaction.Status != "" {
+		args = append(args, transaction.Status)
+	} else {
+		args = append(args, TransactionPosted)
+	}
+
+	return args
 }
 
-func DeleteTransaction(conn *sql.DB, ID string) error {
+// DeleteTransaction deletes a transaction. Its splits, transfers and
+// reimbursement links go with it through the schema's ON DELETE clauses.
+func DeleteTransaction(conn Querier, ID string) error {
 	queryStr := "DELETE FROM transactions WHERE id = ?"
 
 	_, err := conn.Exec(
@@ -478,3 +778,92 @@ func DeleteTransaction(conn *sql.DB, ID string) error {
 
 	return nil
 }
+
+// DeleteTransactions deletes every transaction in IDs in one SQL transaction.
+func DeleteTransactions(conn *sql.DB, IDs []string) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, ID := range IDs {
+		if err := DeleteTransaction(tx, ID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetTransactionsInRange returns every transaction between the given dates,
+// either of which may be empty for an open range. Unlike QueryTransactions it
+// keeps rows in ignored categories.
+func GetTransactionsInRange(conn *sql.DB, startDate string, endDate string) ([]Transaction, error) {
+	queryStr := "SELECT t.id, name, amount, date, account, source, description, c.id, c.label, is_reimbursement, rule_id, category_source, category_assigned_at FROM transactions AS t LEFT JOIN categories AS c ON category_id = c.id WHERE (? = '' OR date >= ?) AND (? = '' OR date <= ?) ORDER BY date, t.id"
+
+	rows, err := conn.Query(queryStr, startDate, startDate, endDate, endDate)
+	if err != nil {
+		return []Transaction{}, err
+	}
+	defer rows.Close()
+
+	transactions := []Transaction{}
+	for rows.Next() {
+		transaction := Transaction{}
+		if err := rows.Scan(
+			&transaction.ID,
+			&transaction.Name,
+			&transaction.Amount,
+			&transaction.Date,
+			&transaction.Account,
+			&transaction.Source,
+			&transaction.Description,
+			&transaction.CategoryID,
+			&transaction.CustomCategory,
+			&transaction.IsReimbursement,
+			&transaction.RuleID,
+			&transaction.CategorySource,
+			&transaction.CategoryAssignedAt,
+		); err != nil {
+			return []Transaction{}, err
+		}
+
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, rows.Err()
+}
+
+// RecategorizeTransaction moves current to categoryID, recording the rule
+// responsible or, without one, a category value match. It only applies while
+// the stored category and source still match current, so a transaction
+// recategorized since a preview was built is left alone; the returned bool
+// reports whether the row was updated.
+func RecategorizeTransaction(conn Querier, current Transaction, categoryID int, ruleID sql.NullInt64) (bool, error) {
+	source := CategorySourceImport
+	if ruleID.Valid {
+		source = CategorySourceRule
+	}
+
+	res, err := conn.Exec(
+		"UPDATE transactions SET category_id = ?, rule_id = ?, category_source = ?, category_assigned_at = ? WHERE id = ? AND category_id IS ? AND category_source IS ?",
+		categoryID,
+		ruleID,
+		source,
+		time.Now().UTC().Format(time.RFC3339),
+		current.ID,
+		current.CategoryID,
+		current.CategorySource,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"database/sql"
+	"strconv"
 	"testing"
 
 	"fin-web/internal/testutil"
@@ -101,3 +102,75 @@ func TestSpendingBreakdownRespectsStartDate(t *testing.T) {
 	assert.InDelta(t, 300, breakdown.Wants, 1e-6)
 	assert.InDelta(t, 300, breakdown.Savings(), 1e-6)
 }
+
+func TestUpdateTransactionRecordsManualSource(t *testing.T) {
+	db := testutil.NewDB(t)
+	rent := seedTypedCategory(t, db, "rent", 1, "fixed")
+	dining := seedTypedCategory(t, db, "dining", 2, "fun")
+	seedTransaction(t, db, "tx-1", 400, "2026-01-16", rent)
+	_, err := db.Exec("UPDATE transactions SET category_source = ? WHERE id = 'tx-1'", CategorySourceImport)
+	require.NoError(t, err)
+
+	// Resubmitting the same category keeps the automatic source.
+	require.NoError(t, UpdateTransaction(db, "tx-1", UpdateTransactionParams{CategoryID: &rent}))
+	tx, err := GetTransaction(db, "tx-1")
+	require.NoError(t, err)
+	assert.Equal(t, CategorySourceImport, tx.CategorySource.String)
+	assert.False(t, tx.CategoryAssignedAt.Valid)
+
+	require.NoError(t, UpdateTransaction(db, "tx-1", UpdateTransactionParams{CategoryID: &dining}))
+	tx, err = GetTransaction(db, "tx-1")
+	require.NoError(t, err)
+	assert.Equal(t, CategorySourceManual, tx.CategorySource.String)
+	assert.True(t, tx.CategoryAssignedAt.Valid)
+}
+
+func filterIDs(t *testing.T, db *sql.DB, filters QueryTransactionsFilters) []string {
+	t.Helper()
+	filters.OrderBy = "date"
+	filters.OrderDirection = "ASC"
+	txns, err := QueryTransactions(db, filters)
+	require.NoError(t, err)
+
+	ids := []string{}
+	for _, tx := range txns {
+		ids = append(ids, tx.ID)
+	}
+	return ids
+}
+
+func TestQueryTransactionsFilters(t *testing.T) {
+	db := testutil.NewDB(t)
+	dining := seedTypedCategory(t, db, "dining", 1, "fun")
+	for _, tx := range []Transaction{
+		{ID: "coffee", Name: "SQ *BLUE BOTTLE 4417", Amount: 6.5, Date: "2026-03-01", Source: "citi", Account: "citi", CategoryID: sql.NullInt32{Valid: true, Int32: int32(dining)}},
+		{ID: "books", Name: "AMAZON MKTPL*2K4", Amount: 31, Date: "2026-03-02", Source: "ofx", Account: "ofx_1234"},
+		{ID: "return", Name: "AMAZON.COM REFUND", Amount: -31, Date: "2026-03-03", Source: "ofx", Account: "ofx_1234", IsReimbursement: true},
+		{ID: "odd", Name: "A_B 100% DEALS", Amount: 12, Date: "2026-03-04", Source: "bofa", Account: "bofa"},
+	} {
+		require.NoError(t, CreateTransaction(db, tx))
+	}
+
+	low, high := 10.0, 40.0
+	yes, no := true, false
+
+	assert.Equal(t, []string{"books", "return"}, filterIDs(t, db, QueryTransactionsFilters{Accounts: []string{"ofx_1234"}}))
+	assert.Equal(t, []string{"coffee", "odd"}, filterIDs(t, db, QueryTransactionsFilters{Sources: []string{"citi", "bofa", ""}}))
+	assert.Equal(t, []string{"books", "odd"}, filterIDs(t, db, QueryTransactionsFilters{MinAmount: &low, MaxAmount: &high}))
+	assert.Equal(t, []string{"return"}, filterIDs(t, db, QueryTransactionsFilters{IsReimbursement: &yes}))
+	assert.Equal(t, []string{"coffee", "books", "odd"}, filterIDs(t, db, QueryTransactionsFilters{IsReimbursement: &no}))
+	assert.Equal(t, []string{"coffee"}, filterIDs(t, db, QueryTransactionsFilters{Categories: []string{strconv.Itoa(dining)}}))
+	assert.Equal(t, []string{"coffee", "books", "return", "odd"}, filterIDs(t, db, QueryTransactionsFilters{Categories: []string{""}}))
+
+	// Merchants match on the normalized key, so processor noise doesn't
+	// matter and LIKE wildcards in the filter are literal.
+	assert.Equal(t, []string{"books", "return"}, filterIDs(t, db, QueryTransactionsFilters{Merchant: "amazon"}))
+	assert.Equal(t, []string{"coffee"}, filterIDs(t, db, QueryTransactionsFilters{Merchant: "sq *blue bottle"}))
+	assert.Equal(t, []string{"odd"}, filterIDs(t, db, QueryTransactionsFilters{Merchant: "a_b 100%"}))
+	assert.Empty(t, filterIDs(t, db, QueryTransactionsFilters{Merchant: "a%"}))
+
+	// Sums take the same filters, allocations included.
+	total, err := SumTransactions(db, QueryTransactionsFilters{Accounts: []string{"ofx_1234"}, IsReimbursement: &no})
+	require.NoError(t, err)
+	assert.InDelta(t, 31, total, 1e-9)
+}
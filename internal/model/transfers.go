@@ -0,0 +1,164 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	TransferDetected  = "detected"
+	TransferConfirmed = "confirmed"
+	TransferUnlinked  = "unlinked"
+)
+
+// Transfer links the two sides of money moved between our own accounts.
+type Transfer struct {
+	ID        int
+	Outflow   Transaction
+	Inflow    Transaction
+	Status    string
+	CreatedAt string
+}
+
+// linkedTransfers selects the IDs of every transaction in a confirmed
+// transfer, for use in "t.id NOT IN" filters. Detected pairs are only
+// suggestions and keep counting until someone confirms them.
+const linkedTransfers = "SELECT outflow_id FROM transfers WHERE status = 'confirmed' UNION ALL SELECT inflow_id FROM transfers WHERE status = 'confirmed'"
+
+// liveTransfers selects the IDs of every transaction in a detected or
+// confirmed transfer, which detection must not pair again.
+const liveTransfers = "SELECT outflow_id FROM transfers WHERE status != 'unlinked' UNION ALL SELECT inflow_id FROM transfers WHERE status != 'unlinked'"
+
+// GetTransfers returns every detected or confirmed transfer, unconfirmed
+// first, newest first within each.
+func GetTransfers(conn *sql.DB) ([]Transfer, error) {
+	rows, err := conn.Query(
+		"SELECT tr.id, tr.status, tr.created_at, o.id, o.name, o.amount, o.date, o.account, i.id, i.name, i.amount, i.date, i.account FROM transfers AS tr JOIN transactions AS o ON tr.outflow_id = o.id JOIN transactions AS i ON tr.inflow_id = i.id WHERE tr.status != 'unlinked' ORDER BY tr.status = 'confirmed', o.date DESC, tr.id DESC",
+	)
+	if err != nil {
+		return []Transfer{}, err
+	}
+	defer rows.Close()
+
+	transfers := []Transfer{}
+	for rows.Next() {
+		transfer := Transfer{}
+		if err := rows.Scan(
+			&transfer.ID,
+			&transfer.Status,
+			&transfer.CreatedAt,
+			&transfer.Outflow.ID,
+			&transfer.Outflow.Name,
+			&transfer.Outflow.Amount,
+			&transfer.Outflow.Date,
+			&transfer.Outflow.Account,
+			&transfer.Inflow.ID,
+			&transfer.Inflow.Name,
+			&transfer.Inflow.Amount,
+			&transfer.Inflow.Date,
+			&transfer.Inflow.Account,
+		); err != nil {
+			return []Transfer{}, err
+		}
+
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, rows.Err()
+}
+
+// TransferCandidates returns the transactions not already in a live transfer.
+func TransferCandidates(conn *sql.DB) ([]Transaction, error) {
+	rows, err := conn.Query(
+		"SELECT id, name, amount, date, account, source FROM transactions WHERE id NOT IN (" + liveTransfers + ") ORDER BY date, id",
+	)
+	if err != nil {
+		return []Transaction{}, err
+	}
+	defer rows.Close()
+
+	transactions := []Transaction{}
+	for rows.Next() {
+		transaction := Transaction{}
+		if err := rows.Scan(
+			&transaction.ID,
+			&transaction.Name,
+			&transaction.Amount,
+			&transaction.Date,
+			&transaction.Account,
+			&transaction.Source,
+		); err != nil {
+			return []Transaction{}, err
+		}
+
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, rows.Err()
+}
+
+// UnlinkedTransferPairs returns the outflow and inflow IDs of every pair
+// someone unlinked.
+func UnlinkedTransferPairs(conn *sql.DB) (map[[2]string]bool, error) {
+	rows, err := conn.Query("SELECT outflow_id, inflow_id FROM transfers WHERE status = 'unlinked'")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pairs := map[[2]string]bool{}
+	for rows.Next() {
+		var pair [2]string
+		if err := rows.Scan(&pair[0], &pair[1]); err != nil {
+			return nil, err
+		}
+		pairs[pair] = true
+	}
+
+	return pairs, rows.Err()
+}
+
+// CreateTransfers stores detected pairs of outflow and inflow IDs in one SQL
+// transaction.
+func CreateTransfers(conn *sql.DB, pairs [][2]string) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	createdAt := time.Now().UTC().Format(time.RFC3339)
+	for _, pair := range pairs {
+		_, err := tx.Exec(
+			"INSERT INTO transfers(outflow_id, inflow_id, status, created_at) VALUES(?, ?, ?, ?)",
+			pair[0],
+			pair[1],
+			TransferDetected,
+			createdAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SetTransferStatus confirms or unlinks a live transfer. It returns
+// sql.ErrNoRows when there is no such transfer or it was already unlinked.
+func SetTransferStatus(conn *sql.DB, ID string, status string) error {
+	res, err := conn.Exec("UPDATE transfers SET status = ? WHERE id = ? AND status != 'unlinked'", status, ID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+// Package notify delivers alerts outside the browser. Each Notifier sends a
+// Message somewhere, such as an inbox or a webhook; deciding what is worth
+// sending, and not sending it twice, is left to the caller.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Message is one alert. Link, when set, is an absolute URL to the page the
+// alert is about.
+type Message struct {
+	Kind      string `json:"kind"`
+	Text      string `json:"text"`
+	Link      string `json:"link,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+type Notifier interface {
+	// Name identifies the notifier in errors and logs.
+	Name() string
+	Notify(ctx context.Context, msg Message) error
+}
+
+// Send delivers msg with every notifier, carrying on past failures, and
+// returns their errors joined.
+func Send(ctx context.Context, notifiers []Notifier, msg Message) error {
+	var errs []error
+	for _, n := range notifiers {
+		if err := n.Notify(ctx, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", n.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
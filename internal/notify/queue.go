@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// queueSize is how many messages may wait to be sent before new ones are
+// dropped.
+const queueSize = 100
+
+// Queue sends messages from a background goroutine, one at a time, so
+// whoever raises an alert doesn't wait on a slow mail server or webhook.
+// Failures are logged; there are no retries.
+type Queue struct {
+	notifiers []Notifier
+	timeout   time.Duration
+	messages  chan Message
+	done      chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewQueue starts a queue sending with notifiers, giving each message at
+// most timeout to reach all of them.
+func NewQueue(notifiers []Notifier, timeout time.Duration) *Queue {
+	q := &Queue{
+		notifiers: notifiers,
+		timeout:   timeout,
+		messages:  make(chan Message, queueSize),
+		done:      make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+func (q *Queue) run() {
+	defer close(q.done)
+	for msg := range q.messages {
+		ctx, cancel := context.WithTimeout(context.Background(), q.timeout)
+		if err := Send(ctx, q.notifiers, msg); err != nil {
+			log.Printf("error sending %s alert: %v", msg.Kind, err)
+		}
+		cancel()
+	}
+}
+
+// Enqueue queues msg without waiting. It reports false, dropping msg, when
+// the queue is full or closed.
+func (q *Queue) Enqueue(msg Message) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return false
+	}
+
+	select {
+	case q.messages <- msg:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops taking messages and waits for the queued ones to be sent.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.messages)
+	}
+	q.mu.Unlock()
+
+	<-q.done
+}
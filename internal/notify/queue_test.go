@@ -0,0 +1,68 @@
+package notify
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gatedNotifier holds every message until the gate is opened.
+type gatedNotifier struct {
+	gate chan struct{}
+
+	mu   sync.Mutex
+	sent []string
+}
+
+func (n *gatedNotifier) Name() string { return "gated" }
+
+func (n *gatedNotifier) Notify(ctx context.Context, msg Message) error {
+	select {
+	case <-n.gate:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent = append(n.sent, msg.Text)
+	return nil
+}
+
+func TestQueueSendsInTheBackground(t *testing.T) {
+	n := &gatedNotifier{gate: make(chan struct{})}
+	q := NewQueue([]Notifier{n}, time.Minute)
+
+	// Enqueueing doesn't wait for the notifier.
+	assert.True(t, q.Enqueue(Message{Text: "one"}))
+	assert.True(t, q.Enqueue(Message{Text: "two"}))
+
+	close(n.gate)
+	q.Close()
+	assert.Equal(t, []string{"one", "two"}, n.sent)
+
+	assert.False(t, q.Enqueue(Message{Text: "three"}), "closed")
+	q.Close()
+}
+
+func TestQueueDropsWhenFull(t *testing.T) {
+	n := &gatedNotifier{gate: make(chan struct{})}
+	q := NewQueue([]Notifier{n}, time.Minute)
+
+	// One message is taken by the sender; queueSize more wait behind it.
+	accepted := 0
+	for i := 0; i < queueSize+10; i++ {
+		if q.Enqueue(Message{Text: "spam"}) {
+			accepted++
+		}
+	}
+	assert.LessOrEqual(t, accepted, queueSize+1)
+	assert.GreaterOrEqual(t, accepted, queueSize)
+
+	close(n.gate)
+	q.Close()
+	assert.Len(t, n.sent, accepted)
+}
@@ -0,0 +1,117 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPNotifier emails each message to To through the server at Addr
+// (host:port). The connection is upgraded with STARTTLS whenever the server
+// offers it; Username and Password, when set, authenticate with PLAIN, which
+// net/smtp only allows over TLS or to localhost.
+type SMTPNotifier struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+func NewSMTPNotifier(addr, username, password, from string, to []string) *SMTPNotifier {
+	return &SMTPNotifier{
+		Addr:     addr,
+		Username: username,
+		Password: password,
+		From:     from,
+		To:       to,
+	}
+}
+
+func (n *SMTPNotifier) Name() string {
+	return "smtp"
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, msg Message) error {
+	host, _, err := net.SplitHostPort(n.Addr)
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", n.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+
+	if n.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", n.Username, n.Password, host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(n.From); err != nil {
+		return err
+	}
+	for _, to := range n.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(n.email(msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// email formats msg as a plain text email. The subject is the message text,
+// kept to one line.
+func (n *SMTPNotifier) email(msg Message) []byte {
+	subject := strings.Join(strings.Fields(msg.Text), " ")
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", n.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(n.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "fin-web: "+subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(msg.Text + "\r\n")
+	if msg.Link != "" {
+		b.WriteString("\r\n" + msg.Link + "\r\n")
+	}
+
+	return b.Bytes()
+}
@@ -0,0 +1,142 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTP is just enough of an SMTP server for net/smtp: it accepts one
+// session, offers AUTH PLAIN but not STARTTLS, and records what it was sent.
+type fakeSMTP struct {
+	addr     string
+	auth     string
+	from     string
+	rcpts    []string
+	data     string
+	rejectTo string
+	done     chan struct{}
+}
+
+func startFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	s := &fakeSMTP{addr: ln.Addr().String(), done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s.serve(conn)
+	}()
+
+	return s
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost fake")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch verb {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			s.auth = line
+			reply("235 ok")
+		case "MAIL":
+			s.from = line
+			reply("250 ok")
+		case "RCPT":
+			if s.rejectTo != "" && strings.Contains(line, s.rejectTo) {
+				reply("550 no such user")
+				continue
+			}
+			s.rcpts = append(s.rcpts, line)
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.data = data.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func (s *fakeSMTP) wait(t *testing.T) {
+	t.Helper()
+	select {
+	case <-s.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("fake smtp server never finished")
+	}
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	s := startFakeSMTP(t)
+	n := NewSMTPNotifier(s.addr, "me", "secret", "fin@example.com", []string{"a@example.com", "b@example.com"})
+
+	err := n.Notify(context.Background(), Message{
+		Kind: "large_transaction",
+		Text: "Large transaction: BEST BUY 1499.00 on 2026-06-01",
+		Link: "http://fin.local/transactions/abc",
+	})
+	require.NoError(t, err)
+	s.wait(t)
+
+	assert.True(t, strings.HasPrefix(s.auth, "AUTH PLAIN "))
+	assert.Equal(t, "MAIL FROM:<fin@example.com>", strings.Split(s.from, " BODY")[0])
+	assert.Equal(t, []string{"RCPT TO:<a@example.com>", "RCPT TO:<b@example.com>"}, s.rcpts)
+	assert.Contains(t, s.data, "Subject: fin-web: Large transaction: BEST BUY 1499.00 on 2026-06-01\r\n")
+	assert.Contains(t, s.data, "To: a@example.com, b@example.com\r\n")
+	assert.Contains(t, s.data, "\r\n\r\nLarge transaction: BEST BUY 1499.00 on 2026-06-01\r\n")
+	assert.Contains(t, s.data, "http://fin.local/transactions/abc")
+}
+
+func TestSMTPNotifierRejectedRecipient(t *testing.T) {
+	s := startFakeSMTP(t)
+	s.rejectTo = "nobody@example.com"
+	n := NewSMTPNotifier(s.addr, "", "", "fin@example.com", []string{"nobody@example.com"})
+
+	err := n.Notify(context.Background(), Message{Text: "hello"})
+	assert.ErrorContains(t, err, "550")
+	s.wait(t)
+	assert.Empty(t, s.auth, "no credentials, no AUTH")
+}
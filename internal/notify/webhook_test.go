@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	var got Message
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	msg := Message{Kind: "budget_over", Text: "Dining is over budget", Link: "http://fin.local/budgets", CreatedAt: "2026-06-01T00:00:00Z"}
+	require.NoError(t, NewWebhookNotifier(srv.URL).Notify(context.Background(), msg))
+	assert.Equal(t, msg, got)
+}
+
+func TestWebhookNotifierErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	err := NewWebhookNotifier(srv.URL).Notify(context.Background(), Message{Text: "hello"})
+	assert.ErrorContains(t, err, "502")
+	assert.ErrorContains(t, err, "boom")
+}
+
+type fakeNotifier struct {
+	name string
+	err  error
+	sent []Message
+}
+
+func (f *fakeNotifier) Name() string { return f.name }
+
+func (f *fakeNotifier) Notify(ctx context.Context, msg Message) error {
+	f.sent = append(f.sent, msg)
+	return f.err
+}
+
+func TestSendCarriesOnPastFailures(t *testing.T) {
+	failing := &fakeNotifier{name: "failing", err: errors.New("down")}
+	ok := &fakeNotifier{name: "ok"}
+
+	err := Send(context.Background(), []Notifier{failing, ok}, Message{Text: "hello"})
+	assert.EqualError(t, err, "failing: down")
+	assert.Len(t, ok.sent, 1)
+}
@@ -0,0 +1,218 @@
+package ofx
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"fin-web/internal/model"
+
+	"github.com/google/uuid"
+)
+
+type Provider struct {
+	DB *sql.DB
+}
+
+func NewOfxProvider(db *sql.DB) *Provider {
+	return &Provider{
+		DB: db,
+	}
+}
+
+func (p *Provider) GetPrefix() string {
+	return "ofx"
+}
+
+// Detect claims OFX 1.x files by their SGML header and OFX 2.x files by the
+// <?OFX ?> processing instruction. QFX is OFX with extra Quicken tags, so it
+// is claimed too.
+func (p *Provider) Detect(header []byte) int {
+	h := strings.ToUpper(string(header))
+	switch {
+	case strings.HasPrefix(strings.TrimSpace(h), "OFXHEADER:"), strings.Contains(h, "<?OFX "):
+		return 100
+	case strings.Contains(h, "<OFX>"):
+		return 80
+	}
+	return 0
+}
+
+// tagPattern matches an opening or closing tag and the text up to the next tag
+// or line break. SGML leaf elements have no closing tag, so this reads both
+// "<TRNAMT>-5.75" and "<TRNAMT>-5.75</TRNAMT>" the same way.
+var tagPattern = regexp.MustCompile(`<(/?)([A-Za-z0-9.]+)>([^<\r\n]*)`)
+
+type statementTransaction struct {
+	datePosted string
+	amount     string
+	fitID      string
+	name       string
+	memo       string
+}
+
+func (p *Provider) ParseFile(filePath string) ([]model.Transaction, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := tagPattern.FindAllStringSubmatch(string(data), -1)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no OFX elements found")
+	}
+
+	var (
+		org, accountID string
+		current        *statementTransaction
+		transactions   []model.Transaction
+	)
+	for _, m := range matches {
+		closing := m[1] == "/"
+		tag := strings.ToUpper(m[2])
+		value := strings.TrimSpace(html.UnescapeString(m[3]))
+
+		if tag == "STMTTRN" {
+			if !closing {
+				current = &statementTransaction{}
+				continue
+			}
+			if current == nil {
+				continue
+			}
+
+			t, err := p.toTransaction(*current, org, accountID)
+			if err != nil {
+				return nil, err
+			}
+			transactions = append(transactions, t)
+			current = nil
+			continue
+		}
+
+		if closing || value == "" {
+			continue
+		}
+
+		if current == nil {
+			switch tag {
+			case "ORG":
+				org = value
+			case "ACCTID":
+				accountID = value
+			}
+			continue
+		}
+
+		switch tag {
+		case "DTPOSTED":
+			current.datePosted = value
+		case "TRNAMT":
+			current.amount = value
+		case "FITID":
+			current.fitID = value
+		case "NAME":
+			current.name = value
+		case "MEMO":
+			current.memo = value
+		}
+	}
+
+	return transactions, nil
+}
+
+func (p *Provider) toTransaction(st statementTransaction, org string, accountID string) (model.Transaction, error) {
+	date, err := parseDate(st.datePosted)
+	if err != nil {
+		return model.Transaction{}, fmt.Errorf("failed to parse date %q: %w", st.datePosted, err)
+	}
+
+	amount, err := parseAmount(st.amount)
+	if err != nil {
+		return model.Transaction{}, fmt.Errorf("failed to parse amount %q: %w", st.amount, err)
+	}
+
+	name := st.name
+	if name == "" {
+		name = st.memo
+	}
+
+	var cc sql.NullInt32
+	categories, _ := model.SearchCategories(p.DB, []string{strings.ToLower(name)})
+	if len(categories) > 0 {
+		cc = sql.NullInt32{Valid: true, Int32: int32(categories[0].ID)}
+	}
+
+	source := sourceName(org)
+	t := model.Transaction{
+		ID:         uuid.NewString(),
+		Name:       name,
+		Source:     source,
+		Account:    accountName(source, accountID),
+		Date:       date.Format("2006-01-02"),
+		Amount:     amount,
+		CategoryID: cc,
+	}
+
+	if st.fitID != "" {
+		t.Fingerprint = fingerprint(accountID, st.fitID)
+	}
+
+	return t, nil
+}
+
+// fingerprint keys a row on its FITID. Banks only promise FITIDs are unique
+// within an account, so the account is part of the key.
+func fingerprint(accountID string, fitID string) string {
+	sum := sha256.Sum256([]byte("ofx|" + accountID + "|" + fitID))
+	return hex.EncodeToString(sum[:])
+}
+
+// parseDate reads the YYYYMMDD prefix of an OFX datetime, ignoring any time,
+// fractional seconds and [offset:TZ] suffix.
+func parseDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("too short")
+	}
+	return time.Parse("20060102", value[:8])
+}
+
+// parseAmount flips OFX's sign convention, where debits are negative, onto
+// ours, where expenses are positive. OFX allows a comma as the decimal point.
+func parseAmount(value string) (float64, error) {
+	if !strings.Contains(value, ".") {
+		value = strings.Replace(value, ",", ".", 1)
+	}
+
+	val, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return val * -1, nil
+}
+
+func sourceName(org string) string {
+	if org == "" {
+		return "ofx"
+	}
+	return strings.ToLower(strings.Join(strings.Fields(org), "_"))
+}
+
+// accountName keeps only the last four digits of the account number.
+func accountName(source string, accountID string) string {
+	if len(accountID) > 4 {
+		accountID = accountID[len(accountID)-4:]
+	}
+	if accountID == "" {
+		return source
+	}
+	return source + "_" + accountID
+}
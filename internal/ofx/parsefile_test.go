@@ -0,0 +1,120 @@
+package ofx
+
+import (
+	"os"
+	"testing"
+
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFileSGML(t *testing.T) {
+	db := testutil.NewDB(t)
+	testutil.SeedCategory(t, db, "Coffee", 5, "starbucks")
+
+	p := NewOfxProvider(db)
+	txns, err := p.ParseFile("testdata/sample.ofx")
+	require.NoError(t, err)
+	require.Len(t, txns, 3)
+
+	// Debit -> positive amount, categorized, keyed on its FITID.
+	coffee := txns[0]
+	assert.Equal(t, "STARBUCKS STORE 123", coffee.Name)
+	assert.Equal(t, "chase_bank", coffee.Source)
+	assert.Equal(t, "chase_bank_6789", coffee.Account)
+	assert.Equal(t, "2026-02-04", coffee.Date)
+	assert.InDelta(t, 5.75, coffee.Amount, 1e-9)
+	assert.True(t, coffee.CategoryID.Valid, "starbucks should be categorized")
+	assert.Equal(t, fingerprint("000123456789", "202602040001"), coffee.Fingerprint)
+
+	// Credit -> negative amount.
+	deposit := txns[1]
+	assert.Equal(t, "PAYROLL DEPOSIT", deposit.Name)
+	assert.InDelta(t, -2500.00, deposit.Amount, 1e-9)
+	assert.False(t, deposit.CategoryID.Valid)
+
+	// Identical rows stay distinct because their FITIDs differ.
+	assert.NotEqual(t, coffee.Fingerprint, txns[2].Fingerprint)
+}
+
+func TestParseFileXML(t *testing.T) {
+	p := NewOfxProvider(testutil.NewDB(t))
+	txns, err := p.ParseFile("testdata/sample.qfx")
+	require.NoError(t, err)
+	require.Len(t, txns, 3)
+
+	assert.Equal(t, "WHOLE FOODS MARKET", txns[0].Name)
+	assert.Equal(t, "amex", txns[0].Source)
+	assert.Equal(t, "amex_1009", txns[0].Account)
+	assert.Equal(t, "2026-02-10", txns[0].Date)
+	assert.InDelta(t, 42.10, txns[0].Amount, 1e-9)
+
+	// NAME nested in PAYEE is still picked up.
+	assert.Equal(t, "PAYMENT - THANK YOU", txns[1].Name)
+	assert.InDelta(t, -200.00, txns[1].Amount, 1e-9)
+
+	assert.Equal(t, "BARNES & NOBLE", txns[2].Name)
+}
+
+func TestParseFileSameRowsDedupe(t *testing.T) {
+	p := NewOfxProvider(testutil.NewDB(t))
+	first, err := p.ParseFile("testdata/sample.ofx")
+	require.NoError(t, err)
+	second, err := p.ParseFile("testdata/sample.ofx")
+	require.NoError(t, err)
+
+	for i := range first {
+		assert.NotEqual(t, first[i].ID, second[i].ID)
+		assert.Equal(t, first[i].Fingerprint, second[i].Fingerprint)
+	}
+}
+
+func TestParseFileMissingFile(t *testing.T) {
+	p := NewOfxProvider(testutil.NewDB(t))
+	_, err := p.ParseFile("testdata/does-not-exist.ofx")
+	require.Error(t, err)
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    float64
+		wantErr bool
+	}{
+		{name: "debit flips to positive", input: "-25.00", want: 25},
+		{name: "credit flips to negative", input: "100.00", want: -100},
+		{name: "comma decimal", input: "-12,50", want: 12.5},
+		{name: "non-numeric", input: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAmount(tt.input)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGetPrefix(t *testing.T) {
+	assert.Equal(t, "ofx", NewOfxProvider(nil).GetPrefix())
+}
+
+func TestDetect(t *testing.T) {
+	p := NewOfxProvider(nil)
+
+	for _, file := range []string{"testdata/sample.ofx", "testdata/sample.qfx"} {
+		header, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, 100, p.Detect(header), file)
+	}
+
+	assert.Equal(t, 0, p.Detect([]byte("Date,Reference Number,Payee,Address,Amount\n")))
+}
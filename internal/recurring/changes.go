@@ -0,0 +1,176 @@
+package recurring
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Change detection thresholds.
+const (
+	// PriceChangeMin is the relative difference between a series' latest
+	// charge and its earlier steady price that counts as a price change.
+	PriceChangeMin = 0.05
+	// missedGraceFactor is how many cadence-lengths past its expected date a
+	// charge may be late before it counts as missed. At least
+	// missedGraceMinDays are always allowed.
+	missedGraceFactor  = 0.25
+	missedGraceMinDays = 3
+)
+
+// Change kinds.
+const (
+	ChangePriceIncrease = "price_increase"
+	ChangePriceDecrease = "price_decrease"
+	ChangeNewSeries     = "new_series"
+	ChangeMissed        = "missed"
+)
+
+// Change is something worth telling someone about a recurring series. Date
+// is the charge it concerns, or for ChangeMissed the date the charge was
+// expected. Previous is the steady price before a price change.
+type Change struct {
+	Kind     string
+	Merchant string
+	Name     string
+	Date     string
+	Amount   float64
+	Previous float64
+}
+
+// Key identifies the change so it is only recorded once however often
+// detection runs.
+func (c Change) Key() string {
+	switch c.Kind {
+	case ChangeNewSeries:
+		return c.Kind + ":" + c.Merchant
+	default:
+		return c.Kind + ":" + c.Merchant + ":" + c.Date
+	}
+}
+
+// Message describes the change in a sentence.
+func (c Change) Message() string {
+	switch c.Kind {
+	case ChangePriceIncrease:
+		return fmt.Sprintf("%s went up from %.2f to %.2f on %s", c.Name, c.Previous, c.Amount, c.Date)
+	case ChangePriceDecrease:
+		return fmt.Sprintf("%s went down from %.2f to %.2f on %s", c.Name, c.Previous, c.Amount, c.Date)
+	case ChangeNewSeries:
+		return fmt.Sprintf("New recurring charge: %s, %.2f on %s", c.Name, c.Amount, c.Date)
+	case ChangeMissed:
+		return fmt.Sprintf("%s was expected around %s but hasn't charged", c.Name, c.Date)
+	default:
+		return c.Name + ": " + c.Kind
+	}
+}
+
+// DetectChanges runs detection with decisions and compares each active or
+// possible series' charges with each other:
+//   - a steady price followed by a latest charge more than PriceChangeMin
+//     away from it is a price change;
+//   - a series with no more charges than detection needs is new;
+//   - an active series whose next charge is overdue has missed it.
+//
+// Ignored series are left out. Changes are sorted by date, newest first.
+func DetectChanges(charges []Charge, now time.Time, decisions map[string]Decision) []Change {
+	groups := groupCharges(charges, decisions)
+	report := detect(groups, now, decisions)
+
+	var changes []Change
+	active := append(append([]Recurring{}, report.Subscriptions...), report.Bills...)
+	for _, r := range active {
+		if c, ok := priceChange(r, groups[r.Merchant]); ok {
+			changes = append(changes, c)
+		}
+		if c, ok := missedCharge(r, now); ok {
+			changes = append(changes, c)
+		}
+	}
+
+	for _, r := range append(active, report.Possible...) {
+		if r.Count <= MinOccurrences {
+			changes = append(changes, Change{
+				Kind:     ChangeNewSeries,
+				Merchant: r.Merchant,
+				Name:     r.Name,
+				Date:     r.Last,
+				Amount:   r.TypicalAmt,
+			})
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Date != changes[j].Date {
+			return changes[i].Date > changes[j].Date
+		}
+		return changes[i].Key() < changes[j].Key()
+	})
+
+	return changes
+}
+
+// priceChange compares the latest of pcs, sorted by date, with the charges
+// before it. Those need a steady price, or a variable bill would change
+// price every month.
+func priceChange(r Recurring, pcs []parsedCharge) (Change, bool) {
+	if len(pcs) < 2 {
+		return Change{}, false
+	}
+
+	earlier := make([]float64, len(pcs)-1)
+	for i, p := range pcs[:len(pcs)-1] {
+		earlier[i] = p.amount
+	}
+	if cv(earlier) > AmountCVMax {
+		return Change{}, false
+	}
+
+	previous := median(earlier)
+	latest := pcs[len(pcs)-1]
+	if previous == 0 || math.Abs(latest.amount-previous)/math.Abs(previous) <= PriceChangeMin {
+		return Change{}, false
+	}
+
+	kind := ChangePriceIncrease
+	if latest.amount < previous {
+		kind = ChangePriceDecrease
+	}
+
+	return Change{
+		Kind:     kind,
+		Merchant: r.Merchant,
+		Name:     r.Name,
+		Date:     latest.date.Format(dateLayout),
+		Amount:   latest.amount,
+		Previous: previous,
+	}, true
+}
+
+// missedCharge reports r's next charge once it is late by more than the
+// grace period.
+func missedCharge(r Recurring, now time.Time) (Change, bool) {
+	last, err := time.Parse(dateLayout, r.Last)
+	if err != nil {
+		return Change{}, false
+	}
+	next, err := time.Parse(dateLayout, r.Next)
+	if err != nil {
+		return Change{}, false
+	}
+
+	gap := next.Sub(last).Hours() / 24
+	grace := max(missedGraceMinDays, gap*missedGraceFactor)
+	if calendarDate(now).Sub(next).Hours()/24 <= grace {
+		return Change{}, false
+	}
+
+	return Change{
+		Kind:     ChangeMissed,
+		Merchant: r.Merchant,
+		Name:     r.Name,
+		Date:     r.Next,
+		Amount:   r.TypicalAmt,
+	}, true
+}
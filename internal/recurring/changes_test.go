@@ -0,0 +1,92 @@
+package recurring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func changeKinds(changes []Change, merchant string) []string {
+	var kinds []string
+	for _, c := range changes {
+		if c.Merchant == merchant {
+			kinds = append(kinds, c.Kind)
+		}
+	}
+	return kinds
+}
+
+func TestDetectChangesPrice(t *testing.T) {
+	up := monthlyCharges("NETFLIX.COM", 15.49, 6, 5)
+	up[len(up)-1].Amount = 17.99
+	down := monthlyCharges("HULU", 17.99, 6, 5)
+	down[len(down)-1].Amount = 7.99
+	// A few cents of tax drift is no change.
+	same := monthlyCharges("SPOTIFY", 11.99, 6, 5)
+	same[len(same)-1].Amount = 12.05
+	// Utilities vary every month, so their latest charge isn't a change.
+	utility := []Charge{
+		{Name: "PEPCO", Amount: 180, Date: "2026-02-15"},
+		{Name: "PEPCO", Amount: 240, Date: "2026-03-15"},
+		{Name: "PEPCO", Amount: 150, Date: "2026-04-15"},
+		{Name: "PEPCO", Amount: 210, Date: "2026-05-15"},
+		{Name: "PEPCO", Amount: 320, Date: "2026-06-15"},
+	}
+
+	var charges []Charge
+	for _, cs := range [][]Charge{up, down, same, utility} {
+		charges = append(charges, cs...)
+	}
+	changes := DetectChanges(charges, now, nil)
+
+	assert.Equal(t, []string{ChangePriceIncrease}, changeKinds(changes, "NETFLIX.COM"))
+	assert.Equal(t, []string{ChangePriceDecrease}, changeKinds(changes, "HULU"))
+	assert.Empty(t, changeKinds(changes, "SPOTIFY"))
+	assert.Empty(t, changeKinds(changes, "PEPCO"))
+
+	for _, c := range changes {
+		if c.Merchant == "NETFLIX.COM" {
+			assert.InDelta(t, 15.49, c.Previous, 1e-9)
+			assert.InDelta(t, 17.99, c.Amount, 1e-9)
+			assert.Equal(t, up[len(up)-1].Date, c.Date)
+			assert.Equal(t, "price_increase:NETFLIX.COM:"+c.Date, c.Key())
+			assert.Contains(t, c.Message(), "from 15.49 to 17.99")
+		}
+	}
+}
+
+func TestDetectChangesNewAndMissed(t *testing.T) {
+	var charges []Charge
+	charges = append(charges, monthlyCharges("NEW APP", 4.99, 3, 3)...)
+	charges = append(charges, monthlyCharges("OLD APP", 9.99, 8, 3)...)
+	// Last charged 40 days ago on a monthly cadence: ten days late, but not
+	// yet canceled.
+	charges = append(charges, monthlyCharges("LATE GYM", 40, 6, 40)...)
+	charges = append(charges, monthlyCharges("IGNORED APP", 2.99, 3, 3)...)
+
+	changes := DetectChanges(charges, now, map[string]Decision{
+		"IGNORED APP": {Key: "IGNORED APP", Status: DecisionIgnored},
+	})
+
+	assert.Equal(t, []string{ChangeNewSeries}, changeKinds(changes, "NEW APP"))
+	assert.Empty(t, changeKinds(changes, "OLD APP"))
+	assert.Equal(t, []string{ChangeMissed}, changeKinds(changes, "LATE GYM"))
+	assert.Empty(t, changeKinds(changes, "IGNORED APP"))
+
+	for _, c := range changes {
+		if c.Kind == ChangeNewSeries {
+			assert.Equal(t, "new_series:NEW APP", c.Key())
+		}
+	}
+
+	// Newest first.
+	require.Len(t, changes, 2)
+	assert.Greater(t, changes[0].Date, changes[1].Date)
+}
+
+func TestDetectChangesMissedGrace(t *testing.T) {
+	// Due two days ago: within the grace period.
+	charges := monthlyCharges("LATE GYM", 40, 6, 32)
+	assert.Empty(t, changeKinds(DetectChanges(charges, now, nil), "LATE GYM"))
+}
@@ -2,7 +2,8 @@
 // flat list of expense transactions. It groups charges by normalized merchant,
 // measures cadence regularity and amount stability, and classifies each group
 // as an active subscription, a recurring bill, a canceled series, or a
-// low-confidence "possible" match. The logic is pure and DB-free so it can be
+// low-confidence "possible" match. DetectChanges reports price changes, new
+// series and missed charges. The logic is pure and DB-free so it can be
 // unit-tested and reused by both the web page and the calibration tool.
 package recurring
 
@@ -42,9 +43,57 @@ type Charge struct {
 	Date   string // "2006-01-02"
 }
 
+// Decision statuses.
+const (
+	// DecisionConfirmed keeps a series recurring even when its cadence or
+	// charge count wouldn't pass detection.
+	DecisionConfirmed = "confirmed"
+	// DecisionIgnored drops a series from every list but Report.Ignored.
+	DecisionIgnored = "ignored"
+)
+
+// Cadences are the cadences a Decision can force.
+var Cadences = []string{"weekly", "biweekly", "monthly", "quarterly", "annual"}
+
+// cadenceDays is the nominal gap for each forced cadence.
+var cadenceDays = map[string]float64{
+	"weekly":    7,
+	"biweekly":  14,
+	"monthly":   30.44,
+	"quarterly": 91.31,
+	"annual":    365.25,
+}
+
+// Decision is what someone has said about one normalized merchant key. Every
+// field is optional. MergeInto folds the key's charges into another key's
+// series; the other fields then have no effect.
+type Decision struct {
+	Key         string
+	Status      string // "" | DecisionConfirmed | DecisionIgnored
+	DisplayName string
+	MergeInto   string
+	Cadence     string // one of Cadences, or "" to detect
+}
+
+// Resolve follows key's merges to the key its charges are grouped under.
+// Merge cycles stop at the last key before repeating.
+func Resolve(key string, decisions map[string]Decision) string {
+	seen := map[string]bool{key: true}
+	for {
+		next := decisions[key].MergeInto
+		if next == "" || seen[next] {
+			return key
+		}
+		seen[next] = true
+		key = next
+	}
+}
+
 // Recurring is a detected recurring charge for one merchant.
 type Recurring struct {
-	Merchant    string
+	Merchant string
+	// Name is the display name, or Merchant when none is set.
+	Name        string
 	Cadence     string // weekly | biweekly | monthly | quarterly | annual
 	Count       int
 	TypicalAmt  float64
@@ -55,6 +104,8 @@ type Recurring struct {
 	Next        string // projected next charge date
 	Active      bool
 	Kind        string // "sub" | "bill"
+	// Decision is the saved decision applied to the series, if any.
+	Decision Decision
 }
 
 // Report is the full detection result, bucketed for presentation.
@@ -63,6 +114,7 @@ type Report struct {
 	Bills            []Recurring // active, bill-sized (rent/utilities)
 	Canceled         []Recurring // regular cadence but no recent charge
 	Possible         []Recurring // only 2 charges: low-confidence new/annual
+	Ignored          []Recurring // dismissed by a Decision
 	MonthlySubTotal  float64
 	MonthlyBillTotal float64
 }
@@ -75,6 +127,16 @@ type parsedCharge struct {
 // Detect groups charges by normalized merchant and classifies each group.
 // `now` anchors the active/canceled decision (pass time.Now()).
 func Detect(charges []Charge, now time.Time) Report {
+	return DetectWith(charges, now, nil)
+}
+
+// DetectWith is Detect with decisions, keyed by merchant key, applied on top.
+func DetectWith(charges []Charge, now time.Time, decisions map[string]Decision) Report {
+	return detect(groupCharges(charges, decisions), now, decisions)
+}
+
+// groupCharges buckets charges under their resolved merchant keys.
+func groupCharges(charges []Charge, decisions map[string]Decision) map[string][]parsedCharge {
 	groups := map[string][]parsedCharge{}
 	for _, c := range charges {
 		d, err := time.Parse(dateLayout, c.Date)
@@ -85,19 +147,34 @@ func Detect(charges []Charge, now time.Time) Report {
 		if key == "" {
 			continue
 		}
+		key = Resolve(key, decisions)
 		groups[key] = append(groups[key], parsedCharge{date: d, amount: c.Amount})
 	}
+	return groups
+}
 
+// detect classifies each group. analyze sorts the groups' charges by date
+// in place, which DetectChanges relies on.
+func detect(groups map[string][]parsedCharge, now time.Time, decisions map[string]Decision) Report {
 	var report Report
 	for key, pcs := range groups {
-		if len(pcs) < 2 {
+		decision := decisions[key]
+		// With a forced cadence a single charge can be projected, which is
+		// how a confirmed annual series shows up after its first charge.
+		if len(pcs) < 2 && decision.Cadence == "" {
 			continue
 		}
-		r, gapCV, cadenceOK := analyze(key, pcs, now)
-		regular := cadenceOK && gapCV <= GapCVMax
+		r, gapCV, cadenceOK := analyze(key, pcs, now, decision.Cadence)
+		r.Decision = decision
+		if decision.DisplayName != "" {
+			r.Name = decision.DisplayName
+		}
+		regular := cadenceOK && (gapCV <= GapCVMax || decision.Cadence != "")
 
 		switch {
-		case r.Count >= MinOccurrences && regular:
+		case decision.Status == DecisionIgnored:
+			report.Ignored = append(report.Ignored, r)
+		case decision.Status == DecisionConfirmed || (r.Count >= MinOccurrences && regular):
 			switch {
 			case !r.Active:
 				report.Canceled = append(report.Canceled, r)
@@ -122,11 +199,81 @@ func Detect(charges []Charge, now time.Time) Report {
 	byAnnualDesc(report.Bills)
 	byAnnualDesc(report.Canceled)
 	byAnnualDesc(report.Possible)
+	byAnnualDesc(report.Ignored)
 
 	return report
 }
 
-func analyze(key string, pcs []parsedCharge, now time.Time) (Recurring, float64, bool) {
+// Occurrence is one projected charge of a recurring series.
+type Occurrence struct {
+	Merchant string
+	Name     string
+	Cadence  string
+	Kind     string
+	Date     string
+	Amount   float64
+}
+
+// Upcoming projects each series forward from its Next date, one cadence at a
+// time, and returns the charges dated after from and on or before to, soonest
+// first. Each charge is expected at the series' typical amount. Only the
+// calendar dates of from and to matter.
+func Upcoming(rs []Recurring, from, to time.Time) []Occurrence {
+	from, to = calendarDate(from), calendarDate(to)
+
+	var out []Occurrence
+	for _, r := range rs {
+		last, err := time.Parse(dateLayout, r.Last)
+		if err != nil {
+			continue
+		}
+		next, err := time.Parse(dateLayout, r.Next)
+		if err != nil {
+			continue
+		}
+		gap := int(math.Round(next.Sub(last).Hours() / 24))
+		if gap <= 0 {
+			continue
+		}
+		name := r.Name
+		if name == "" {
+			name = r.Merchant
+		}
+
+		for d := next; !d.After(to); d = d.AddDate(0, 0, gap) {
+			if !d.After(from) {
+				continue
+			}
+			out = append(out, Occurrence{
+				Merchant: r.Merchant,
+				Name:     name,
+				Cadence:  r.Cadence,
+				Kind:     r.Kind,
+				Date:     d.Format(dateLayout),
+				Amount:   r.TypicalAmt,
+			})
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Date != out[j].Date {
+			return out[i].Date < out[j].Date
+		}
+		return out[i].Merchant < out[j].Merchant
+	})
+
+	return out
+}
+
+// calendarDate is t's date at midnight UTC, which is how charge dates parse.
+func calendarDate(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// analyze measures one merchant's charges. A forced cadence replaces the
+// median gap with the cadence's nominal one.
+func analyze(key string, pcs []parsedCharge, now time.Time, forced string) (Recurring, float64, bool) {
 	sort.Slice(pcs, func(i, j int) bool { return pcs[i].date.Before(pcs[j].date) })
 
 	gaps := make([]float64, 0, len(pcs)-1)
@@ -141,10 +288,15 @@ func analyze(key string, pcs []parsedCharge, now time.Time) (Recurring, float64,
 	medGap := median(gaps)
 	medAmt := median(amounts)
 	cadence := classifyCadence(medGap)
+	if forced != "" {
+		cadence = forced
+		medGap = cadenceDays[forced]
+	}
 	last := pcs[len(pcs)-1].date
 
 	r := Recurring{
 		Merchant:    key,
+		Name:        key,
 		Cadence:     cadence,
 		Count:       len(pcs),
 		TypicalAmt:  medAmt,
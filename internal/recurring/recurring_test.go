@@ -104,3 +104,97 @@ func TestDetectNextExpected(t *testing.T) {
 	require.NoError(t, err)
 	assert.InDelta(t, 30, next.Sub(now).Hours()/24, 1)
 }
+
+func TestUpcoming(t *testing.T) {
+	rs := []Recurring{
+		{Merchant: "NETFLIX.COM", Cadence: "monthly", TypicalAmt: 15.49, Last: "2026-06-10", Next: "2026-07-10"},
+		{Merchant: "GYM", Cadence: "weekly", TypicalAmt: 10, Last: "2026-06-28", Next: "2026-07-05"},
+		{Merchant: "NO NEXT", TypicalAmt: 99, Last: "2026-06-28"},
+	}
+
+	got := Upcoming(rs, now.Add(13*time.Hour), time.Date(2026, 7, 19, 23, 0, 0, 0, time.FixedZone("UTC+9", 9*3600)))
+	var dates []string
+	for _, o := range got {
+		dates = append(dates, o.Date+" "+o.Merchant)
+	}
+	// From excludes its own day, to includes its own calendar day.
+	assert.Equal(t, []string{"2026-07-05 GYM", "2026-07-10 NETFLIX.COM", "2026-07-12 GYM", "2026-07-19 GYM"}, dates)
+	assert.InDelta(t, 15.49, got[1].Amount, 1e-9)
+}
+
+func TestDetectWithDecisions(t *testing.T) {
+	var charges []Charge
+	charges = append(charges, monthlyCharges("NETFLIX.COM", 15.49, 5, 10)...)
+	charges = append(charges, monthlyCharges("HULU", 7.99, 4, 12)...)
+	charges = append(charges, monthlyCharges("GYM ONE", 40, 2, 8)...)
+	charges = append(charges, Charge{Name: "PARKING PERMIT", Amount: 1200, Date: "2026-06-20"})
+	charges = append(charges, Charge{Name: "DOMAIN RENEWAL", Amount: 20, Date: "2026-06-15"})
+
+	decisions := map[string]Decision{
+		"NETFLIX.COM":    {Key: "NETFLIX.COM", DisplayName: "Netflix"},
+		"HULU":           {Key: "HULU", Status: DecisionIgnored},
+		"GYM ONE":        {Key: "GYM ONE", Status: DecisionConfirmed},
+		"PARKING PERMIT": {Key: "PARKING PERMIT", Status: DecisionConfirmed, Cadence: "annual"},
+		"DOMAIN RENEWAL": {Key: "DOMAIN RENEWAL", Cadence: "annual"},
+	}
+
+	report := DetectWith(charges, now, decisions)
+
+	assert.ElementsMatch(t, []string{"NETFLIX.COM", "GYM ONE", "PARKING PERMIT"}, merchants(report.Subscriptions))
+	for _, r := range report.Subscriptions {
+		if r.Merchant == "NETFLIX.COM" {
+			assert.Equal(t, "Netflix", r.Name)
+		}
+	}
+
+	// Two charges would only be possible, but confirming makes it active.
+	assert.Empty(t, report.Possible)
+
+	require.Len(t, report.Ignored, 1)
+	assert.Equal(t, "HULU", report.Ignored[0].Merchant)
+	assert.Equal(t, "HULU", report.Ignored[0].Name)
+
+	// A confirmed single charge with a forced cadence is projected a year
+	// out; without confirming, one charge isn't enough.
+	for _, r := range report.Subscriptions {
+		if r.Merchant == "PARKING PERMIT" {
+			assert.Equal(t, "annual", r.Cadence)
+			assert.Equal(t, "2027-06-20", r.Next)
+			assert.InDelta(t, 100, r.Monthly, 0.1)
+		}
+	}
+	assert.NotContains(t, merchants(report.Possible), "DOMAIN RENEWAL")
+}
+
+func TestDetectWithMerge(t *testing.T) {
+	charges := []Charge{
+		{Name: "APPLE.COM/BILL", Amount: 2.99, Date: "2026-03-05"},
+		{Name: "ITUNES.COM", Amount: 2.99, Date: "2026-04-05"},
+		{Name: "APPLE.COM/BILL", Amount: 2.99, Date: "2026-05-05"},
+		{Name: "ITUNES.COM", Amount: 2.99, Date: "2026-06-05"},
+	}
+
+	report := Detect(charges, now)
+	assert.Empty(t, report.Subscriptions)
+
+	report = DetectWith(charges, now, map[string]Decision{
+		"ITUNES.COM": {Key: "ITUNES.COM", MergeInto: "APPLE.COM/BILL"},
+	})
+	require.Len(t, report.Subscriptions, 1)
+	assert.Equal(t, "APPLE.COM/BILL", report.Subscriptions[0].Merchant)
+	assert.Equal(t, 4, report.Subscriptions[0].Count)
+}
+
+func TestResolve(t *testing.T) {
+	decisions := map[string]Decision{
+		"A": {Key: "A", MergeInto: "B"},
+		"B": {Key: "B", MergeInto: "C"},
+		"X": {Key: "X", MergeInto: "Y"},
+		"Y": {Key: "Y", MergeInto: "X"},
+	}
+
+	assert.Equal(t, "C", Resolve("A", decisions))
+	assert.Equal(t, "C", Resolve("C", decisions))
+	assert.Equal(t, "Z", Resolve("Z", decisions))
+	assert.Equal(t, "Y", Resolve("X", decisions))
+}
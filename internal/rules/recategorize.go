@@ -0,0 +1,143 @@
+package rules
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"fin-web/internal/model"
+)
+
+// RecategorizeOptions selects which transactions to re-evaluate. Dates are
+// inclusive and either may be empty. KeepManual leaves alone any transaction
+// whose category a person picked.
+type RecategorizeOptions struct {
+	StartDate  string
+	EndDate    string
+	KeepManual bool
+}
+
+// Change is one transaction whose category re-evaluation would move.
+// Transaction holds its current state, with the current label in
+// CustomCategory.
+type Change struct {
+	Transaction model.Transaction
+	CategoryID  int
+	Category    string
+	RuleID      sql.NullInt64
+}
+
+// Move counts the changes going from one category to another.
+type Move struct {
+	From  string
+	To    string
+	Count int
+}
+
+// Plan re-runs categorization (rules first, then category values, as at
+// import) over the selected transactions and returns the ones that would
+// change. Transactions nothing matches keep their category. Nothing is
+// written.
+func Plan(conn *sql.DB, opts RecategorizeOptions) ([]Change, error) {
+	engine, err := Load(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := model.GetCategories(conn)
+	if err != nil {
+		return nil, err
+	}
+	labels := map[int]string{}
+	for _, c := range categories {
+		labels[c.ID] = c.Label
+	}
+
+	transactions, err := model.GetTransactionsInRange(conn, opts.StartDate, opts.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []Change{}
+	for _, t := range transactions {
+		if opts.KeepManual && t.CategorySource.String == model.CategorySourceManual {
+			continue
+		}
+
+		change := Change{Transaction: t}
+		if r := engine.Match(t); r != nil {
+			change.CategoryID = r.CategoryID
+			change.RuleID = sql.NullInt64{Valid: true, Int64: int64(r.ID)}
+		} else {
+			matches, err := model.SearchCategories(conn, []string{strings.ToLower(t.Name)})
+			if err != nil {
+				return nil, err
+			}
+			if len(matches) == 0 {
+				continue
+			}
+			change.CategoryID = matches[0].ID
+		}
+
+		if t.CategoryID.Valid && int(t.CategoryID.Int32) == change.CategoryID {
+			continue
+		}
+
+		change.Category = labels[change.CategoryID]
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// Apply writes changes in one SQL transaction and returns how many were made.
+// A transaction recategorized since Plan ran, by hand or otherwise, is
+// skipped.
+func Apply(conn *sql.DB, changes []Change) (int, error) {
+	tx, err := conn.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	applied := 0
+	for _, c := range changes {
+		ok, err := model.RecategorizeTransaction(tx, c.Transaction, c.CategoryID, c.RuleID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to recategorize %s: %w", c.Transaction.ID, err)
+		}
+		if ok {
+			applied++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return applied, nil
+}
+
+// Summarize groups changes by their from and to categories, in the order each
+// pair first appears.
+func Summarize(changes []Change) []Move {
+	moves := []Move{}
+	index := map[[2]string]int{}
+	for _, c := range changes {
+		from := c.Transaction.CustomCategory.String
+		if !c.Transaction.CategoryID.Valid {
+			from = "Uncategorized"
+		}
+
+		key := [2]string{from, c.Category}
+		i, ok := index[key]
+		if !ok {
+			i = len(moves)
+			index[key] = i
+			moves = append(moves, Move{From: from, To: c.Category})
+		}
+		moves[i].Count++
+	}
+
+	return moves
+}
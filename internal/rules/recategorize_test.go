@@ -0,0 +1,146 @@
+package rules
+
+import (
+	"database/sql"
+	"testing"
+
+	"fin-web/internal/model"
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seedTransaction(t *testing.T, db *sql.DB, id string, name string, date string, categoryID sql.NullInt32, ruleID sql.NullInt64) {
+	t.Helper()
+
+	source := sql.NullString{}
+	switch {
+	case ruleID.Valid:
+		source = sql.NullString{Valid: true, String: model.CategorySourceRule}
+	case categoryID.Valid:
+		source = sql.NullString{Valid: true, String: model.CategorySourceImport}
+	}
+
+	require.NoError(t, model.CreateTransaction(db, model.Transaction{
+		ID:             id,
+		Name:           name,
+		Amount:         10,
+		Date:           date,
+		Source:         "citi",
+		Account:        "citi",
+		CategoryID:     categoryID,
+		RuleID:         ruleID,
+		CategorySource: source,
+	}))
+}
+
+func categoryOf(t *testing.T, db *sql.DB, id string) sql.NullInt32 {
+	t.Helper()
+
+	var categoryID sql.NullInt32
+	require.NoError(t, db.QueryRow("SELECT category_id FROM transactions WHERE id = ?", id).Scan(&categoryID))
+	return categoryID
+}
+
+func TestPlanFindsChangesInRange(t *testing.T) {
+	db := testutil.NewDB(t)
+	groceries := testutil.SeedCategory(t, db, "Groceries", 1, "whole foods")
+	dining := testutil.SeedCategory(t, db, "Dining", 2, "chipotle")
+	home := testutil.SeedCategory(t, db, "Home", 3, "home depot")
+	ruleID, err := model.CreateCategoryRule(db, model.CategoryRule{Priority: 1, MatchType: model.RuleMatchPrefix, Pattern: "amazon", CategoryID: home})
+	require.NoError(t, err)
+
+	seedTransaction(t, db, "uncategorized", "WHOLE FOODS #10", "2026-03-02", sql.NullInt32{}, sql.NullInt64{})
+	seedTransaction(t, db, "wrong", "CHIPOTLE 88", "2026-03-03", sql.NullInt32{Valid: true, Int32: int32(groceries)}, sql.NullInt64{})
+	seedTransaction(t, db, "settled", "WHOLE FOODS #11", "2026-03-04", sql.NullInt32{Valid: true, Int32: int32(groceries)}, sql.NullInt64{})
+	seedTransaction(t, db, "by-rule", "AMAZON MKTPL", "2026-03-05", sql.NullInt32{}, sql.NullInt64{})
+	seedTransaction(t, db, "no-match", "MYSTERY SHOP", "2026-03-06", sql.NullInt32{}, sql.NullInt64{})
+	seedTransaction(t, db, "out-of-range", "CHIPOTLE 12", "2026-04-01", sql.NullInt32{}, sql.NullInt64{})
+
+	changes, err := Plan(db, RecategorizeOptions{StartDate: "2026-03-01", EndDate: "2026-03-31"})
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+
+	assert.Equal(t, "uncategorized", changes[0].Transaction.ID)
+	assert.Equal(t, groceries, changes[0].CategoryID)
+	assert.False(t, changes[0].RuleID.Valid)
+
+	assert.Equal(t, "wrong", changes[1].Transaction.ID)
+	assert.Equal(t, "Groceries", changes[1].Transaction.CustomCategory.String)
+	assert.Equal(t, dining, changes[1].CategoryID)
+	assert.Equal(t, "Dining", changes[1].Category)
+
+	assert.Equal(t, "by-rule", changes[2].Transaction.ID)
+	assert.Equal(t, home, changes[2].CategoryID)
+	assert.Equal(t, int64(ruleID), changes[2].RuleID.Int64)
+
+	assert.Equal(t, []Move{
+		{From: "Uncategorized", To: "Groceries", Count: 1},
+		{From: "Groceries", To: "Dining", Count: 1},
+		{From: "Uncategorized", To: "Home", Count: 1},
+	}, Summarize(changes))
+
+	// Planning is a dry run.
+	assert.False(t, categoryOf(t, db, "uncategorized").Valid)
+}
+
+func TestPlanKeepManual(t *testing.T) {
+	db := testutil.NewDB(t)
+	groceries := testutil.SeedCategory(t, db, "Groceries", 1, "whole foods")
+	dining := testutil.SeedCategory(t, db, "Dining", 2, "chipotle")
+	ruleID, err := model.CreateCategoryRule(db, model.CategoryRule{Priority: 1, MatchType: model.RuleMatchContains, Pattern: "catering", CategoryID: groceries})
+	require.NoError(t, err)
+
+	seedTransaction(t, db, "manual", "CHIPOTLE 88", "2026-03-03", sql.NullInt32{}, sql.NullInt64{})
+	require.NoError(t, model.UpdateTransaction(db, "manual", model.UpdateTransactionParams{CategoryID: &groceries}))
+	seedTransaction(t, db, "ruled", "CHIPOTLE 89", "2026-03-04", sql.NullInt32{Valid: true, Int32: int32(groceries)}, sql.NullInt64{Valid: true, Int64: int64(ruleID)})
+	seedTransaction(t, db, "uncategorized", "CHIPOTLE 90", "2026-03-05", sql.NullInt32{}, sql.NullInt64{})
+
+	changes, err := Plan(db, RecategorizeOptions{KeepManual: true})
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "ruled", changes[0].Transaction.ID)
+	assert.Equal(t, "uncategorized", changes[1].Transaction.ID)
+	assert.Equal(t, dining, changes[1].CategoryID)
+
+	changes, err = Plan(db, RecategorizeOptions{})
+	require.NoError(t, err)
+	assert.Len(t, changes, 3)
+}
+
+func TestApplyRecategorizes(t *testing.T) {
+	db := testutil.NewDB(t)
+	groceries := testutil.SeedCategory(t, db, "Groceries", 1, "whole foods")
+	dining := testutil.SeedCategory(t, db, "Dining", 2, "chipotle")
+	home := testutil.SeedCategory(t, db, "Home", 3, "ikea")
+	ruleID, err := model.CreateCategoryRule(db, model.CategoryRule{Priority: 1, MatchType: model.RuleMatchPrefix, Pattern: "chipotle", CategoryID: dining})
+	require.NoError(t, err)
+
+	seedTransaction(t, db, "a", "CHIPOTLE 88", "2026-03-03", sql.NullInt32{Valid: true, Int32: int32(groceries)}, sql.NullInt64{})
+	seedTransaction(t, db, "b", "WHOLE FOODS", "2026-03-04", sql.NullInt32{}, sql.NullInt64{})
+
+	changes, err := Plan(db, RecategorizeOptions{})
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+
+	// "b" is categorized by hand after the preview, so applying leaves it.
+	require.NoError(t, model.UpdateTransaction(db, "b", model.UpdateTransactionParams{CategoryID: &home}))
+
+	applied, err := Apply(db, changes)
+	require.NoError(t, err)
+	assert.Equal(t, 1, applied)
+
+	assert.Equal(t, int32(dining), categoryOf(t, db, "a").Int32)
+	assert.Equal(t, int32(home), categoryOf(t, db, "b").Int32)
+
+	a, err := model.GetTransaction(db, "a")
+	require.NoError(t, err)
+	assert.Equal(t, int64(ruleID), a.RuleID.Int64)
+	assert.Equal(t, model.CategorySourceRule, a.CategorySource.String)
+	assert.True(t, a.CategoryAssignedAt.Valid)
+
+	b, err := model.GetTransaction(db, "b")
+	require.NoError(t, err)
+	assert.Equal(t, model.CategorySourceManual, b.CategorySource.String)
+}
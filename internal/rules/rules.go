@@ -0,0 +1,155 @@
+package rules
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"fin-web/internal/model"
+	"fin-web/internal/util"
+)
+
+// Engine evaluates category rules against transactions. Rules are compiled
+// once, so load an Engine per import rather than per row.
+type Engine struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	model.CategoryRule
+	re       *regexp.Regexp
+	merchant string
+}
+
+// Load compiles every saved rule.
+func Load(conn *sql.DB) (*Engine, error) {
+	rules, err := model.GetCategoryRules(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(rules)
+}
+
+// New compiles rules, which must already be in priority order.
+func New(rules []model.CategoryRule) (*Engine, error) {
+	e := &Engine{}
+	for _, r := range rules {
+		cr, err := compile(r)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", r.ID, err)
+		}
+		e.rules = append(e.rules, cr)
+	}
+
+	return e, nil
+}
+
+// Validate checks that a rule can be evaluated, e.g. that its regex parses.
+func Validate(r model.CategoryRule) error {
+	_, err := compile(r)
+	return err
+}
+
+func compile(r model.CategoryRule) (compiledRule, error) {
+	cr := compiledRule{CategoryRule: r}
+
+	switch r.MatchType {
+	case model.RuleMatchContains, model.RuleMatchExact, model.RuleMatchPrefix:
+	case model.RuleMatchRegex:
+		re, err := regexp.Compile("(?i)" + r.Pattern)
+		if err != nil {
+			return cr, err
+		}
+		cr.re = re
+	case model.RuleMatchMerchant:
+		cr.merchant = util.NormalizeMerchant(r.Pattern)
+	default:
+		return cr, fmt.Errorf("unknown match type %q", r.MatchType)
+	}
+
+	return cr, nil
+}
+
+// Match returns the first rule that applies to t, or nil.
+func (e *Engine) Match(t model.Transaction) *model.CategoryRule {
+	for i := range e.rules {
+		if e.rules[i].matches(t) {
+			return &e.rules[i].CategoryRule
+		}
+	}
+	return nil
+}
+
+// Apply categorizes t with the first matching rule and reports whether one
+// matched. A rule's reimbursement flag only ever sets IsReimbursement.
+func (e *Engine) Apply(t *model.Transaction) bool {
+	r := e.Match(*t)
+	if r == nil {
+		return false
+	}
+
+	t.CategoryID = sql.NullInt32{Valid: true, Int32: int32(r.CategoryID)}
+	t.RuleID = sql.NullInt64{Valid: true, Int64: int64(r.ID)}
+	t.CategorySource = sql.NullString{Valid: true, String: model.CategorySourceRule}
+	if r.IsReimbursement {
+		t.IsReimbursement = true
+	}
+
+	return true
+}
+
+func (r compiledRule) matches(t model.Transaction) bool {
+	if !r.matchesName(t.Name) {
+		return false
+	}
+
+	if r.MinAmount.Valid && t.Amount < r.MinAmount.Float64 {
+		return false
+	}
+	if r.MaxAmount.Valid && t.Amount > r.MaxAmount.Float64 {
+		return false
+	}
+
+	if r.Source.Valid && !strings.EqualFold(r.Source.String, t.Source) {
+		return false
+	}
+	if r.Account.Valid && !strings.EqualFold(r.Account.String, t.Account) {
+		return false
+	}
+
+	if r.DayMin.Valid || r.DayMax.Valid {
+		if len(t.Date) < 10 {
+			return false
+		}
+		var day int32
+		if _, err := fmt.Sscanf(t.Date[8:10], "%d", &day); err != nil {
+			return false
+		}
+		if r.DayMin.Valid && day < r.DayMin.Int32 {
+			return false
+		}
+		if r.DayMax.Valid && day > r.DayMax.Int32 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r compiledRule) matchesName(name string) bool {
+	switch r.MatchType {
+	case model.RuleMatchContains:
+		return strings.Contains(strings.ToLower(name), strings.ToLower(r.Pattern))
+	case model.RuleMatchExact:
+		return strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(r.Pattern))
+	case model.RuleMatchPrefix:
+		return strings.HasPrefix(strings.ToLower(strings.TrimSpace(name)), strings.ToLower(r.Pattern))
+	case model.RuleMatchRegex:
+		return r.re.MatchString(name)
+	case model.RuleMatchMerchant:
+		return r.merchant != "" && util.NormalizeMerchant(name) == r.merchant
+	}
+	return false
+}
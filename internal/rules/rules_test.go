@@ -0,0 +1,110 @@
+package rules
+
+import (
+	"database/sql"
+	"strconv"
+	"testing"
+
+	"fin-web/internal/model"
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func txn(name string, amount float64, date string) model.Transaction {
+	return model.Transaction{Name: name, Amount: amount, Date: date, Source: "citi", Account: "citi"}
+}
+
+func TestMatchTypes(t *testing.T) {
+	tests := []struct {
+		name      string
+		matchType string
+		pattern   string
+		txnName   string
+		want      bool
+	}{
+		{name: "contains ignores case", matchType: model.RuleMatchContains, pattern: "amazon", txnName: "AMAZON MKTPL*2K4", want: true},
+		{name: "contains misses", matchType: model.RuleMatchContains, pattern: "amazon", txnName: "AMZN", want: false},
+		{name: "exact", matchType: model.RuleMatchExact, pattern: "netflix.com", txnName: "NETFLIX.COM", want: true},
+		{name: "exact rejects longer names", matchType: model.RuleMatchExact, pattern: "netflix.com", txnName: "NETFLIX.COM LOS GATOS", want: false},
+		{name: "prefix", matchType: model.RuleMatchPrefix, pattern: "venmo", txnName: "VENMO *JANE DOE", want: true},
+		{name: "prefix only at start", matchType: model.RuleMatchPrefix, pattern: "venmo", txnName: "FROM VENMO", want: false},
+		{name: "regex", matchType: model.RuleMatchRegex, pattern: `^shell\s+\d+`, txnName: "SHELL 57442", want: true},
+		{name: "merchant key", matchType: model.RuleMatchMerchant, pattern: "STARBUCKS STORE 456", txnName: "STARBUCKS STORE 123 WA", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := New([]model.CategoryRule{{ID: 1, MatchType: tt.matchType, Pattern: tt.pattern, CategoryID: 7}})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, e.Match(txn(tt.txnName, 10, "2026-02-04")) != nil)
+		})
+	}
+}
+
+func TestConditions(t *testing.T) {
+	// "Amazon over $200 is Home" and "rent on the 1st-5th from Venmo".
+	e, err := New([]model.CategoryRule{
+		{ID: 1, MatchType: model.RuleMatchContains, Pattern: "amazon", MinAmount: sql.NullFloat64{Valid: true, Float64: 200}, CategoryID: 1},
+		{ID: 2, MatchType: model.RuleMatchPrefix, Pattern: "venmo", Source: sql.NullString{Valid: true, String: "CITI"}, DayMin: sql.NullInt32{Valid: true, Int32: 1}, DayMax: sql.NullInt32{Valid: true, Int32: 5}, CategoryID: 2},
+		{ID: 3, MatchType: model.RuleMatchContains, Pattern: "amazon", MaxAmount: sql.NullFloat64{Valid: true, Float64: 199.99}, Account: sql.NullString{Valid: true, String: "other"}, CategoryID: 3},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, e.Match(txn("AMAZON", 250, "2026-02-10")).ID)
+	assert.Nil(t, e.Match(txn("AMAZON", 50, "2026-02-10")), "rule 3 needs another account")
+	assert.Equal(t, 2, e.Match(txn("VENMO *LANDLORD", -1500, "2026-02-03")).ID)
+	assert.Nil(t, e.Match(txn("VENMO *LANDLORD", -1500, "2026-02-06")))
+}
+
+func TestFirstRuleWins(t *testing.T) {
+	e, err := New([]model.CategoryRule{
+		{ID: 5, MatchType: model.RuleMatchContains, Pattern: "uber eats", CategoryID: 1},
+		{ID: 6, MatchType: model.RuleMatchContains, Pattern: "uber", CategoryID: 2},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, e.Match(txn("UBER EATS 123", 20, "2026-02-04")).ID)
+	assert.Equal(t, 6, e.Match(txn("UBER TRIP", 20, "2026-02-04")).ID)
+}
+
+func TestApply(t *testing.T) {
+	e, err := New([]model.CategoryRule{
+		{ID: 4, MatchType: model.RuleMatchExact, Pattern: "employer reimb", CategoryID: 9, IsReimbursement: true},
+	})
+	require.NoError(t, err)
+
+	t1 := txn("EMPLOYER REIMB", -80, "2026-02-04")
+	require.True(t, e.Apply(&t1))
+	assert.Equal(t, int32(9), t1.CategoryID.Int32)
+	assert.Equal(t, int64(4), t1.RuleID.Int64)
+	assert.True(t, t1.IsReimbursement)
+
+	t2 := txn("SOMETHING ELSE", 1, "2026-02-04")
+	assert.False(t, e.Apply(&t2))
+	assert.False(t, t2.CategoryID.Valid)
+}
+
+func TestValidateRejectsBadRegex(t *testing.T) {
+	require.Error(t, Validate(model.CategoryRule{MatchType: model.RuleMatchRegex, Pattern: "(unclosed"}))
+	require.Error(t, Validate(model.CategoryRule{MatchType: "fuzzy", Pattern: "x"}))
+	require.NoError(t, Validate(model.CategoryRule{MatchType: model.RuleMatchRegex, Pattern: "ok+"}))
+}
+
+func TestLoadSkipsRulesForDeletedCategories(t *testing.T) {
+	db := testutil.NewDB(t)
+	home := testutil.SeedCategory(t, db, "Home", 1, "ikea")
+	gone := testutil.SeedCategory(t, db, "Gone", 2, "gone")
+
+	for _, catID := range []int{home, gone} {
+		_, err := model.CreateCategoryRule(db, model.CategoryRule{Priority: catID, MatchType: model.RuleMatchContains, Pattern: "x", CategoryID: catID})
+		require.NoError(t, err)
+	}
+	require.NoError(t, model.DeleteCategory(db, strconv.Itoa(gone)))
+
+	e, err := Load(db)
+	require.NoError(t, err)
+	require.Len(t, e.rules, 1)
+	assert.Equal(t, home, e.rules[0].CategoryID)
+}
@@ -26,13 +26,30 @@ func (p *Provider) GetPrefix() string {
 	return "schwab"
 }
 
+// Detect claims JSON objects with a PostedTransactions or PendingTransactions
+// key. The header may cut the document short, so it is matched textually
+// rather than decoded.
+func (p *Provider) Detect(header []byte) int {
+	trimmed := strings.TrimSpace(string(header))
+	if !strings.HasPrefix(trimmed, "{") {
+		return 0
+	}
+	if strings.Contains(trimmed, `"PostedTransactions"`) || strings.Contains(trimmed, `"PendingTransactions"`) {
+		return 100
+	}
+	return 0
+}
+
+type statementTransaction struct {
+	Description string     `json:"Description"`
+	Date        CustomDate `json:"Date"`
+	Withdrawal  string     `json:"Withdrawal"`
+	Deposit     string     `json:"Deposit"`
+}
+
 type statementSchema struct {
-	PostedTransactions []struct {
-		Description string     `json:"Description"`
-		Date        CustomDate `json:"Date"`
-		Withdrawal  string     `json:"Withdrawal"`
-		Deposit     string     `json:"Deposit"`
-	} `json:"PostedTransactions"`
+	PendingTransactions []statementTransaction `json:"PendingTransactions"`
+	PostedTransactions  []statementTransaction `json:"PostedTransactions"`
 }
 
 func (p *Provider) ParseFile(filePath string) ([]model.Transaction, error) {
@@ -48,31 +65,39 @@ func (p *Provider) ParseFile(filePath string) ([]model.Transaction, error) {
 
 	var transactions []model.Transaction
 	for _, t := range statement.PostedTransactions {
-		var amount float64
-		if t.Withdrawal != "" {
-			amount, _ = util.ParseAmount(t.Withdrawal)
-		} else if t.Deposit != "" {
-			a, _ := util.ParseAmount(t.Deposit)
-			amount = -a
-		}
-
-		normalizedName := strings.ToLower(t.Description)
-		var cc sql.NullInt32
-		categories, _ := model.SearchCategories(p.DB, []string{normalizedName})
-		if len(categories) > 0 {
-			cc = sql.NullInt32{Valid: true, Int32: int32(categories[0].ID)}
-		}
-
-		transactions = append(transactions, model.Transaction{
-			ID:         uuid.NewString(),
-			Name:       t.Description,
-			Source:     "schwab",
-			Account:    "schwab",
-			Date:       t.Date.Format("2006-01-02"),
-			Amount:     amount,
-			CategoryID: cc,
-		})
+		transactions = append(transactions, p.toTransaction(t, model.TransactionPosted))
+	}
+	for _, t := range statement.PendingTransactions {
+		transactions = append(transactions, p.toTransaction(t, model.TransactionPending))
 	}
 
 	return transactions, nil
 }
+
+func (p *Provider) toTransaction(t statementTransaction, status string) model.Transaction {
+	var amount float64
+	if t.Withdrawal != "" {
+		amount, _ = util.ParseAmount(t.Withdrawal)
+	} else if t.Deposit != "" {
+		a, _ := util.ParseAmount(t.Deposit)
+		amount = -a
+	}
+
+	normalizedName := strings.ToLower(t.Description)
+	var cc sql.NullInt32
+	categories, _ := model.SearchCategories(p.DB, []string{normalizedName})
+	if len(categories) > 0 {
+		cc = sql.NullInt32{Valid: true, Int32: int32(categories[0].ID)}
+	}
+
+	return model.Transaction{
+		ID:         uuid.NewString(),
+		Name:       t.Description,
+		Source:     "schwab",
+		Account:    "schwab",
+		Date:       t.Date.Format("2006-01-02"),
+		Amount:     amount,
+		CategoryID: cc,
+		Status:     status,
+	}
+}
@@ -1,6 +1,7 @@
 package schwab
 
 import (
+	"os"
 	"testing"
 
 	"fin-web/internal/testutil"
@@ -50,3 +51,29 @@ func TestParseFileInvalidJSON(t *testing.T) {
 func TestGetPrefix(t *testing.T) {
 	assert.Equal(t, "schwab", NewSchwabProvider(nil).GetPrefix())
 }
+
+func TestDetect(t *testing.T) {
+	p := NewSchwabProvider(nil)
+
+	header, err := os.ReadFile("testdata/sample.json")
+	require.NoError(t, err)
+	assert.Equal(t, 100, p.Detect(header))
+
+	assert.Equal(t, 100, p.Detect([]byte(`{"PendingTransactions": [`)))
+	assert.Equal(t, 0, p.Detect([]byte("[{\"Description\": \"X\"}]")))
+}
+
+func TestParseFilePending(t *testing.T) {
+	p := NewSchwabProvider(testutil.NewDB(t))
+	txns, err := p.ParseFile("testdata/pending.json")
+	require.NoError(t, err)
+	require.Len(t, txns, 2)
+
+	assert.Equal(t, "STARBUCKS STORE 123", txns[0].Name)
+	assert.False(t, txns[0].Pending())
+
+	assert.Equal(t, "SQ *BLUE BOTTLE", txns[1].Name)
+	assert.Equal(t, "2026-02-06", txns[1].Date)
+	assert.InDelta(t, 12.40, txns[1].Amount, 1e-9)
+	assert.True(t, txns[1].Pending())
+}
@@ -0,0 +1,126 @@
+// Package suggest proposes categories for uncategorized transactions from how
+// earlier transactions with the same normalized merchant were categorized.
+// Build is pure so it can be unit-tested; Load feeds it from the database.
+package suggest
+
+import (
+	"database/sql"
+
+	"fin-web/internal/model"
+	"fin-web/internal/util"
+)
+
+// Suggestion is the category most of a merchant's history agrees on.
+type Suggestion struct {
+	CategoryID int
+	Label      string
+	// Count is how many earlier transactions are in the category, out of
+	// Total for the merchant.
+	Count int
+	Total int
+	// Confidence is Count/(Total+1): the share of history that agrees,
+	// discounted so a single earlier transaction counts for 50% rather than
+	// 100%.
+	Confidence float64
+}
+
+// Percent is Confidence as a whole percentage, for display.
+func (s Suggestion) Percent() int {
+	return int(s.Confidence*100 + 0.5)
+}
+
+// Index holds a suggestion for every merchant with a majority category.
+type Index struct {
+	merchants map[string]Suggestion
+}
+
+type tally struct {
+	label string
+	count int
+}
+
+// Build indexes categorized history. Uncategorized rows are skipped. A
+// merchant gets a suggestion only when more than half its history is in one
+// category; ties between equal counts can't be a majority, so never arise.
+func Build(history []model.Transaction) *Index {
+	tallies := map[string]map[int]*tally{}
+	totals := map[string]int{}
+	for _, t := range history {
+		if !t.CategoryID.Valid {
+			continue
+		}
+
+		merchant := util.NormalizeMerchant(t.Name)
+		if merchant == "" {
+			continue
+		}
+
+		byCategory, ok := tallies[merchant]
+		if !ok {
+			byCategory = map[int]*tally{}
+			tallies[merchant] = byCategory
+		}
+
+		id := int(t.CategoryID.Int32)
+		c, ok := byCategory[id]
+		if !ok {
+			c = &tally{}
+			byCategory[id] = c
+		}
+		c.label = t.CustomCategory.String
+		c.count++
+		totals[merchant]++
+	}
+
+	idx := &Index{merchants: map[string]Suggestion{}}
+	for merchant, byCategory := range tallies {
+		total := totals[merchant]
+		for id, c := range byCategory {
+			if c.count*2 <= total {
+				continue
+			}
+
+			idx.merchants[merchant] = Suggestion{
+				CategoryID: id,
+				Label:      c.label,
+				Count:      c.count,
+				Total:      total,
+				Confidence: float64(c.count) / float64(total+1),
+			}
+		}
+	}
+
+	return idx
+}
+
+// Load builds an index from every categorized transaction.
+func Load(conn *sql.DB) (*Index, error) {
+	history, err := model.GetTransactionsInRange(conn, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	return Build(history), nil
+}
+
+// Suggest returns the suggestion for the merchant behind name, if any.
+func (idx *Index) Suggest(name string) (Suggestion, bool) {
+	s, ok := idx.merchants[util.NormalizeMerchant(name)]
+	return s, ok
+}
+
+// ForTransactions maps the ID of each uncategorized transaction with a
+// suggestion to it.
+func (idx *Index) ForTransactions(transactions []model.Transaction) map[string]Suggestion {
+	suggestions := map[string]Suggestion{}
+	for _, t := range transactions {
+		if t.CategoryID.Valid {
+			continue
+		}
+		if s, ok := idx.Suggest(t.Name); ok {
+			suggestions[t.ID] = s
+		}
+	}
+
+	return suggestions
+}
@@ -0,0 +1,79 @@
+package suggest
+
+import (
+	"database/sql"
+	"testing"
+
+	"fin-web/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func categorized(id string, name string, categoryID int, label string) model.Transaction {
+	return model.Transaction{
+		ID:             id,
+		Name:           name,
+		CategoryID:     sql.NullInt32{Valid: true, Int32: int32(categoryID)},
+		CustomCategory: sql.NullString{Valid: true, String: label},
+	}
+}
+
+func TestSuggestMajorityCategory(t *testing.T) {
+	idx := Build([]model.Transaction{
+		categorized("1", "SWEETGREEN 1234 NEW YORK NY", 1, "Dining"),
+		categorized("2", "SWEETGREEN 5678 NEW YORK NY", 1, "Dining"),
+		categorized("3", "SWEETGREEN 9012 BROOKLYN NY", 2, "Work"),
+		{ID: "4", Name: "SWEETGREEN 3456 NEW YORK NY"},
+	})
+
+	s, ok := idx.Suggest("SWEETGREEN 7777 NEW YORK NY")
+	require.True(t, ok)
+	assert.Equal(t, 1, s.CategoryID)
+	assert.Equal(t, "Dining", s.Label)
+	assert.Equal(t, 2, s.Count)
+	assert.Equal(t, 3, s.Total, "uncategorized history doesn't count")
+	assert.InDelta(t, 0.5, s.Confidence, 0.001)
+	assert.Equal(t, 50, s.Percent())
+}
+
+func TestSuggestNeedsMajority(t *testing.T) {
+	idx := Build([]model.Transaction{
+		categorized("1", "VENMO *ALEX", 1, "Dining"),
+		categorized("2", "VENMO *SAM", 2, "Rent"),
+	})
+
+	_, ok := idx.Suggest("VENMO *JO")
+	assert.False(t, ok)
+
+	_, ok = idx.Suggest("NEVER SEEN")
+	assert.False(t, ok)
+}
+
+func TestConfidenceGrowsWithHistory(t *testing.T) {
+	one := Build([]model.Transaction{categorized("1", "NETFLIX.COM", 1, "Subscriptions")})
+	s, ok := one.Suggest("NETFLIX.COM")
+	require.True(t, ok)
+	assert.Equal(t, 50, s.Percent())
+
+	history := []model.Transaction{}
+	for i := range 9 {
+		history = append(history, categorized(string(rune('a'+i)), "NETFLIX.COM", 1, "Subscriptions"))
+	}
+	s, ok = Build(history).Suggest("NETFLIX.COM")
+	require.True(t, ok)
+	assert.Equal(t, 90, s.Percent())
+}
+
+func TestForTransactionsSkipsCategorized(t *testing.T) {
+	idx := Build([]model.Transaction{categorized("1", "NETFLIX.COM", 1, "Subscriptions")})
+
+	suggestions := idx.ForTransactions([]model.Transaction{
+		{ID: "new", Name: "NETFLIX.COM"},
+		categorized("done", "NETFLIX.COM", 2, "Other"),
+		{ID: "unknown", Name: "MYSTERY"},
+	})
+
+	assert.Len(t, suggestions, 1)
+	assert.Equal(t, 1, suggestions["new"].CategoryID)
+}
@@ -3,26 +3,28 @@ package testutil
 
 import (
 	"database/sql"
-	_ "embed"
 	"testing"
 
-	_ "github.com/mattn/go-sqlite3"
+	"fin-web/internal/db"
+
 	"github.com/stretchr/testify/require"
 )
 
-//go:embed schema.sql
-var schema string
-
 // NewDB returns an isolated in-memory SQLite database with the full
-// application schema applied. The connection is closed when the test finishes.
+// application schema applied from the same migrations production runs. The
+// connection is closed when the test finishes.
 func NewDB(t *testing.T) *sql.DB {
 	t.Helper()
 
-	conn, err := sql.Open("sqlite3", ":memory:")
+	conn, err := sql.Open(db.DriverName, ":memory:")
 	require.NoError(t, err)
 	t.Cleanup(func() { conn.Close() })
 
-	_, err = conn.Exec(schema)
+	// Every new connection to ":memory:" is a fresh, empty database, so pin the
+	// pool to the one connection that holds the schema.
+	conn.SetMaxOpenConns(1)
+
+	_, err = db.Migrate(conn)
 	require.NoError(t, err)
 
 	return conn
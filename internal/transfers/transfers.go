@@ -0,0 +1,135 @@
+// Package transfers pairs the two sides of money moved between our own
+// accounts: an outflow on one account and an inflow of the same amount on
+// another a few days apart. Match is pure; Detect runs it over the database
+// and stores what it finds for review.
+package transfers
+
+import (
+	"database/sql"
+	"math"
+	"sort"
+	"time"
+
+	"fin-web/internal/model"
+)
+
+// DefaultWindowDays is how far apart the two sides may post when no window is
+// given. Card payments usually land within a business day or two.
+const DefaultWindowDays = 3
+
+const dateLayout = "2006-01-02"
+
+// Pair is a matched outflow (positive amount) and inflow (negative amount).
+type Pair struct {
+	Outflow model.Transaction
+	Inflow  model.Transaction
+	Days    int
+}
+
+type candidate struct {
+	out, in int
+	days    int
+}
+
+// Match pairs transactions with opposite signs and equal amounts on different
+// accounts posted at most windowDays apart. Each transaction joins at most one
+// pair; the closest dates are paired first. skip rejects specific pairs by
+// outflow and inflow ID, and may be nil.
+func Match(transactions []model.Transaction, windowDays int, skip func(outflowID string, inflowID string) bool) []Pair {
+	dates := make([]time.Time, len(transactions))
+	byCents := map[int64][]int{}
+	for i, t := range transactions {
+		d, err := time.Parse(dateLayout, t.Date)
+		if err != nil {
+			continue
+		}
+		dates[i] = d
+
+		if t.Amount < 0 {
+			cents := int64(math.Round(-t.Amount * 100))
+			byCents[cents] = append(byCents[cents], i)
+		}
+	}
+
+	candidates := []candidate{}
+	for out, t := range transactions {
+		if t.Amount <= 0 || dates[out].IsZero() {
+			continue
+		}
+
+		for _, in := range byCents[int64(math.Round(t.Amount*100))] {
+			inflow := transactions[in]
+			if inflow.Account == t.Account {
+				continue
+			}
+
+			days := int(math.Abs(dates[out].Sub(dates[in]).Hours() / 24))
+			if days > windowDays {
+				continue
+			}
+			if skip != nil && skip(t.ID, inflow.ID) {
+				continue
+			}
+
+			candidates = append(candidates, candidate{out: out, in: in, days: days})
+		}
+	}
+
+	// Closest first; ties go to the earlier outflow so the result is stable.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].days != candidates[j].days {
+			return candidates[i].days < candidates[j].days
+		}
+		if candidates[i].out != candidates[j].out {
+			return candidates[i].out < candidates[j].out
+		}
+		return candidates[i].in < candidates[j].in
+	})
+
+	used := map[int]bool{}
+	pairs := []Pair{}
+	for _, c := range candidates {
+		if used[c.out] || used[c.in] {
+			continue
+		}
+		used[c.out] = true
+		used[c.in] = true
+
+		pairs = append(pairs, Pair{
+			Outflow: transactions[c.out],
+			Inflow:  transactions[c.in],
+			Days:    c.days,
+		})
+	}
+
+	return pairs
+}
+
+// Detect matches every transaction not already in a transfer, leaving out
+// pairs that were unlinked before, and stores the new pairs as detected.
+func Detect(conn *sql.DB, windowDays int) ([]Pair, error) {
+	transactions, err := model.TransferCandidates(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	unlinked, err := model.UnlinkedTransferPairs(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := Match(transactions, windowDays, func(outflowID string, inflowID string) bool {
+		return unlinked[[2]string{outflowID, inflowID}]
+	})
+
+	ids := make([][2]string, len(pairs))
+	for i, p := range pairs {
+		ids[i] = [2]string{p.Outflow.ID, p.Inflow.ID}
+	}
+
+	if err := model.CreateTransfers(conn, ids); err != nil {
+		return nil, err
+	}
+
+	return pairs, nil
+}
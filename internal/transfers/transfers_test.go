@@ -0,0 +1,159 @@
+package transfers
+
+import (
+	"database/sql"
+	"strconv"
+	"testing"
+
+	"fin-web/internal/model"
+	"fin-web/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func txn(id string, account string, amount float64, date string) model.Transaction {
+	return model.Transaction{ID: id, Name: id, Account: account, Source: account, Amount: amount, Date: date}
+}
+
+func ids(pairs []Pair) [][2]string {
+	out := [][2]string{}
+	for _, p := range pairs {
+		out = append(out, [2]string{p.Outflow.ID, p.Inflow.ID})
+	}
+	return out
+}
+
+func TestMatchPairsOppositeAmountsAcrossAccounts(t *testing.T) {
+	pairs := Match([]model.Transaction{
+		txn("checking-payment", "bofa", 512.34, "2026-03-01"),
+		txn("card-payment", "citi", -512.34, "2026-03-03"),
+		txn("same-account-refund", "bofa", -512.34, "2026-03-01"),
+		txn("too-late", "citi", -80, "2026-03-20"),
+		txn("groceries", "bofa", 80, "2026-03-02"),
+	}, 3, nil)
+
+	assert.Equal(t, [][2]string{{"checking-payment", "card-payment"}}, ids(pairs))
+	assert.Equal(t, 2, pairs[0].Days)
+}
+
+func TestMatchPrefersClosestDates(t *testing.T) {
+	pairs := Match([]model.Transaction{
+		txn("out-1", "bofa", 100, "2026-03-01"),
+		txn("out-2", "bofa", 100, "2026-03-05"),
+		txn("in-1", "citi", -100, "2026-03-03"),
+		txn("in-2", "citi", -100, "2026-03-05"),
+	}, 3, nil)
+
+	assert.ElementsMatch(t, [][2]string{{"out-2", "in-2"}, {"out-1", "in-1"}}, ids(pairs))
+}
+
+func TestMatchSkipsRejectedPairs(t *testing.T) {
+	transactions := []model.Transaction{
+		txn("out", "bofa", 100, "2026-03-01"),
+		txn("in", "citi", -100, "2026-03-01"),
+	}
+
+	pairs := Match(transactions, 3, func(outflowID string, inflowID string) bool {
+		return outflowID == "out" && inflowID == "in"
+	})
+	assert.Empty(t, pairs)
+}
+
+func seed(t *testing.T, db *sql.DB, transactions ...model.Transaction) {
+	t.Helper()
+	for _, tx := range transactions {
+		require.NoError(t, model.CreateTransaction(db, tx))
+	}
+}
+
+func TestDetectStoresPairsAndRespectsUnlinking(t *testing.T) {
+	db := testutil.NewDB(t)
+	seed(t, db,
+		txn("out", "bofa", 250, "2026-03-01"),
+		txn("in", "citi", -250, "2026-03-02"),
+	)
+
+	pairs, err := Detect(db, DefaultWindowDays)
+	require.NoError(t, err)
+	require.Len(t, pairs, 1)
+
+	// Already linked, so nothing new.
+	pairs, err = Detect(db, DefaultWindowDays)
+	require.NoError(t, err)
+	assert.Empty(t, pairs)
+
+	ts, err := model.GetTransfers(db)
+	require.NoError(t, err)
+	require.Len(t, ts, 1)
+	assert.Equal(t, model.TransferDetected, ts[0].Status)
+
+	require.NoError(t, model.SetTransferStatus(db, "1", model.TransferUnlinked))
+	pairs, err = Detect(db, DefaultWindowDays)
+	require.NoError(t, err)
+	assert.Empty(t, pairs, "an unlinked pair isn't proposed again")
+
+	ts, err = model.GetTransfers(db)
+	require.NoError(t, err)
+	assert.Empty(t, ts)
+}
+
+func TestConfirmedTransfersLeaveTotals(t *testing.T) {
+	db := testutil.NewDB(t)
+	payment := testutil.SeedCategory(t, db, "Card Payment", 1, "payment")
+	_, err := db.Exec("UPDATE categories SET type = 'neutral' WHERE id = ?", payment)
+	require.NoError(t, err)
+
+	seed(t, db,
+		model.Transaction{ID: "out", Name: "CITI PAYMENT", Account: "bofa", Source: "bofa", Amount: 300, Date: "2026-03-01", CategoryID: sql.NullInt32{Valid: true, Int32: int32(payment)}},
+		model.Transaction{ID: "in", Name: "PAYMENT THANK YOU", Account: "citi", Source: "citi", Amount: -300, Date: "2026-03-02", CategoryID: sql.NullInt32{Valid: true, Int32: int32(payment)}},
+	)
+
+	expenses, err := model.SumTransactions(db, model.QueryTransactionsFilters{Type: "expenses"})
+	require.NoError(t, err)
+	assert.Equal(t, 300.0, expenses)
+
+	// A detected pair is only a suggestion and still counts.
+	_, err = Detect(db, DefaultWindowDays)
+	require.NoError(t, err)
+
+	expenses, err = model.SumTransactions(db, model.QueryTransactionsFilters{Type: "expenses"})
+	require.NoError(t, err)
+	assert.Equal(t, 300.0, expenses)
+
+	ts, err := model.GetTransfers(db)
+	require.NoError(t, err)
+	require.Len(t, ts, 1)
+	require.NoError(t, model.SetTransferStatus(db, strconv.Itoa(ts[0].ID), model.TransferConfirmed))
+
+	expenses, err = model.SumTransactions(db, model.QueryTransactionsFilters{Type: "expenses"})
+	require.NoError(t, err)
+	assert.Zero(t, expenses)
+
+	income, err := model.SumTransactions(db, model.QueryTransactionsFilters{Type: "income"})
+	require.NoError(t, err)
+	assert.Zero(t, income)
+
+	// Both sides still show in transaction lists.
+	txns, err := model.QueryTransactions(db, model.QueryTransactionsFilters{})
+	require.NoError(t, err)
+	assert.Len(t, txns, 2)
+}
+
+func TestTransfersFollowTheirTransactions(t *testing.T) {
+	db := testutil.NewDB(t)
+	seed(t, db,
+		txn("out", "bofa", 250, "2026-03-01"),
+		txn("in", "citi", -250, "2026-03-02"),
+	)
+
+	require.Error(t, model.CreateTransfers(db, [][2]string{{"out", "missing"}}))
+	require.NoError(t, model.CreateTransfers(db, [][2]string{{"out", "in"}}))
+
+	_, err := db.Exec("DELETE FROM transactions WHERE id = 'in'")
+	require.NoError(t, err)
+
+	var n int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM transfers").Scan(&n))
+	assert.Zero(t, n)
+}
@@ -1,25 +1,56 @@
 package worker
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"log"
 	"os"
 	"path"
 	"strings"
 
 	"fin-web/internal/model"
+	"fin-web/internal/rules"
 )
 
+// Provider parses one bank's statement exports. GetPrefix names the provider
+// on import batches. Detect inspects the first HeaderSize bytes of a file and
+// returns how confident the provider is that it can parse it, from 0 (not
+// this provider's format) to 100 (an exact match).
 type Provider interface {
 	GetPrefix() string
+	Detect(header []byte) (confidence int)
 	ParseFile(filePath string) ([]model.Transaction, error)
 }
 
+// HeaderSize is how much of a file Detect gets to see.
+const HeaderSize = 4096
+
 type BaseWorker struct {
 	DB      *sql.DB
 	DirPath string
 }
 
+// FileResult summarizes the import of one statement file. Duplicates are rows
+// whose fingerprint was already stored, or pending rows that have since
+// posted; Reconciled rows settled a stored pending row instead of being
+// inserted. Rejected rows were unusable (no name or date) and never reached
+// the database. Err is set when the file failed as a whole, in which case
+// nothing from it is kept. BatchID is the import_batches row recording the
+// run.
+type FileResult struct {
+	File       string
+	BatchID    int
+	Inserted   int
+	Duplicates int
+	Reconciled int
+	Rejected   int
+	Err        error
+}
+
 func NewBaseWorker(db *sql.DB, dp string) *BaseWorker {
 	return &BaseWorker{
 		DB:      db,
@@ -27,51 +58,370 @@ func NewBaseWorker(db *sql.DB, dp string) *BaseWorker {
 	}
 }
 
-func (bw *BaseWorker) Process(p Provider) error {
+// Process imports every file in DirPath that one of providers claims and
+// deletes it once imported. Files nothing claims are logged and left alone.
+func (bw *BaseWorker) Process(providers []Provider) ([]FileResult, error) {
 	entries, err := os.ReadDir(bw.DirPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	results := []FileResult{}
 	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasPrefix(entry.Name(), p.GetPrefix()) {
+		if entry.IsDir() {
 			continue
 		}
 
 		filePath := path.Join(bw.DirPath, entry.Name())
 
-		transactions, err := p.ParseFile(filePath)
+		p, err := DetectProvider(providers, filePath)
 		if err != nil {
-			fmt.Printf("failed to parse %s: %v\n", entry.Name(), err)
+			log.Printf("error reading %s: %v", entry.Name(), err)
+			continue
+		}
+		if p == nil {
+			log.Printf("no provider recognised %s, skipping", entry.Name())
 			continue
 		}
 
-		var insertedIDs []string
-		var failed bool
+		result := bw.ImportFile(p, filePath, entry.Name())
+		results = append(results, result)
+		if result.Err != nil {
+			continue
+		}
 
-		for _, t := range transactions {
-			if err := model.CreateTransaction(bw.DB, t); err != nil {
-				fmt.Printf("failed to create transaction %s: %v\n", t.Name, err)
-				failed = true
-				break
-			}
-			insertedIDs = append(insertedIDs, t.ID)
+		err = os.Remove(filePath)
+		if err != nil {
+			log.Printf("error deleting %s: %v", entry.Name(), err)
 		}
+	}
+	return results, nil
+}
 
-		if failed {
-			for _, id := range insertedIDs {
-				if err := model.DeleteTransaction(bw.DB, id); err != nil {
-					fmt.Printf("failed to rollback transaction ID %s: %v\n", id, err)
-				}
-			}
+// ImportFile parses one file and inserts its rows inside a single SQL
+// transaction, so a file is either imported completely or not at all. Every
+// run, successful or not, leaves an import_batches row behind. fileName is
+// recorded on the batch; it is passed separately because uploads are staged
+// under a different path than the name the user chose.
+func (bw *BaseWorker) ImportFile(p Provider, filePath string, fileName string) FileResult {
+	result := FileResult{File: fileName}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to read: %w", err)
+		return result
+	}
+	sum := sha256.Sum256(data)
+	batch := model.ImportBatch{
+		FileName: fileName,
+		Provider: p.GetPrefix(),
+		FileHash: hex.EncodeToString(sum[:]),
+	}
+
+	transactions, err := p.ParseFile(filePath)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to parse: %w", err)
+		bw.recordFailedBatch(batch, &result)
+		return result
+	}
+
+	if err := bw.applyRules(transactions); err != nil {
+		result.Err = fmt.Errorf("failed to apply category rules: %w", err)
+		bw.recordFailedBatch(batch, &result)
+		return result
+	}
+
+	valid, rejected := prepareTransactions(transactions)
+	result.Rejected = rejected
+
+	if err := bw.insertBatch(batch, valid, &result); err != nil {
+		result.Inserted = 0
+		result.Duplicates = 0
+		result.Err = err
+		bw.recordFailedBatch(batch, &result)
+	}
+
+	return result
+}
+
+func (bw *BaseWorker) insertBatch(batch model.ImportBatch, transactions []model.Transaction, result *FileResult) error {
+	tx, err := bw.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	batch.Status = model.ImportBatchRunning
+	batchID, err := model.CreateImportBatch(tx, batch)
+	if err != nil {
+		return fmt.Errorf("failed to create import batch: %w", err)
+	}
+
+	for _, t := range transactions {
+		t.BatchID = sql.NullInt64{Valid: true, Int64: int64(batchID)}
+
+		status, err := reconcile(tx, t)
+		if err != nil {
+			return fmt.Errorf("failed to reconcile transaction %s: %w", t.Name, err)
+		}
+		if status == reconciled {
+			result.Reconciled++
+			continue
+		}
+		if status == alreadyPosted {
+			result.Duplicates++
 			continue
 		}
 
-		err = os.Remove(filePath)
+		inserted, err := model.CreateTransactionIfNew(tx, t)
 		if err != nil {
-			fmt.Println("Error deleting file:", err)
+			return fmt.Errorf("failed to create transaction %s: %w", t.Name, err)
 		}
 
+		if inserted {
+			result.Inserted++
+		} else {
+			result.Duplicates++
+		}
 	}
+
+	err = model.FinishImportBatch(tx, batchID, model.FinishImportBatchParams{
+		Inserted:   result.Inserted,
+		Duplicates: result.Duplicates,
+		Rejected:   result.Rejected,
+		Reconciled: result.Reconciled,
+		Status:     model.ImportBatchCompleted,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to finish import batch: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	result.BatchID = batchID
 	return nil
 }
+
+type reconcileStatus int
+
+const (
+	// unmatched rows are inserted as usual.
+	unmatched reconcileStatus = iota
+	// reconciled posted rows settled a stored pending row.
+	reconciled
+	// alreadyPosted pending rows match a stored posted row.
+	alreadyPosted
+)
+
+// reconcile settles a stored pending row with t when t is its posted
+// counterpart, and spots pending rows that have since posted. Posted rows
+// already stored by fingerprint are left for the insert to count as
+// duplicates.
+func reconcile(conn model.Querier, t model.Transaction) (reconcileStatus, error) {
+	if t.Pending() {
+		posted, err := model.HasPostedMatch(conn, t, model.DefaultReconcileTolerance)
+		if err != nil || !posted {
+			return unmatched, err
+		}
+		return alreadyPosted, nil
+	}
+
+	existing, err := model.ExistingFingerprints(conn, []string{t.Fingerprint})
+	if err != nil || existing[t.Fingerprint] {
+		return unmatched, err
+	}
+
+	pending, ok, err := model.FindPendingMatch(conn, t, model.DefaultReconcileTolerance)
+	if err != nil || !ok {
+		return unmatched, err
+	}
+
+	if err := model.ReconcilePending(conn, pending, t); err != nil {
+		return unmatched, err
+	}
+
+	return reconciled, nil
+}
+
+// recordFailedBatch writes a failed batch outside the (rolled back) import
+// transaction so the failure itself is still on record.
+func (bw *BaseWorker) recordFailedBatch(batch model.ImportBatch, result *FileResult) {
+	batch.Status = model.ImportBatchFailed
+	batch.Rejected = result.Rejected
+	batch.Error = sql.NullString{Valid: true, String: result.Err.Error()}
+
+	batchID, err := model.CreateImportBatch(bw.DB, batch)
+	if err != nil {
+		log.Printf("failed to record failed import of %s: %v", batch.FileName, err)
+		return
+	}
+
+	result.BatchID = batchID
+}
+
+// DetectProvider returns the provider most confident it can parse the file at
+// filePath, or nil if none claims it. Ties go to the earlier provider.
+func DetectProvider(providers []Provider, filePath string) (Provider, error) {
+	header, err := readHeader(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var best Provider
+	bestConfidence := 0
+	for _, p := range providers {
+		if confidence := p.Detect(header); confidence > bestConfidence {
+			best = p
+			bestConfidence = confidence
+		}
+	}
+
+	return best, nil
+}
+
+// readHeader returns up to HeaderSize bytes from the start of a file, without
+// a UTF-8 byte order mark so providers can match their headers literally.
+func readHeader(filePath string) ([]byte, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	header := make([]byte, HeaderSize)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+
+	return bytes.TrimPrefix(header[:n], []byte("\xef\xbb\xbf")), nil
+}
+
+// FilePreview is what importing a file would do, without writing anything.
+type FilePreview struct {
+	File     string
+	Provider string
+	Rows     []PreviewRow
+	Rejected int
+}
+
+// PreviewRow is a parsed transaction and whether it is already stored.
+// Pending is the stored pending row a posted row would settle.
+type PreviewRow struct {
+	Transaction model.Transaction
+	Duplicate   bool
+	Pending     *model.Transaction
+}
+
+// NewRows counts the rows an import would actually insert.
+func (fp FilePreview) NewRows() int {
+	n := 0
+	for _, r := range fp.Rows {
+		if !r.Duplicate && r.Pending == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// PreviewFile parses a file and flags rows that ImportFile would skip as
+// duplicates, so a user can review an upload before committing it.
+func (bw *BaseWorker) PreviewFile(p Provider, filePath string, fileName string) (FilePreview, error) {
+	preview := FilePreview{File: fileName, Provider: p.GetPrefix()}
+
+	transactions, err := p.ParseFile(filePath)
+	if err != nil {
+		return preview, err
+	}
+
+	if err := bw.applyRules(transactions); err != nil {
+		return preview, err
+	}
+
+	valid, rejected := prepareTransactions(transactions)
+	preview.Rejected = rejected
+
+	fingerprints := make([]string, len(valid))
+	for i, t := range valid {
+		fingerprints[i] = t.Fingerprint
+	}
+
+	existing, err := model.ExistingFingerprints(bw.DB, fingerprints)
+	if err != nil {
+		return preview, err
+	}
+
+	for _, t := range valid {
+		row := PreviewRow{
+			Transaction: t,
+			Duplicate:   existing[t.Fingerprint],
+		}
+
+		switch {
+		case row.Duplicate:
+		case t.Pending():
+			row.Duplicate, err = model.HasPostedMatch(bw.DB, t, model.DefaultReconcileTolerance)
+		default:
+			var (
+				pending model.Transaction
+				ok      bool
+			)
+			pending, ok, err = model.FindPendingMatch(bw.DB, t, model.DefaultReconcileTolerance)
+			if ok {
+				row.Pending = &pending
+			}
+		}
+		if err != nil {
+			return preview, err
+		}
+
+		preview.Rows = append(preview.Rows, row)
+	}
+
+	return preview, nil
+}
+
+// applyRules lets saved category rules override the category a provider
+// picked from category_values, and records which of the two assigned it.
+func (bw *BaseWorker) applyRules(transactions []model.Transaction) error {
+	engine, err := rules.Load(bw.DB)
+	if err != nil {
+		return err
+	}
+
+	for i := range transactions {
+		t := &transactions[i]
+		if !engine.Apply(t) && t.CategoryID.Valid {
+			t.CategorySource = sql.NullString{Valid: true, String: model.CategorySourceImport}
+		}
+	}
+
+	return nil
+}
+
+// prepareTransactions drops unusable rows and fingerprints the rest, returning
+// the importable rows and how many were rejected.
+func prepareTransactions(transactions []model.Transaction) ([]model.Transaction, int) {
+	var valid []model.Transaction
+	rejected := 0
+	for _, t := range transactions {
+		if !isImportable(t) {
+			rejected++
+			continue
+		}
+		valid = append(valid, t)
+	}
+
+	model.AssignFingerprints(valid)
+
+	return valid, rejected
+}
+
+// isImportable rejects rows a parser produced without a usable name or date.
+// Parsers that swallow date errors emit Go's zero date, so that counts as
+// missing too.
+func isImportable(t model.Transaction) bool {
+	return strings.TrimSpace(t.Name) != "" && t.Date != "" && t.Date != "0001-01-01"
+}
@@ -0,0 +1,323 @@
+package worker
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"fin-web/internal/model"
+	"fin-web/internal/testutil"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProvider hands back a fixed set of rows for any file starting with
+// "fake", minting fresh IDs per parse the way the real providers do.
+type fakeProvider struct {
+	rows []model.Transaction
+}
+
+func (p *fakeProvider) GetPrefix() string { return "fake" }
+
+func (p *fakeProvider) Detect(header []byte) int { return detectFake(header) }
+
+func (p *fakeProvider) ParseFile(filePath string) ([]model.Transaction, error) {
+	out := make([]model.Transaction, len(p.rows))
+	for i, r := range p.rows {
+		r.ID = uuid.NewString()
+		out[i] = r
+	}
+	return out, nil
+}
+
+func detectFake(header []byte) int {
+	if strings.HasPrefix(string(header), "fake") {
+		return 100
+	}
+	return 0
+}
+
+func writeStatement(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+}
+
+func TestProcessSkipsRowsAlreadyImported(t *testing.T) {
+	db := testutil.NewDB(t)
+	dir := t.TempDir()
+
+	coffee := model.Transaction{Name: "STARBUCKS", Amount: 5.75, Date: "2026-02-04", Source: "fake", Account: "fake"}
+	p := &fakeProvider{rows: []model.Transaction{
+		coffee,
+		coffee, // a genuine second coffee the same day
+		{Name: "", Amount: 1, Date: "2026-02-04", Source: "fake", Account: "fake"},
+		{Name: "BAD DATE", Amount: 1, Date: "0001-01-01", Source: "fake", Account: "fake"},
+	}}
+	bw := NewBaseWorker(db, dir)
+
+	writeStatement(t, dir, "fake-jan.csv", "fake")
+	results, err := bw.Process([]Provider{p})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, 2, results[0].Inserted)
+	assert.Equal(t, 0, results[0].Duplicates)
+	assert.Equal(t, 2, results[0].Rejected)
+
+	batch, err := model.GetImportBatch(db, strconv.Itoa(results[0].BatchID))
+	require.NoError(t, err)
+	assert.Equal(t, model.ImportBatchCompleted, batch.Status)
+	assert.Equal(t, "fake", batch.Provider)
+	assert.Equal(t, "fake-jan.csv", batch.FileName)
+	assert.NotEmpty(t, batch.FileHash)
+	assert.Equal(t, 2, batch.Inserted)
+	assert.Equal(t, 2, batch.Rejected)
+
+	var tagged int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM transactions WHERE batch_id = ?", batch.ID).Scan(&tagged))
+	assert.Equal(t, 2, tagged)
+
+	_, err = os.Stat(filepath.Join(dir, "fake-jan.csv"))
+	assert.True(t, os.IsNotExist(err), "imported file should be removed")
+
+	// An overlapping export with one extra row only adds the new row.
+	p.rows = append(p.rows, model.Transaction{Name: "WHOLE FOODS", Amount: 42.10, Date: "2026-02-05", Source: "fake", Account: "fake"})
+	writeStatement(t, dir, "fake-feb.csv", "fake")
+	results, err = bw.Process([]Provider{p})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, 1, results[0].Inserted)
+	assert.Equal(t, 2, results[0].Duplicates)
+
+	txns, err := model.QueryTransactions(db, model.QueryTransactionsFilters{})
+	require.NoError(t, err)
+	assert.Len(t, txns, 3)
+}
+
+func TestProcessLeavesUnclaimedFiles(t *testing.T) {
+	dir := t.TempDir()
+	// The name no longer matters, only the content.
+	writeStatement(t, dir, "fake-looking.csv", "other")
+
+	results, err := NewBaseWorker(testutil.NewDB(t), dir).Process([]Provider{&fakeProvider{}})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	_, err = os.Stat(filepath.Join(dir, "fake-looking.csv"))
+	assert.NoError(t, err)
+}
+
+// scoredProvider claims every file with a fixed confidence.
+type scoredProvider struct {
+	name       string
+	confidence int
+}
+
+func (p scoredProvider) GetPrefix() string { return p.name }
+
+func (p scoredProvider) Detect(header []byte) int { return p.confidence }
+
+func (p scoredProvider) ParseFile(filePath string) ([]model.Transaction, error) { return nil, nil }
+
+func TestDetectProviderPicksMostConfident(t *testing.T) {
+	dir := t.TempDir()
+	writeStatement(t, dir, "statement.csv", "anything")
+	filePath := filepath.Join(dir, "statement.csv")
+
+	p, err := DetectProvider([]Provider{
+		scoredProvider{name: "weak", confidence: 20},
+		scoredProvider{name: "strong", confidence: 90},
+		scoredProvider{name: "tied", confidence: 90},
+	}, filePath)
+	require.NoError(t, err)
+	require.NotNil(t, p)
+	assert.Equal(t, "strong", p.GetPrefix())
+
+	p, err = DetectProvider([]Provider{scoredProvider{name: "none"}}, filePath)
+	require.NoError(t, err)
+	assert.Nil(t, p)
+}
+
+// clashingProvider returns rows sharing one primary key, so the second insert
+// fails partway through the file.
+type clashingProvider struct{}
+
+func (clashingProvider) GetPrefix() string { return "fake" }
+
+func (clashingProvider) Detect(header []byte) int { return detectFake(header) }
+
+func (clashingProvider) ParseFile(filePath string) ([]model.Transaction, error) {
+	return []model.Transaction{
+		{ID: "same", Name: "FIRST", Amount: 1, Date: "2026-02-04", Source: "fake", Account: "fake"},
+		{ID: "same", Name: "SECOND", Amount: 2, Date: "2026-02-04", Source: "fake", Account: "fake"},
+	}, nil
+}
+
+func TestProcessRollsBackWholeFileOnFailure(t *testing.T) {
+	db := testutil.NewDB(t)
+	dir := t.TempDir()
+	writeStatement(t, dir, "fake-bad.csv", "fake")
+
+	results, err := NewBaseWorker(db, dir).Process([]Provider{clashingProvider{}})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Error(t, results[0].Err)
+	assert.Equal(t, 0, results[0].Inserted)
+
+	txns, err := model.QueryTransactions(db, model.QueryTransactionsFilters{})
+	require.NoError(t, err)
+	assert.Empty(t, txns, "no row from a failed file should survive")
+
+	batch, err := model.GetImportBatch(db, strconv.Itoa(results[0].BatchID))
+	require.NoError(t, err)
+	assert.Equal(t, model.ImportBatchFailed, batch.Status)
+	assert.True(t, batch.Error.Valid)
+
+	_, err = os.Stat(filepath.Join(dir, "fake-bad.csv"))
+	assert.NoError(t, err, "a failed file stays in place to retry")
+}
+
+func TestProcessAppliesCategoryRules(t *testing.T) {
+	db := testutil.NewDB(t)
+	dir := t.TempDir()
+	home := testutil.SeedCategory(t, db, "Home", 1, "home")
+	ruleID, err := model.CreateCategoryRule(db, model.CategoryRule{
+		Priority:   1,
+		MatchType:  model.RuleMatchContains,
+		Pattern:    "amazon",
+		MinAmount:  sql.NullFloat64{Valid: true, Float64: 200},
+		CategoryID: home,
+	})
+	require.NoError(t, err)
+
+	p := &fakeProvider{rows: []model.Transaction{
+		{Name: "AMAZON MKTPL", Amount: 250, Date: "2026-02-04", Source: "fake", Account: "fake"},
+		{Name: "AMAZON MKTPL", Amount: 20, Date: "2026-02-05", Source: "fake", Account: "fake"},
+	}}
+	writeStatement(t, dir, "fake-feb.csv", "fake")
+	_, err = NewBaseWorker(db, dir).Process([]Provider{p})
+	require.NoError(t, err)
+
+	txns, err := model.QueryTransactions(db, model.QueryTransactionsFilters{OrderBy: "amount", OrderDirection: "DESC"})
+	require.NoError(t, err)
+	require.Len(t, txns, 2)
+	assert.Equal(t, int32(home), txns[0].CategoryID.Int32)
+	assert.Equal(t, int64(ruleID), txns[0].RuleID.Int64)
+	assert.False(t, txns[1].CategoryID.Valid, "the small order is under the rule's minimum")
+	assert.False(t, txns[1].RuleID.Valid)
+}
+
+func TestProcessRecordsCategorySource(t *testing.T) {
+	db := testutil.NewDB(t)
+	dir := t.TempDir()
+	home := testutil.SeedCategory(t, db, "Home", 1, "home")
+	_, err := model.CreateCategoryRule(db, model.CategoryRule{Priority: 1, MatchType: model.RuleMatchContains, Pattern: "amazon", CategoryID: home})
+	require.NoError(t, err)
+
+	p := &fakeProvider{rows: []model.Transaction{
+		{Name: "AMAZON MKTPL", Amount: 25, Date: "2026-02-04", Source: "fake", Account: "fake"},
+		{Name: "HOME DEPOT", Amount: 30, Date: "2026-02-05", Source: "fake", Account: "fake", CategoryID: sql.NullInt32{Valid: true, Int32: int32(home)}},
+		{Name: "MYSTERY", Amount: 35, Date: "2026-02-06", Source: "fake", Account: "fake"},
+	}}
+	writeStatement(t, dir, "fake-feb.csv", "fake")
+	_, err = NewBaseWorker(db, dir).Process([]Provider{p})
+	require.NoError(t, err)
+
+	txns, err := model.GetTransactionsInRange(db, "", "")
+	require.NoError(t, err)
+	require.Len(t, txns, 3)
+	assert.Equal(t, model.CategorySourceRule, txns[0].CategorySource.String)
+	assert.Equal(t, model.CategorySourceImport, txns[1].CategorySource.String)
+	assert.True(t, txns[1].CategoryAssignedAt.Valid)
+	assert.False(t, txns[2].CategorySource.Valid)
+	assert.False(t, txns[2].CategoryAssignedAt.Valid)
+}
+
+func TestProcessReconcilesPendingWithPosted(t *testing.T) {
+	db := testutil.NewDB(t)
+	dir := t.TempDir()
+	dining := testutil.SeedCategory(t, db, "Dining", 1, "dining")
+	bw := NewBaseWorker(db, dir)
+
+	p := &fakeProvider{rows: []model.Transaction{
+		{Name: "SQ *BLUE BOTTLE 4417", Amount: 40, Date: "2026-02-04", Source: "fake", Account: "fake", Status: model.TransactionPending},
+		{Name: "SHELL OIL", Amount: 30, Date: "2026-02-04", Source: "fake", Account: "fake", Status: model.TransactionPending},
+	}}
+	writeStatement(t, dir, "fake-pending.csv", "fake")
+	results, err := bw.Process([]Provider{p})
+	require.NoError(t, err)
+	require.Equal(t, 2, results[0].Inserted)
+
+	txns, err := model.GetTransactionsInRange(db, "", "")
+	require.NoError(t, err)
+	var coffeeID string
+	for _, tx := range txns {
+		if tx.Name == "SQ *BLUE BOTTLE 4417" {
+			coffeeID = tx.ID
+		}
+	}
+	category := dining
+	require.NoError(t, model.UpdateTransaction(db, coffeeID, model.UpdateTransactionParams{CategoryID: &category}))
+
+	// The coffee posts two days later with a tip; the gas station hasn't
+	// posted, and a re-export still lists the coffee as pending.
+	p.rows = []model.Transaction{
+		{Name: "SQ *BLUE BOTTLE 4417", Amount: 46, Date: "2026-02-06", Source: "fake", Account: "fake"},
+		{Name: "SQ *BLUE BOTTLE 4417", Amount: 40, Date: "2026-02-04", Source: "fake", Account: "fake", Status: model.TransactionPending},
+		{Name: "UNRELATED", Amount: 30, Date: "2026-02-05", Source: "fake", Account: "fake"},
+	}
+	writeStatement(t, dir, "fake-posted.csv", "fake")
+	results, err = bw.Process([]Provider{p})
+	require.NoError(t, err)
+	require.NoError(t, results[0].Err)
+	assert.Equal(t, 1, results[0].Reconciled)
+	assert.Equal(t, 1, results[0].Duplicates)
+	assert.Equal(t, 1, results[0].Inserted)
+
+	batch, err := model.GetImportBatch(db, strconv.Itoa(results[0].BatchID))
+	require.NoError(t, err)
+	assert.Equal(t, 1, batch.Reconciled)
+
+	coffee, err := model.GetTransaction(db, coffeeID)
+	require.NoError(t, err)
+	assert.Equal(t, model.TransactionPosted, coffee.Status)
+	assert.Equal(t, 46.0, coffee.Amount)
+	assert.Equal(t, "2026-02-06", coffee.Date)
+	assert.Equal(t, int32(dining), coffee.CategoryID.Int32, "the category picked while pending stays")
+
+	txns, err = model.GetTransactionsInRange(db, "", "")
+	require.NoError(t, err)
+	assert.Len(t, txns, 3)
+
+	// Importing the posted export again changes nothing.
+	writeStatement(t, dir, "fake-posted-again.csv", "fake")
+	results, err = bw.Process([]Provider{p})
+	require.NoError(t, err)
+	assert.Equal(t, 0, results[0].Inserted)
+	assert.Equal(t, 0, results[0].Reconciled)
+	assert.Equal(t, 3, results[0].Duplicates)
+}
+
+func TestPreviewFlagsReconciledRows(t *testing.T) {
+	db := testutil.NewDB(t)
+	dir := t.TempDir()
+	require.NoError(t, model.CreateTransaction(db, model.Transaction{ID: "pending", Name: "AMAZON.COM", Amount: 20, Date: "2026-02-04", Source: "fake", Account: "fake", Status: model.TransactionPending}))
+
+	p := &fakeProvider{rows: []model.Transaction{
+		{Name: "AMAZON.COM", Amount: 20, Date: "2026-02-05", Source: "fake", Account: "fake"},
+		{Name: "AMAZON.COM", Amount: 20, Date: "2026-02-05", Source: "fake", Account: "other"},
+	}}
+	writeStatement(t, dir, "fake.csv", "fake")
+	preview, err := NewBaseWorker(db, dir).PreviewFile(p, filepath.Join(dir, "fake.csv"), "fake.csv")
+	require.NoError(t, err)
+	require.Len(t, preview.Rows, 2)
+	require.NotNil(t, preview.Rows[0].Pending)
+	assert.Equal(t, "pending", preview.Rows[0].Pending.ID)
+	assert.Nil(t, preview.Rows[1].Pending, "another account's charge")
+	assert.Equal(t, 1, preview.NewRows())
+}